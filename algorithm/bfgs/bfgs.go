@@ -27,16 +27,17 @@ package bfgs
 import   "fmt"
 import   "math"
 
-import . "github.com/pbenner/autodiff"
+import   "github.com/pbenner/autodiff"
 //import . "github.com/pbenner/autodiff/algorithm"
 import   "github.com/pbenner/autodiff/algorithm/matrixInverse"
 
 /* -------------------------------------------------------------------------- */
 
-type Objective func(Vector) (Scalar, error)
+type Objective func(autodiff.Vector) (autodiff.Scalar, error)
 
+// Initial approximation to the Hessian matrix
 type Hessian struct {
-  Value Matrix
+  Value autodiff.Matrix
 }
 
 type Epsilon struct {
@@ -44,21 +45,23 @@ type Epsilon struct {
 }
 
 type Hook struct {
-  Value func(x, gradient Vector, y Scalar) bool
+  Value func(x, gradient autodiff.Vector, y autodiff.Scalar) bool
 }
 
 type Constraints struct {
-  Value func(x Vector) bool
+  Value func(x autodiff.Vector) bool
 }
 
 /* -------------------------------------------------------------------------- */
 
 type ObjectiveInSitu struct {
-  Eval func(x, g Vector, y Scalar) error
+  Eval func(x, g autodiff.Vector, y autodiff.Scalar) error
+  // derivative slots of variables in enclosing differentiation contexts
+  Offset int
 }
 
-func newObjectiveInSitu(f Objective) ObjectiveInSitu {
-  g := func(x, g Vector, y Scalar) error {
+func newObjectiveInSitu(f Objective, offset int) ObjectiveInSitu {
+  g := func(x, g autodiff.Vector, y autodiff.Scalar) error {
     z, err := f(x)
     if err != nil {
       return err
@@ -66,16 +69,16 @@ func newObjectiveInSitu(f Objective) ObjectiveInSitu {
     // copy value
     y.Copy(z)
     // copy gradient
-    for i := 0; i < z.GetN(); i++ {
-      g[i].SetValue(z.GetDerivative(1, i))
+    for i := 0; i < len(g); i++ {
+      g[i].SetValue(z.GetDerivative(1, offset+i))
     }
     return nil
   }
-  return ObjectiveInSitu{g}
+  return ObjectiveInSitu{g, offset}
 }
 
-func (f ObjectiveInSitu) Differentiate(x, g Vector, y Scalar) error {
-  x.Variables(1)
+func (f ObjectiveInSitu) Differentiate(x, g autodiff.Vector, y autodiff.Scalar) error {
+  x.VariablesAt(f.Offset, 1)
   if err := f.Eval(x, g, y); err != nil {
     return err
   }
@@ -87,14 +90,14 @@ func (f ObjectiveInSitu) Differentiate(x, g Vector, y Scalar) error {
 /* Broyden–Fletcher–Goldfarb–Shanno (BFGS) algorithm:
  */
 
-func bgfs_computeDirection(x Vector, y Scalar, g Vector, B Matrix, p Vector) {
+func bgfs_computeDirection(x autodiff.Vector, y autodiff.Scalar, g autodiff.Vector, B autodiff.Matrix, p autodiff.Vector) {
   p.MdotV(B, g)
   for i := 0; i < len(x); i++ {
     p[i].Neg(p[i])
   }
 }
 
-func bgfs_backtrackingLineSearch(f ObjectiveInSitu, x1, x2 Vector, y1, y2 Scalar, g1, g2, p1, p2 Vector, a1 Vector, t1, t2 Scalar, constraints Constraints) bool {
+func bgfs_backtrackingLineSearch(f ObjectiveInSitu, x1, x2 autodiff.Vector, y1, y2 autodiff.Scalar, g1, g2, p1, p2 autodiff.Vector, a1 autodiff.Vector, t1, t2 autodiff.Scalar, constraints Constraints) bool {
   c1  := 1e-3
   rho := t2
   rho.Reset()
@@ -130,7 +133,7 @@ func bgfs_backtrackingLineSearch(f ObjectiveInSitu, x1, x2 Vector, y1, y2 Scalar
 }
 
 // update approximation of the Hessian matrix
-func bfgs_updateB(g1, g2, p2 Vector, B1, B2 Matrix, t1, t2 Scalar, t3, t4 Vector, t5, t6 Matrix) {
+func bfgs_updateB(g1, g2, p2 autodiff.Vector, B1, B2 autodiff.Matrix, t1, t2 autodiff.Scalar, t3, t4 autodiff.Vector, t5, t6 autodiff.Matrix) {
   s := p2
   y := t3
   // y = Df(x2) - Df(x1)
@@ -175,7 +178,7 @@ func bfgs_updateB(g1, g2, p2 Vector, B1, B2 Matrix, t1, t2 Scalar, t3, t4 Vector
 }
 
 // update approximation of the inverse Hessian matrix
-func bfgs_updateH(g1, g2, p2 Vector, H1, H2, I Matrix, t1, t2 Scalar, t3, t4 Vector, t5, t6 Matrix) bool {
+func bfgs_updateH(g1, g2, p2 autodiff.Vector, H1, H2, I autodiff.Matrix, t1, t2 autodiff.Scalar, t3, t4 autodiff.Vector, t5, t6 autodiff.Matrix) bool {
   s := p2
   y := t3
   // y = Df(x2) - Df(x1)
@@ -204,32 +207,35 @@ func bfgs_updateH(g1, g2, p2 Vector, H1, H2, I Matrix, t1, t2 Scalar, t3, t4 Vec
   return true
 }
 
-func bfgs(f ObjectiveInSitu, x0 Vector, H0 Matrix, epsilon Epsilon, hook Hook, constraints Constraints) (Vector, error) {
+func bfgs(f ObjectiveInSitu, x0 autodiff.Vector, H0 autodiff.Matrix, epsilon Epsilon, hook Hook, constraints Constraints) (autodiff.Vector, error) {
 
   n := len(x0)
-  t := BareRealType
+  t := autodiff.BareRealType
 
-  a1 := NewVector(t, []float64{1e-8})
-  p1 := NullVector(t, n)
-  p2 := NullVector(t, n)
+  a1 := autodiff.NewVector(t, []float64{1e-8})
+  p1 := autodiff.NullVector(t, n)
+  p2 := autodiff.NullVector(t, n)
   x1 := x0.Clone()
   x2 := x1.Clone()
-  y1 := NullScalar(t)
-  y2 := NullScalar(t)
-  g1 := NullVector(t, n)
-  g2 := NullVector(t, n)
-  H1 := H0.Clone()
-  H2 := NullDenseMatrix(t, n, n)
+  y1 := autodiff.NullScalar(t)
+  y2 := autodiff.NullScalar(t)
+  g1 := autodiff.NullVector(t, n)
+  g2 := autodiff.NullVector(t, n)
+  // approximations of the inverse Hessian carry no derivatives and are
+  // stored as float64 matrices
+  H1 := autodiff.NullDenseFloat64Matrix(n, n)
+  H2 := autodiff.NullDenseFloat64Matrix(n, n)
+  H1.Copy(H0)
   // some temporary variables
-  t1 := NullScalar(t)
-  t2 := NullScalar(t)
-  t3 := NullVector(t, n)
-  t4 := NullVector(t, n)
-  t5 := NullDenseMatrix(t, n, n)
-  t6 := NullDenseMatrix(t, n, n)
-  I  := IdentityMatrix(t, n)
-
-  equals := func(x1, x2 Vector) bool {
+  t1 := autodiff.NullScalar(t)
+  t2 := autodiff.NullScalar(t)
+  t3 := autodiff.NullVector(t, n)
+  t4 := autodiff.NullVector(t, n)
+  t5 := autodiff.NullDenseFloat64Matrix(n, n)
+  t6 := autodiff.NullDenseFloat64Matrix(n, n)
+  I  := autodiff.IdentityDenseFloat64Matrix(n)
+
+  equals := func(x1, x2 autodiff.Vector) bool {
     for i := 0; i < len(x1); i++ {
       if x1[i].GetValue() != x2[i].GetValue() {
         return false
@@ -246,7 +252,7 @@ func bfgs(f ObjectiveInSitu, x0 Vector, H0 Matrix, epsilon Epsilon, hook Hook, c
     return x1, fmt.Errorf("invalid initial value: %s", err)
   }
   // evaluate stop criterion
-  if Vnorm(g1).GetValue() < epsilon.Value {
+  if autodiff.Vnorm(g1).GetValue() < epsilon.Value {
     return x1, fmt.Errorf("initial value satisfies stop criterium")
   }
   // execute hook if available
@@ -277,8 +283,8 @@ func bfgs(f ObjectiveInSitu, x0 Vector, H0 Matrix, epsilon Epsilon, hook Hook, c
         return x1, fmt.Errorf("invalid value: %s", err)
       }
       // evaluate stop criterion
-      if Vnorm(g2).GetValue() < epsilon.Value {
-        break
+      if autodiff.Vnorm(g2).GetValue() < epsilon.Value {
+        return x2, nil
       }
       if ok := bfgs_updateH(g1, g2, p2, H1, H2, I, t1, t2, t3, t4, t5, t6); !ok {
         // reset H to find a new direction
@@ -303,7 +309,7 @@ func bfgs(f ObjectiveInSitu, x0 Vector, H0 Matrix, epsilon Epsilon, hook Hook, c
 // x0: starting point
 // B0: initial approximation to the Hessian matrix
 
-func Run(f Objective, x0 Vector, args ...interface{}) (Vector, error) {
+func Run(f Objective, x0 autodiff.Vector, args ...interface{}) (autodiff.Vector, error) {
 
   hessian     := Hessian{ nil}
   hook        := Hook   { nil}
@@ -327,16 +333,77 @@ func Run(f Objective, x0 Vector, args ...interface{}) (Vector, error) {
     }
   }
   if hessian.Value == nil {
-    hessian.Value = IdentityMatrix(x0.ElementType(), n)
+    hessian.Value = autodiff.IdentityMatrix(x0.ElementType(), n)
   } else {
     r, c := hessian.Value.Dims()
     if n != r || n != c {
-      return nil, fmt.Errorf("argument dimensions do not match, i.e. x0 has length %s and B0 has dimension %dx%d\n", n, r, c)
+      return nil, fmt.Errorf("argument dimensions do not match, i.e. x0 has length %d and B0 has dimension %dx%d\n", n, r, c)
     }
   }
   H, err := matrixInverse.Run(hessian.Value)
   if err != nil {
     return nil, err
   }
-  return bfgs(newObjectiveInSitu(f), x0, H, epsilon, hook, constraints)
+  // variables are placed in a context nested within all variables on which
+  // the objective function depends at x0, so that bfgs may be called within
+  // a differentiated computation
+  if constraints.Value != nil && !constraints.Value(x0) {
+    return x0, fmt.Errorf("invalid initial value: %v", x0)
+  }
+  y0, err := f(x0)
+  if err != nil {
+    return x0, fmt.Errorf("invalid initial value: %s", err)
+  }
+  c := autodiff.ContextOf(x0, autodiff.Vector{y0}).Nested(n)
+  xn, err := bfgs(newObjectiveInSitu(f, c.Offset), x0, H, epsilon, hook, constraints)
+  if err != nil || c.Offset == 0 {
+    return xn, err
+  }
+  return bfgs_differentiateOptimum(f, xn, c)
+}
+
+// Derivatives of the optimum xn with respect to the variables of enclosing
+// contexts are given by the implicit function theorem, i.e.
+//
+//   dxn/dtheta = -H^-1 d^2f/dx dtheta
+//
+// where H is the Hessian of f at xn.
+func bfgs_differentiateOptimum(f Objective, xn autodiff.Vector, c autodiff.DiffContext) (autodiff.Vector, error) {
+  n := len(xn)
+  k := c.Offset
+  x := autodiff.NullVector(xn.ElementType(), n)
+  for i := 0; i < n; i++ {
+    x[i].SetValue(xn[i].GetValue())
+  }
+  c.Variables(2, x...)
+  y, err := f(x)
+  if err != nil {
+    return xn, err
+  }
+  h := make([]float64, n*n)
+  b := make([]float64, n*k)
+  for i := 0; i < n; i++ {
+    for j := 0; j < n; j++ {
+      h[i*n+j] = y.GetHessian(k+i, k+j)
+    }
+    for j := 0; j < k; j++ {
+      b[i*k+j] = y.GetHessian(k+i, j)
+    }
+  }
+  H := autodiff.NewDenseMatrix(autodiff.BareRealType, n, n, h)
+  B := autodiff.NewDenseMatrix(autodiff.BareRealType, n, k, b)
+  Hinv, err := matrixInverse.Run(H)
+  if err != nil {
+    return xn, fmt.Errorf("derivatives of the optimum are not available: %v", err)
+  }
+  D := autodiff.MdotM(Hinv, B)
+  r := autodiff.NullVector(xn.ElementType(), n)
+  for i := 0; i < n; i++ {
+    r[i].SetVariable(0, k, 1)
+    r[i].SetValue(xn[i].GetValue())
+    for j := 0; j < k; j++ {
+      r[i].SetDerivative(1, j, -D.At(i, j).GetValue())
+    }
+  }
+  return r, nil
 }
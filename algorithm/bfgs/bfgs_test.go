@@ -14,15 +14,17 @@
  * along with this program.  If not, see <http://www.gnu.org/licenses/>.
  */
 
-package bfgs
+package bfgs_test
 
 /* -------------------------------------------------------------------------- */
 
 import   "fmt"
+import   "math"
 import   "os"
 import   "testing"
 
 import . "github.com/pbenner/autodiff"
+import   "github.com/pbenner/autodiff/algorithm/bfgs"
 
 /* -------------------------------------------------------------------------- */
 
@@ -52,9 +54,9 @@ func TestBfgsMatyas(t *testing.T) {
 
   x0 := NewVector(RealType, []float64{-2.5,2})
   xr := NewVector(RealType, []float64{0, 0})
-  xn, err := Run(f, x0,
-    Hook{hook},
-    Epsilon{1e-8})
+  xn, err := bfgs.Run(f, x0,
+    bfgs.Hook{hook},
+    bfgs.Epsilon{1e-8})
   if err != nil {
     t.Error(err)
   }
@@ -63,6 +65,28 @@ func TestBfgsMatyas(t *testing.T) {
   }
 }
 
+func TestBfgsConverged(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    // f(x1, x2) = 0.26(x1^2 + x2^2) - 0.48 x1 x2
+    y := Sub(Mul(NewReal(0.26), Add(Mul(x[0], x[0]), Mul(x[1], x[1]))),
+      Mul(NewReal(0.48), Mul(x[0], x[1])))
+    return y, nil
+  }
+  x0 := NewVector(RealType, []float64{-2.5,2})
+  xn, err := bfgs.Run(f, x0, bfgs.Epsilon{1e-8})
+  if err != nil {
+    t.Error(err)
+  }
+  // the returned value must satisfy the stop criterion
+  x := xn.Clone()
+  x.Variables(1)
+  y, _ := f(x)
+  if math.Hypot(y.GetDerivative(1, 0), y.GetDerivative(1, 1)) >= 1e-8 {
+    t.Error("BFGS convergence test failed!")
+  }
+}
+
 func TestBfgsRosenbrock(t *testing.T) {
 
   fp, err := os.Create("bfgs_test2.table")
@@ -93,9 +117,32 @@ func TestBfgsRosenbrock(t *testing.T) {
 
   x0 := NewVector(RealType, []float64{-0.5, 2})
   xr := NewVector(RealType, []float64{   1, 1})
-  xn, err := Run(f, x0,
-    Hook{hook},
-    Epsilon{1e-10})
+  xn, err := bfgs.Run(f, x0,
+    bfgs.Hook{hook},
+    bfgs.Epsilon{1e-10})
+  if err != nil {
+    t.Error(err)
+  }
+  if Vnorm(VsubV(xn, xr)).GetValue() > 1e-8 {
+    t.Error("BFGS Rosenbrock test failed!")
+  }
+}
+
+func TestBfgsTapeReal(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    // f(x1, x2) = (a - x1)^2 + b(x2 - x1^2)^2
+    a := NewTapeReal(  1.0)
+    b := NewTapeReal(100.0)
+    s := Pow(Sub(a, x[0]), NewBareReal(2.0))
+    t := Mul(b, Pow(Sub(x[1], Mul(x[0], x[0])), NewBareReal(2.0)))
+    return Add(s, t), nil
+  }
+
+  x0 := NewVector(TapeRealType, []float64{-0.5, 2})
+  xr := NewVector(TapeRealType, []float64{   1, 1})
+  xn, err := bfgs.Run(f, x0,
+    bfgs.Epsilon{1e-10})
   if err != nil {
     t.Error(err)
   }
@@ -103,3 +150,110 @@ func TestBfgsRosenbrock(t *testing.T) {
     t.Error("BFGS Rosenbrock test failed!")
   }
 }
+
+func TestBfgsNested(t *testing.T) {
+
+  theta := NewReal(3.0)
+  Variables(1, theta)
+
+  f := func(x Vector) (Scalar, error) {
+    // f(x) = (x - theta)^2 + x^2 with minimum at theta/2
+    return Add(Pow(Sub(x[0], theta), NewBareReal(2)), Pow(x[0], NewBareReal(2))), nil
+  }
+  x0 := NewVector(RealType, []float64{0.0})
+
+  xn, err := bfgs.Run(f, x0)
+  if err != nil {
+    t.Error(err)
+  }
+  if math.Abs(xn[0].GetValue() - 1.5) > 1e-6 {
+    t.Error("BFGS nested test failed!")
+  }
+  // derivative of the optimum with respect to theta
+  if math.Abs(xn[0].GetDerivative(1, 0) - 0.5) > 1e-6 {
+    t.Error("BFGS nested test failed!")
+  }
+}
+
+func TestBfgsBeta(t *testing.T) {
+
+  // maximum likelihood estimate of the parameters of a beta distribution
+  d := []float64{0.12, 0.31, 0.25, 0.08, 0.44, 0.19, 0.27, 0.36, 0.15, 0.22}
+
+  f := func(x Vector) (Scalar, error) {
+    // parameters on log scale
+    a := Exp(x[0])
+    b := Exp(x[1])
+    // negative log likelihood
+    y := Mul(NewReal(float64(len(d))), Lbeta(a, b))
+    for i := 0; i < len(d); i++ {
+      y = Sub(y, Mul(Sub(a, NewReal(1.0)), NewReal(math.Log(d[i]))))
+      y = Sub(y, Mul(Sub(b, NewReal(1.0)), NewReal(math.Log1p(-d[i]))))
+    }
+    return y, nil
+  }
+  x0 := NewVector(RealType, []float64{0.0, 0.0})
+  xn, err := bfgs.Run(f, x0, bfgs.Epsilon{1e-5})
+  if err != nil {
+    t.Error(err)
+  }
+  // the estimate satisfies E[log x] = digamma(a) - digamma(a+b)
+  a := Exp(xn[0])
+  b := Exp(xn[1])
+  r := 0.0
+  for i := 0; i < len(d); i++ {
+    r += math.Log(d[i])/float64(len(d))
+  }
+  if math.Abs(Sub(Digamma(a), Digamma(Add(a, b))).GetValue() - r) > 1e-5 {
+    t.Error("BFGS beta test failed!")
+  }
+}
+
+func TestBfgsGraph(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    // f(x1, x2) = (a - x1)^2 + b(x2 - x1^2)^2
+    a := NewScalar(x.ElementType(),   1.0)
+    b := NewScalar(x.ElementType(), 100.0)
+    s := Pow(Sub(a, x[0]), NewBareReal(2.0))
+    t := Mul(b, Pow(Sub(x[1], Mul(x[0], x[0])), NewBareReal(2.0)))
+    return Add(s, t), nil
+  }
+  x0 := NewVector(RealType, []float64{-0.5, 2})
+  xr := NewVector(RealType, []float64{   1, 1})
+
+  // record the objective once and replay it in each iteration
+  g, err := NewGraph(f, x0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  xn, err := bfgs.Run(g.Eval, x0, bfgs.Epsilon{1e-10})
+  if err != nil {
+    t.Error(err)
+  }
+  if Vnorm(VsubV(xn, xr)).GetValue() > 1e-8 {
+    t.Error("BFGS graph test failed!")
+  }
+}
+
+func TestBfgsHuber(t *testing.T) {
+
+  // robust estimate of location with an outlier
+  d := []float64{1.0, 1.2, 0.9, 1.1, 10.0}
+
+  f := func(x Vector) (Scalar, error) {
+    y := Scalar(NewReal(0.0))
+    for i := 0; i < len(d); i++ {
+      y = Add(y, Huber(Sub(x[0], NewReal(d[i])), NewReal(1.0)))
+    }
+    return y, nil
+  }
+  x0 := NewVector(RealType, []float64{0.0})
+  xn, err := bfgs.Run(f, x0, bfgs.Epsilon{1e-8})
+  if err != nil {
+    t.Error(err)
+  }
+  if math.Abs(xn[0].GetValue() - 1.3) > 1e-6 {
+    t.Error("BFGS Huber test failed!")
+  }
+}
@@ -117,6 +117,9 @@ func Run(a Matrix, args ...interface{}) (Matrix, error) {
       panic("Cholesky(): Invalid optional argument!")
     }
   }
+  if ab, ok := a.(*BandMatrix); ok && ab.Symmetric {
+    return choleskyBand(ab, inSitu)
+  }
   if ad, ok := a.(*DenseMatrix); ok {
     t := a.ElementType()
     if t == RealType && inSitu == true {
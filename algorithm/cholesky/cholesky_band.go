@@ -0,0 +1,65 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cholesky
+
+/* -------------------------------------------------------------------------- */
+
+//import   "fmt"
+import   "errors"
+
+import . "github.com/pbenner/autodiff"
+import   "github.com/pbenner/autodiff/algorithm"
+
+/* -------------------------------------------------------------------------- */
+
+// Cholesky factorization of a symmetric band matrix. The factor has the same
+// lower bandwidth as A and is returned as lower triangular band matrix. If
+// inSitu is true, A is converted into the factor.
+func choleskyBand(A *BandMatrix, inSitu bool) (Matrix, error) {
+  n, _  := A.Dims()
+  k, _  := A.Bandwidth()
+  eType := A.ElementType()
+  t     := NewScalar(eType, 0.0)
+  s     := NewScalar(eType, 0.0)
+  L     := A
+  if inSitu {
+    // the lower band of a symmetric matrix has the same layout as
+    // a lower triangular band matrix
+    L.Symmetric = false
+  } else {
+    L = NullBandMatrix(eType, n, k, 0)
+  }
+  for i := 0; i < n; i++ {
+    for j := algorithm.MaxInt(0, i-k); j < (i+1); j++ {
+      s.Reset()
+      for l := algorithm.MaxInt(0, i-k); l < j; l++ {
+        t.Mul(L.ReferenceAt(i, l), L.ReferenceAt(j, l))
+        s.Add(s, t)
+      }
+      t.Sub(A.ReferenceAt(i, j), s)
+      if i == j {
+        if t.GetValue() <= 0.0 {
+          return nil, errors.New("matrix is not positive definite")
+        }
+        L.ReferenceAt(i, j).Sqrt(t)
+      } else {
+        L.ReferenceAt(i, j).Div(t, L.ReferenceAt(j, j))
+      }
+    }
+  }
+  return L, nil
+}
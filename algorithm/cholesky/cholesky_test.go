@@ -65,3 +65,36 @@ func TestCholesky2(t *testing.T) {
     t.Error("Cholesky failed!")
   }
 }
+
+func TestCholeskyBand(t *testing.T) {
+  n := 4
+  a := NewSymmetricBandMatrix(RealType, n, 1, []float64{
+    4,
+    2, 5,
+       2, 5,
+          2, 5 })
+  d := NullDenseMatrix(RealType, n, n)
+  d.Copy(a)
+  r, _ := Run(d)
+  for _, inSitu := range []bool{false, true} {
+    x, err := Run(a.Clone(), InSitu{inSitu})
+    if err != nil {
+      t.Error(err)
+    } else if l, ok := x.(*BandMatrix); !ok || !l.IsLowerTriangular() || len(l.GetValues()) != 7 {
+      t.Error("Cholesky failed!")
+    }
+    if Mnorm(MsubM(x, r)).GetValue() > 1e-8 {
+      t.Error("Cholesky failed!")
+    }
+  }
+}
+
+func TestCholeskyBandSingular(t *testing.T) {
+  // positive semidefinite matrix with a zero pivot
+  a := NewSymmetricBandMatrix(RealType, 2, 1, []float64{
+    1,
+    1, 1 })
+  if _, err := Run(a); err == nil {
+    t.Error("Cholesky failed!")
+  }
+}
@@ -45,10 +45,10 @@ func determinantNaive(a Matrix) Scalar {
   if (n < 1) {
     /* nothing to do */
   } else if n == 1 {
-    det.Copy(a.ReferenceAt(0, 0))
+    det.Copy(a.ConstAt(0, 0))
   } else if n == 2 {
-    t1.Mul(a.ReferenceAt(0, 0), a.ReferenceAt(1, 1))
-    t2.Mul(a.ReferenceAt(1, 0), a.ReferenceAt(0, 1))
+    t1.Mul(a.ConstAt(0, 0), a.ConstAt(1, 1))
+    t2.Mul(a.ConstAt(1, 0), a.ConstAt(0, 1))
     det.Sub(t1, t2)
   } else {
     m := NullDenseMatrix(a.ElementType(), n-1, n-1)
@@ -59,15 +59,15 @@ func determinantNaive(a Matrix) Scalar {
           if j == j1 {
             continue
           }
-          m.ReferenceAt(i-1, j2).Copy(a.ReferenceAt(i, j))
+          m.ReferenceAt(i-1, j2).Copy(a.ConstAt(i, j))
           j2++;
         }
       }
       if j1 % 2 == 0 {
-        t1.Mul(a.ReferenceAt(0, j1), determinantNaive(m))
+        t1.Mul(a.ConstAt(0, j1), determinantNaive(m))
         det.Add(det, t1)
       } else {
-        t1.Mul(a.ReferenceAt(0, j1), determinantNaive(m))
+        t1.Mul(a.ConstAt(0, j1), determinantNaive(m))
         det.Sub(det, t1)
       }
     }
@@ -103,7 +103,56 @@ func determinantPD(a Matrix, logScale bool) (Scalar, error) {
   return r, nil
 }
 
+// Determinant of a band matrix. For triangular matrices it is the product
+// of the diagonal and for tridiagonal matrices it is computed with a
+// three-term recurrence. Symmetric positive definite band matrices are
+// handled by the band Cholesky factorization, which is also used on log
+// scale, since the recurrence overflows for large matrices.
+func determinantBand(a *BandMatrix, positiveDefinite, logScale bool) (Scalar, error) {
+  n, _ := a.Dims()
+  lower, upper := a.Bandwidth()
+  r := NullScalar(a.ElementType())
+  t := NullScalar(a.ElementType())
+  switch {
+  case lower == 0 || upper == 0:
+    if logScale {
+      for i := 0; i < n; i++ {
+        t.Log(a.ReferenceAt(i, i))
+        r.Add(r, t)
+      }
+    } else {
+      r.SetValue(1.0)
+      for i := 0; i < n; i++ {
+        r.Mul(r, a.ReferenceAt(i, i))
+      }
+    }
+  case logScale:
+    return determinantPD(a, logScale)
+  case lower == 1 && upper == 1:
+    // f_i = a_ii f_{i-1} - a_{i,i-1} a_{i-1,i} f_{i-2}
+    f1 := NewScalar(a.ElementType(), 1.0)
+    f2 := NullScalar(a.ElementType())
+    r.Copy(a.ReferenceAt(0, 0))
+    for i := 1; i < n; i++ {
+      f2.Copy(f1)
+      f1.Copy(r)
+      t.Mul(a.ReferenceAt(i, i-1), a.ReferenceAt(i-1, i))
+      t.Mul(t, f2)
+      r.Mul(a.ReferenceAt(i, i), f1)
+      r.Sub(r, t)
+    }
+  case positiveDefinite:
+    return determinantPD(a, logScale)
+  default:
+    return determinantNaive(a), nil
+  }
+  return r, nil
+}
+
 func determinant(a Matrix, positiveDefinite, logScale bool) (Scalar, error) {
+  if b, ok := a.(*BandMatrix); ok {
+    return determinantBand(b, positiveDefinite, logScale)
+  }
   if positiveDefinite {
     return determinantPD(a, logScale)
   } else {
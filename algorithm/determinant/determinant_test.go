@@ -72,3 +72,44 @@ func TestDeterminant4(t *testing.T) {
   }
 
 }
+
+func TestDeterminantBand(t *testing.T) {
+  m := []Matrix{
+    NewLowerTriangularMatrix(RealType, 3, []float64{2, 1, 3, 1, 1, 4}),
+    NewUpperTriangularMatrix(RealType, 3, []float64{2, 1, 1, 3, 1, 4}),
+    NewTridiagonalMatrix(RealType, 4, []float64{2, 1, 1, 3, 1, 2, 4, 1, 1, 5}),
+    NewSymmetricBandMatrix(RealType, 4, 1, []float64{4, 2, 5, 2, 5, 2, 5}) }
+  for _, a := range m {
+    n, _ := a.Dims()
+    d := NullDenseMatrix(RealType, n, n)
+    d.Copy(a)
+    r1, _ := Run(a)
+    r2, _ := Run(d)
+    if math.Abs(r1.GetValue() - r2.GetValue()) > 1e-8 {
+      t.Error("Matrix determinant failed!")
+    }
+    if b := a.(*BandMatrix); b.Symmetric || b.IsLowerTriangular() || b.IsUpperTriangular() {
+      r3, _ := Run(a, PositiveDefinite{true}, LogScale{true})
+      if math.Abs(r3.GetValue() - math.Log(r2.GetValue())) > 1e-8 {
+        t.Error("Matrix determinant failed!")
+      }
+    }
+  }
+}
+
+func TestDeterminantBandLogScale(t *testing.T) {
+  n := 1000
+  v := make([]float64, 2*n-1)
+  for i := 0; i < n; i++ {
+    if i == 0 {
+      v[0] = 4
+    } else {
+      v[2*i-1], v[2*i] = 1, 4
+    }
+  }
+  // the determinant itself overflows
+  a := NewSymmetricBandMatrix(RealType, n, 1, v)
+  if r, err := Run(a, PositiveDefinite{true}, LogScale{true}); err != nil || math.Abs(r.GetValue() - 1317.0324014968) > 1e-6 {
+    t.Error("Matrix determinant failed!")
+  }
+}
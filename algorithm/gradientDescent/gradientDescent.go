@@ -41,7 +41,15 @@ func gradientDescent(f func(Vector) (Scalar, error), x0 Vector, step, epsilon fl
   t := x0.ElementType()
   // copy variables
   x := x0.Clone()
-  x.Variables(1)
+  // place variables in a context nested within all variables on which the
+  // objective function depends at x0
+  y0, err := f(x0)
+  if err != nil {
+    return x, err
+  }
+  c := ContextOf(x0, Vector{y0}).Nested(len(x))
+  c.Variables(1, x...)
+  k := c.Offset
   // slice containing the gradient
   gradient := make([]float64, len(x))
 
@@ -54,7 +62,7 @@ func gradientDescent(f func(Vector) (Scalar, error), x0 Vector, step, epsilon fl
     // compute partial derivatives and update variables
     for i, _ := range x {
       // save partial derivative
-      gradient[i] = s.GetDerivative(1, i)
+      gradient[i] = s.GetDerivative(1, k+i)
     }
     // execute hook if available
     if hook != nil && hook(gradient, x, s) {
@@ -66,7 +74,7 @@ func gradientDescent(f func(Vector) (Scalar, error), x0 Vector, step, epsilon fl
     }
     // update variables
     for i, _ := range x {
-      x[i] = Sub(x[i], NewScalar(t, step*s.GetDerivative(1, i)))
+      x[i] = Sub(x[i], NewScalar(t, step*s.GetDerivative(1, k+i)))
       if math.IsNaN(x[i].GetValue()) {
         panic("Gradient descent diverged!")
       }
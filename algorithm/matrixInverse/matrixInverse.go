@@ -19,8 +19,10 @@ package matrixInverse
 /* -------------------------------------------------------------------------- */
 
 //import   "fmt"
+import   "errors"
 
 import . "github.com/pbenner/autodiff"
+import   "github.com/pbenner/autodiff/algorithm"
 import   "github.com/pbenner/autodiff/algorithm/cholesky"
 import   "github.com/pbenner/autodiff/algorithm/gaussJordan"
 import   "github.com/pbenner/autodiff/algorithm/rprop"
@@ -75,7 +77,7 @@ func mInverse(matrix Matrix, args ...interface{}) (Matrix, error) {
 func mInversePD(matrix Matrix, s InSitu, args ...interface{}) (Matrix, error) {
   rows, _ := matrix.Dims()
   t := matrix.ElementType()
-  a, err := cholesky.Run(matrix, cholesky.InSitu{s.Value})
+  a, err := cholesky.Run(matrix, cholesky.InSitu{Value: s.Value})
   if err != nil {
     return nil, err
   }
@@ -86,13 +88,89 @@ func mInversePD(matrix Matrix, s InSitu, args ...interface{}) (Matrix, error) {
   for i, _ := range b {
     b[i].SetValue(1.0)
   }
-  args = append(args, gaussJordan.Triangular{true})
+  args = append(args, gaussJordan.Triangular{Value: true})
   // call Gauss-Jordan algorithm
   gaussJordan.Run(a, x, b, args...)
   // recycle a to store the result
   return a.MdotM(x, x.T()), nil
 }
 
+// Inverse of a lower triangular band matrix by forward substitution.
+func mInverseLowerBand(a *BandMatrix) (*BandMatrix, error) {
+  n, _ := a.Dims()
+  k, _ := a.Bandwidth()
+  t := a.ElementType()
+  x := NullLowerTriangularMatrix(t, n)
+  s := NewScalar(t, 0.0)
+  u := NewScalar(t, 0.0)
+  c := NewScalar(t, 1.0)
+  for j := 0; j < n; j++ {
+    if a.ReferenceAt(j, j).GetValue() == 0.0 {
+      return nil, errors.New("system is computationally singular")
+    }
+    x.ReferenceAt(j, j).Div(c, a.ReferenceAt(j, j))
+    for i := j+1; i < n; i++ {
+      s.Reset()
+      for l := algorithm.MaxInt(j, i-k); l < i; l++ {
+        u.Mul(a.ReferenceAt(i, l), x.ReferenceAt(l, j))
+        s.Add(s, u)
+      }
+      x.ReferenceAt(i, j).Div(s, a.ReferenceAt(i, i))
+      x.ReferenceAt(i, j).Neg(x.ReferenceAt(i, j))
+    }
+  }
+  return x, nil
+}
+
+// Inverse of a band matrix that exploits its structure. The inverse of a
+// diagonal or triangular matrix has the same structure. The inverse of a
+// symmetric positive definite matrix is computed from its band Cholesky
+// factor. All other band matrices are inverted as dense matrices.
+func mInverseBand(a *BandMatrix, positiveDefinite bool, s InSitu, args ...interface{}) (Matrix, error) {
+  n, _ := a.Dims()
+  t := a.ElementType()
+  switch {
+  case len(args) > 0:
+    // options of the Gauss-Jordan algorithm require a dense matrix
+  case a.IsDiagonal():
+    r := NullDiagonalMatrix(t, n)
+    c := NewScalar(t, 1.0)
+    for i := 0; i < n; i++ {
+      if a.ReferenceAt(i, i).GetValue() == 0.0 {
+        return nil, errors.New("system is computationally singular")
+      }
+      r.ReferenceAt(i, i).Div(c, a.ReferenceAt(i, i))
+    }
+    return r, nil
+  case a.IsLowerTriangular():
+    return mInverseLowerBand(a)
+  case a.IsUpperTriangular():
+    r, err := mInverseLowerBand(a.T().(*BandMatrix))
+    if err != nil {
+      return nil, err
+    }
+    return r.T(), nil
+  case a.Symmetric && positiveDefinite:
+    l, err := cholesky.Run(a, cholesky.InSitu{Value: s.Value})
+    if err != nil {
+      return nil, err
+    }
+    x, err := mInverseLowerBand(l.(*BandMatrix))
+    if err != nil {
+      return nil, err
+    }
+    // inverse of L L^T is X^T X, where X is the inverse of L
+    return NullSymmetricMatrix(t, n).MdotM(x.T(), x), nil
+  }
+  b := NullDenseMatrix(t, n, n)
+  b.Copy(a)
+  if positiveDefinite {
+    return mInversePD(b, InSitu{true}, args...)
+  } else {
+    return mInverse(b, args...)
+  }
+}
+
 /* -------------------------------------------------------------------------- */
 
 func Run(matrix Matrix, args ...interface{}) (Matrix, error) {
@@ -120,6 +198,9 @@ func Run(matrix Matrix, args ...interface{}) (Matrix, error) {
       gArgs = append(gArgs, arg)
     }
   }
+  if b, ok := matrix.(*BandMatrix); ok {
+    return mInverseBand(b, positiveDefinite, InSitu{inSitu}, gArgs...)
+  }
   if positiveDefinite {
     return mInversePD(matrix, InSitu{inSitu}, gArgs...)
   } else {
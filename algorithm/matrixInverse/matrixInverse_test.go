@@ -45,7 +45,7 @@ func TestSubmatrixInverse(t *testing.T) {
   submatrix := []bool{true, true, false}
 
   m1 := NewDenseMatrix(RealType, 3, 3, []float64{1,2,50,3,4,60,70,80,90})
-  m2, _ := Run(m1, gaussJordan.Submatrix{submatrix})
+  m2, _ := Run(m1, gaussJordan.Submatrix{Value: submatrix})
   m3 := NewDenseMatrix(RealType, 3, 3, []float64{-2, 1, 0, 1.5, -0.5, 0, 0, 0, 1})
 
   if Mnorm(MsubM(m2, m3)).GetValue() > 1e-8 {
@@ -99,3 +99,29 @@ func TestMatrixPerformance(t *testing.T) {
   fmt.Printf("Inverting a 100x100 bare real positive definite matrix took %s.\n", elapsed)
 
 }
+
+func TestMatrixInverseBand(t *testing.T) {
+  m := []Matrix{
+    NewDiagonalMatrix(RealType, []float64{2, 4, 8, 16}),
+    NewLowerTriangularMatrix(RealType, 4, []float64{2, 1, 3, 1, 1, 4, 1, 2, 1, 5}),
+    NewUpperTriangularMatrix(RealType, 4, []float64{2, 1, 1, 3, 1, 4, 1, 2, 1, 5}),
+    NewSymmetricBandMatrix(RealType, 4, 1, []float64{4, 2, 5, 2, 5, 2, 5}),
+    NewTridiagonalMatrix(RealType, 4, []float64{2, 1, 1, 3, 1, 2, 4, 1, 1, 5}) }
+  for _, a := range m {
+    d := NullDenseMatrix(RealType, 4, 4)
+    d.Copy(a)
+    r1, err := Run(a, PositiveDefinite{a.(*BandMatrix).Symmetric})
+    if err != nil {
+      t.Error(err); continue
+    }
+    r2, _ := Run(d)
+    if Mnorm(MsubM(r1, r2)).GetValue() > 1e-8 {
+      t.Error("Inverting matrix failed!")
+    }
+    if b, ok := a.(*BandMatrix); ok && (b.IsLowerTriangular() || b.IsUpperTriangular()) {
+      if c, ok := r1.(*BandMatrix); !ok || c.IsLowerTriangular() != b.IsLowerTriangular() {
+        t.Error("Inverting matrix failed!")
+      }
+    }
+  }
+}
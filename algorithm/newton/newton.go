@@ -31,10 +31,19 @@ type Hook struct {
   Value func(Matrix, Vector, Vector) bool
 }
 
+// Sparsity pattern of the Jacobian. If the value is nil, the pattern is
+// detected at each iterate and accumulated, so that it may depend on the
+// position. A given pattern must contain all structurally non-zero elements
+// at all iterates.
+type Sparsity struct {
+  Value SparsityPattern
+}
+
 /* -------------------------------------------------------------------------- */
 
 func newton(f func(Vector) (Vector, error), x Vector, epsilon float64,
   hook func(Matrix, Vector, Vector) bool,
+  sparsity *Sparsity,
   options []interface{}) (Vector, error) {
   x1  := x.Clone()
   x2  := x.Clone()
@@ -42,17 +51,36 @@ func newton(f func(Vector) (Vector, error), x Vector, epsilon float64,
     y, _ := f(x)
     return y
   }
+  detect := sparsity != nil && sparsity.Value == nil
   for {
     y, err := f(x1)
     if err != nil {
       return nil, err
     }
-    J := Jacobian(g, x1)
-    Q, err := matrixInverse.Run(J, options...)
-    if err != nil {
-      return nil, err
+    var J Matrix
+    if sparsity != nil {
+      if detect {
+        p := DetectJacobianSparsity(g, x1)
+        if sparsity.Value != nil {
+          p = sparsity.Value.Union(p)
+        }
+        sparsity.Value = p
+      }
+      S := SparseJacobian(g, x1, sparsity.Value)
+      d, err := sparseSolve(S, y)
+      if err != nil {
+        return nil, err
+      }
+      J  = S
+      x2 = VsubV(x1, d)
+    } else {
+      J = Jacobian(g, x1)
+      Q, err := matrixInverse.Run(J, options...)
+      if err != nil {
+        return nil, err
+      }
+      x2 = VsubV(x1, MdotV(Q, y))
     }
-    x2  = VsubV(x1, MdotV(Q, y))
     // execute hook if available
     if hook != nil && hook(J, x2, y) {
       break;
@@ -72,6 +100,7 @@ func Run(f func(Vector) (Vector, error), x Vector, args ...interface{}) (Vector,
 
   hook      := Hook     { nil}.Value
   epsilon   := Epsilon  {1e-8}.Value
+  sparsity  := (*Sparsity)(nil)
   options   := make([]interface{}, 0)
 
   for _, arg := range args {
@@ -80,9 +109,11 @@ func Run(f func(Vector) (Vector, error), x Vector, args ...interface{}) (Vector,
       hook = a.Value
     case Epsilon:
       epsilon = a.Value
+    case Sparsity:
+      sparsity = &a
     default:
       options = append(options, a)
     }
   }
-  return newton(f, x, epsilon, hook, options)
+  return newton(f, x, epsilon, hook, sparsity, options)
 }
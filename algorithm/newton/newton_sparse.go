@@ -0,0 +1,128 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+
+package newton
+
+/* -------------------------------------------------------------------------- */
+
+import   "errors"
+import   "math"
+import   "sort"
+
+import . "github.com/pbenner/autodiff"
+
+/* -------------------------------------------------------------------------- */
+
+// Solve J d = y by Gaussian elimination with partial pivoting on the rows
+// of the sparse matrix J, so that only non-zero elements (including
+// fill-in) are stored.
+func sparseSolve(J *SparseMatrix, y Vector) (Vector, error) {
+  n, m := J.Dims()
+  if n != m || n != len(y) {
+    panic("matrix/vector dimensions do not match!")
+  }
+  // rows of J and for each column the rows with a non-zero element
+  a := make([]map[int]float64, n)
+  c := make([]map[int]bool,    n)
+  for i := 0; i < n; i++ {
+    a[i] = make(map[int]float64)
+    c[i] = make(map[int]bool)
+  }
+  for p := 0; p+1 < len(J.Offset); p++ {
+    for k := J.Offset[p]; k < J.Offset[p+1]; k++ {
+      i, j := p, J.Index[k]
+      if J.Transposed {
+        i, j = j, i
+      }
+      if v := J.Values[k].GetValue(); v != 0.0 {
+        a[i][j] = v
+        c[j][i] = true
+      }
+    }
+  }
+  b := make([]float64, n)
+  for i := 0; i < n; i++ {
+    b[i] = y[i].GetValue()
+  }
+  // pivot row of each column
+  pivot := make([]int, n)
+  for k := 0; k < n; k++ {
+    pivot[k] = -1
+    for i := range c[k] {
+      if pivot[k] == -1 || math.Abs(a[i][k]) > math.Abs(a[pivot[k]][k]) ||
+        (math.Abs(a[i][k]) == math.Abs(a[pivot[k]][k]) && i < pivot[k]) {
+        pivot[k] = i
+      }
+    }
+    if pivot[k] == -1 || a[pivot[k]][k] == 0.0 {
+      return nil, errors.New("Jacobian is singular")
+    }
+    p := pivot[k]
+    // remove pivot row from column lists
+    for j := range a[p] {
+      delete(c[j], p)
+    }
+    for _, i := range sortedRows(c[k]) {
+      r := a[i][k]/a[p][k]
+      for j, v := range a[p] {
+        if j == k {
+          continue
+        }
+        a[i][j] -= r*v
+        c[j][i]  = true
+      }
+      delete(a[i], k)
+      delete(c[k], i)
+      b[i] -= r*b[p]
+    }
+  }
+  // back substitution
+  d := make([]float64, n)
+  for k := n-1; k >= 0; k-- {
+    p := pivot[k]
+    s := b[p]
+    for _, j := range sortedCols(a[p]) {
+      if j != k {
+        s -= a[p][j]*d[j]
+      }
+    }
+    d[k] = s/a[p][k]
+  }
+  r := NullVector(y.ElementType(), n)
+  for i := 0; i < n; i++ {
+    r[i].SetValue(d[i])
+  }
+  return r, nil
+}
+
+func sortedRows(m map[int]bool) []int {
+  r := make([]int, 0, len(m))
+  for k := range m {
+    r = append(r, k)
+  }
+  sort.Ints(r)
+  return r
+}
+
+func sortedCols(m map[int]float64) []int {
+  r := make([]int, 0, len(m))
+  for k := range m {
+    r = append(r, k)
+  }
+  sort.Ints(r)
+  return r
+}
@@ -47,3 +47,61 @@ func TestNewton(t *testing.T) {
     t.Error("Newton method failed!")
   }
 }
+
+func TestNewtonSparse(t *testing.T) {
+
+  n := 20
+  // discretized boundary value problem with tridiagonal Jacobian
+  f := func(x Vector) (Vector, error) {
+    y := NullVector(x.ElementType(), n)
+    for i := 0; i < n; i++ {
+      // y_i = 2 x_i - x_{i-1} - x_{i+1} + 0.1 x_i^3 - 1
+      y[i] = Sub(Mul(x[i], NewBareReal(2)), NewBareReal(1))
+      y[i] = Add(y[i], Mul(Pow(x[i], NewBareReal(3)), NewBareReal(0.1)))
+      if i > 0 {
+        y[i] = Sub(y[i], x[i-1])
+      }
+      if i < n-1 {
+        y[i] = Sub(y[i], x[i+1])
+      }
+    }
+    return y, nil
+  }
+  x0 := NullVector(RealType, n)
+  r1, err1 := Run(f, x0, Epsilon{1e-10})
+  r2, err2 := Run(f, x0, Epsilon{1e-10}, Sparsity{nil})
+  if err1 != nil || err2 != nil {
+    t.Fatal("Newton method failed!")
+  }
+  if Vnorm(VsubV(r1, r2)).GetValue() > 1e-10 {
+    t.Error("Newton method failed!")
+  }
+}
+
+func TestNewtonSparsityUnion(t *testing.T) {
+
+  f := func(x Vector) (Vector, error) {
+    y := NullVector(x.ElementType(), 2)
+    // y1 = x1 - 1
+    y[0] = Sub(x[0], NewBareReal(1))
+    // y2 = x2 - 2 + x1 x2 / 10 if x1 > 0.5 and x2 - 2 otherwise
+    y[1] = Sub(x[1], NewBareReal(2))
+    if x[0].GetValue() > 0.5 {
+      y[1] = Add(y[1], Mul(Mul(x[0], x[1]), NewBareReal(0.1)))
+    }
+    return y, nil
+  }
+  // the element (2, 1) of the Jacobian is structurally zero at x0
+  x0 := NullVector(RealType, 2)
+  J  := Matrix(nil)
+  hook := func(J_ Matrix, x Vector, y Vector) bool {
+    J = J_; return false
+  }
+  r, err := Run(f, x0, Epsilon{1e-10}, Sparsity{nil}, Hook{hook})
+  if err != nil {
+    t.Fatal("Newton method failed!")
+  }
+  if J.At(1, 0).GetValue() != 0.1*r[1].GetValue() {
+    t.Error("Newton method failed!")
+  }
+}
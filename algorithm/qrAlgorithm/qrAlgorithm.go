@@ -166,6 +166,10 @@ func hessenbergQrAlgorithm(h, u Matrix, c, s Vector, t1, t2, t3 Scalar, epsilon
 
     if v := h.ReferenceAt(n-1, n-2); math.Abs(v.GetValue()) < epsilon {
       n--
+    } else if hasComplexBlock(h, n-2, epsilon) {
+      // the trailing 2x2 block is decoupled but has a pair of complex
+      // eigenvalues, which does not converge in real arithmetic
+      n -= 2
     } else {
       hessenbergQrAlgorithmStep(h, u, c, s, t1, t2, t3, n, false)
     }
@@ -174,6 +178,62 @@ func hessenbergQrAlgorithm(h, u Matrix, c, s Vector, t1, t2, t3 Scalar, epsilon
   return h, u, nil
 }
 
+// Check if the 2x2 diagonal block of h starting at position i is decoupled
+// from the rest of the matrix and has a pair of complex eigenvalues.
+func hasComplexBlock(h Matrix, i int, epsilon float64) bool {
+  if i > 0 && math.Abs(h.At(i, i-1).GetValue()) >= epsilon {
+    return false
+  }
+  a := h.At(i+0, i+0).GetValue()
+  b := h.At(i+0, i+1).GetValue()
+  c := h.At(i+1, i+0).GetValue()
+  d := h.At(i+1, i+1).GetValue()
+  // discriminant of the characteristic polynomial
+  return (a-d)*(a-d)/4.0 + b*c < 0.0
+}
+
+// Extract the eigenvalues from the quasi-triangular matrix h computed by Run.
+// Eigenvalues are returned as a vector of ComplexType, where complex
+// eigenvalues are given by 2x2 blocks on the diagonal of h.
+func Eigenvalues(h Matrix, args ...interface{}) Vector {
+  n, _ := h.Dims()
+
+  epsilon := 1e-12
+
+  // loop over optional arguments
+  for _, arg := range args {
+    switch tmp := arg.(type) {
+    case Epsilon:
+      epsilon = tmp.Value
+    }
+  }
+  r := NullVector(ComplexType, n)
+  for i := 0; i < n; i++ {
+    if i+1 < n && math.Abs(h.At(i+1, i).GetValue()) >= epsilon {
+      // lambda = (a+d)/2 +/- sqrt((a-d)^2/4 + bc)
+      a := h.At(i+0, i+0)
+      b := h.At(i+0, i+1)
+      c := h.At(i+1, i+0)
+      d := h.At(i+1, i+1)
+      t := NullComplex()
+      t.Sub(a, d)
+      t.Div(t, NewBareReal(2.0))
+      t.Mul(t, t)
+      t.Add(t, Mul(b, c))
+      t.Sqrt(t)
+      m := NullComplex()
+      m.Add(a, d)
+      m.Div(m, NewBareReal(2.0))
+      r[i+0].Add(m, t)
+      r[i+1].Sub(m, t)
+      i++
+    } else {
+      r[i].Set(h.At(i, i))
+    }
+  }
+  return r
+}
+
 /* -------------------------------------------------------------------------- */
 
 func qrAlgorithm(a, b, q, r Matrix, t ScalarType) (Matrix, Matrix, error) {
@@ -190,7 +250,7 @@ func qrAlgorithm(a, b, q, r Matrix, t ScalarType) (Matrix, Matrix, error) {
         r.ReferenceAt(i, j).Reset()
       }
     }
-    q, r, _ = gramSchmidt.Run(a, gramSchmidt.InSitu{q, r})
+    q, r, _ = gramSchmidt.Run(a, gramSchmidt.InSitu{Q: q, R: r})
     b.MdotM(r, q)
     if Mnorm(a.MsubM(a, b)).GetValue() < 1e-12 {
       break
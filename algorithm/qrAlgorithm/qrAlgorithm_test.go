@@ -20,6 +20,7 @@ package qrAlgorithm
 
 //import   "fmt"
 import   "math"
+import   "math/cmplx"
 import   "sort"
 import   "testing"
 
@@ -55,3 +56,29 @@ func TestRProp(t *testing.T) {
     t.Errorf("test failed")
   }
 }
+
+func TestComplexEigenvalues(t *testing.T) {
+  // companion matrix of (x-2)(x-4)(x^2+1)
+  a := NewDenseMatrix(RealType, 4, 4, []float64{
+    6, -9, 6, -8,
+    1,  0, 0,  0,
+    0,  1, 0,  0,
+    0,  0, 1,  0 })
+
+  h, _, _ := Run(a)
+
+  r := Eigenvalues(h)
+  s := []complex128{4, 2, 1i, -1i}
+
+  for _, lambda := range s {
+    found := false
+    for i := 0; i < 4; i++ {
+      if cmplx.Abs(r[i].(*Complex).GetComplexValue() - lambda) < 1e-6 {
+        found = true
+      }
+    }
+    if !found {
+      t.Errorf("test failed for eigenvalue `%v'", lambda)
+    }
+  }
+}
@@ -67,7 +67,19 @@ func rprop(f func(Vector) (Scalar, error), x0 Vector, step_init float64 , eta []
     gradient_new[i] = 1
     gradient_old[i] = 1
   }
-  x1.Variables(1)
+  // check initial value
+  if constraints.Value != nil && !constraints.Value(x1) {
+    return x1, fmt.Errorf("invalid initial value: %v", x1)
+  }
+  // place variables in a context nested within all variables on which the
+  // objective function depends at x0
+  y0, err := f(x0)
+  if err != nil {
+    return x1, fmt.Errorf("invalid initial value: %v", x1)
+  }
+  c := ContextOf(x0, Vector{y0}).Nested(len(x1))
+  c.Variables(1, x1...)
+  k := c.Offset
 
   gradient_is_nan := func(s Scalar) bool {
     for i := 0; i < s.GetN(); i++ {
@@ -77,13 +89,13 @@ func rprop(f func(Vector) (Scalar, error), x0 Vector, step_init float64 , eta []
     }
     return false
   }
-  // check initial value
-  if constraints.Value != nil && !constraints.Value(x1) {
-    return x1, fmt.Errorf("invalid initial value: %v", x1)
-  }
   // evaluate objective function
   s, err := f(x1)
   if err != nil || gradient_is_nan(s) {
+    // report the offending operation if NaN diagnostics are enabled
+    if err := NaNCheckError(); err != nil {
+      return x1, fmt.Errorf("invalid initial value: %v: %v", x1, err)
+    }
     return x1, fmt.Errorf("invalid initial value: %v", x1)
   }
   for {
@@ -93,7 +105,7 @@ func rprop(f func(Vector) (Scalar, error), x0 Vector, step_init float64 , eta []
     // compute partial derivatives and update x
     for i, _ := range x1 {
       // save derivative
-      gradient_new[i] = s.GetDerivative(1, i)
+      gradient_new[i] = s.GetDerivative(1, k+i)
     }
     // execute hook if available
     if hook.Value != nil && hook.Value(gradient_new, step, x1, s) {
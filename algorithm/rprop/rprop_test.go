@@ -86,3 +86,24 @@ func TestRPropRosenbrock(t *testing.T) {
     t.Error("Rosenbrock test failed!")
   }
 }
+
+func TestRPropTapeReal(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    // f(x1, x2) = (a - x1)^2 + b(x2 - x1^2)^2
+    a := NewTapeReal(  1.0)
+    b := NewTapeReal(100.0)
+    s := Pow(Sub(a, x[0]), NewBareReal(2.0))
+    t := Mul(b, Pow(Sub(x[1], Mul(x[0], x[0])), NewBareReal(2.0)))
+    return Add(s, t), nil
+  }
+
+  x0 := NewVector(TapeRealType, []float64{-10,10})
+  xr := NewVector(TapeRealType, []float64{  1, 1})
+  xn, _ := Run(f, x0, 0.01, []float64{1.2, 0.8},
+    Epsilon{1e-10})
+
+  if Vnorm(VsubV(xn, xr)).GetValue() > 1e-8 {
+    t.Error("Rosenbrock test failed!")
+  }
+}
@@ -36,3 +36,11 @@ func ScaleVectorLength(v []float64, length float64) {
     v[i] *= c
   }
 }
+
+func MaxInt(a, b int) int {
+  if a > b {
+    return a
+  } else {
+    return b
+  }
+}
@@ -100,6 +100,10 @@ func (a *BareReal) GetDerivative(i, j int) float64 {
   return 0.0
 }
 
+func (a *BareReal) GetHessian(i, j int) float64 {
+  return 0.0
+}
+
 func (a *BareReal) GetN() int {
   return 0
 }
@@ -125,8 +129,20 @@ func (a *BareReal) SetValue(v float64) {
   *a = BareReal(v)
 }
 
+// Set the result of an operation on a and b (b may be nil) and check it
+// if NaN diagnostics are enabled.
+func (c *BareReal) set(v float64, a, b Scalar) {
+  if nanCheckEnabled {
+    nanCheck(c, v, a, b)
+  }
+  *c = BareReal(v)
+}
+
 func (a *BareReal) SetDerivative(i, j int, v float64) {
 }
 
+func (a *BareReal) SetHessian(i, j int, v float64) {
+}
+
 func (a *BareReal) SetVariable(i, n, order int) {
 }
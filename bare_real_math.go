@@ -64,11 +64,15 @@ func (a *BareReal) BareRealSmaller(b *BareReal) bool {
 
 /* -------------------------------------------------------------------------- */
 
+// At ties b is cloned, since a carries no derivatives.
 func (a *BareReal) Min(b Scalar) Scalar {
-  if a.GetValue() < b.GetValue() {
+  switch {
+  case a.GetValue() < b.GetValue():
     return a
+  case a.GetValue() > b.GetValue():
+    return b
   }
-  return b
+  return scalarTie(b, a)
 }
 
 func (a *BareReal) BareRealMin(b *BareReal) Scalar {
@@ -81,10 +85,13 @@ func (a *BareReal) BareRealMin(b *BareReal) Scalar {
 /* -------------------------------------------------------------------------- */
 
 func (a *BareReal) Max(b Scalar) Scalar {
-  if a.GetValue() > b.GetValue() {
+  switch {
+  case a.GetValue() > b.GetValue():
     return a
+  case a.GetValue() < b.GetValue():
+    return b
   }
-  return b
+  return scalarTie(b, a)
 }
 
 func (a *BareReal) BareRealMax(b *BareReal) Scalar {
@@ -97,16 +104,13 @@ func (a *BareReal) BareRealMax(b *BareReal) Scalar {
 /* -------------------------------------------------------------------------- */
 
 func (c *BareReal) Abs(a Scalar) Scalar {
-  if c.Sign() == -1 {
-    c.Neg(a)
-  }
+  checkBare(a)
+  c.set(math.Abs(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) BareRealAbs(a *BareReal) Scalar {
-  if c.Sign() == -1 {
-    c.Neg(a)
-  }
+  c.set(math.Abs(a.GetValue()), a, nil)
   return c
 }
 
@@ -136,12 +140,12 @@ func (a *BareReal) RealSign() int {
 
 func (c *BareReal) Neg(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(-a.GetValue())
+  c.set(-a.GetValue(), a, nil)
   return c
 }
 
 func (c *BareReal) BareRealNeg(a *BareReal) *BareReal {
-  *c = BareReal(-a.GetValue())
+  c.set(-a.GetValue(), a, nil)
   return c
 }
 
@@ -150,12 +154,12 @@ func (c *BareReal) BareRealNeg(a *BareReal) *BareReal {
 func (c *BareReal) Add(a, b Scalar) Scalar {
   checkBare(a)
   checkBare(b)
-  *c = BareReal(a.GetValue() + b.GetValue())
+  c.set(a.GetValue() + b.GetValue(), a, b)
   return c
 }
 
 func (c *BareReal) BareRealAdd(a, b *BareReal) *BareReal {
-  *c = *a + *b
+  c.set(float64(*a + *b), a, b)
   return c
 }
 
@@ -164,12 +168,12 @@ func (c *BareReal) BareRealAdd(a, b *BareReal) *BareReal {
 func (c *BareReal) Sub(a, b Scalar) Scalar {
   checkBare(a)
   checkBare(b)
-  *c = BareReal(a.GetValue() - b.GetValue())
+  c.set(a.GetValue() - b.GetValue(), a, b)
   return c
 }
 
 func (c *BareReal) BareRealSub(a, b *BareReal) *BareReal {
-  *c = *a - *b
+  c.set(float64(*a - *b), a, b)
   return c
 }
 
@@ -178,12 +182,12 @@ func (c *BareReal) BareRealSub(a, b *BareReal) *BareReal {
 func (c *BareReal) Mul(a, b Scalar) Scalar {
   checkBare(a)
   checkBare(b)
-  *c = BareReal(a.GetValue() * b.GetValue())
+  c.set(a.GetValue() * b.GetValue(), a, b)
   return c
 }
 
 func (c *BareReal) BareRealMul(a, b *BareReal) *BareReal {
-  *c = *a * *b
+  c.set(float64(*a * *b), a, b)
   return c
 }
 
@@ -192,12 +196,12 @@ func (c *BareReal) BareRealMul(a, b *BareReal) *BareReal {
 func (c *BareReal) Div(a, b Scalar) Scalar {
   checkBare(a)
   checkBare(b)
-  *c = BareReal(a.GetValue() / b.GetValue())
+  c.set(a.GetValue() / b.GetValue(), a, b)
   return c
 }
 
 func (c *BareReal) BareRealDiv(a, b *BareReal) *BareReal {
-  *c = *a / *b
+  c.set(float64(*a / *b), a, b)
   return c
 }
 
@@ -206,12 +210,12 @@ func (c *BareReal) BareRealDiv(a, b *BareReal) *BareReal {
 func (c *BareReal) Pow(a, k Scalar) Scalar {
   checkBare(a)
   checkBare(k)
-  *c = BareReal(math.Pow(a.GetValue(), k.GetValue()))
+  c.set(math.Pow(a.GetValue(), k.GetValue()), a, k)
   return c
 }
 
 func (c *BareReal) BareRealPow(a, k *BareReal) *BareReal {
-  *c = BareReal(math.Pow(a.GetValue(), k.GetValue()))
+  c.set(math.Pow(a.GetValue(), k.GetValue()), a, k)
   return c
 }
 
@@ -230,79 +234,140 @@ func (c *BareReal) BareRealSqrt(a *BareReal) *BareReal {
 
 func (c *BareReal) Sin(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Sin(a.GetValue()))
+  c.set(math.Sin(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Sinh(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Sinh(a.GetValue()))
+  c.set(math.Sinh(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Cos(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Cos(a.GetValue()))
+  c.set(math.Cos(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Cosh(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Cosh(a.GetValue()))
+  c.set(math.Cosh(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Tan(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Tan(a.GetValue()))
+  c.set(math.Tan(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Tanh(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Tanh(a.GetValue()))
+  c.set(math.Tanh(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Asin(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Asin(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Acos(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Acos(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Atan(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Atan(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Atan2(a, b Scalar) Scalar {
+  checkBare(a)
+  checkBare(b)
+  c.set(math.Atan2(a.GetValue(), b.GetValue()), a, b)
+  return c
+}
+
+func (c *BareReal) Asinh(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Asinh(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Acosh(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Acosh(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Atanh(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Atanh(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Exp(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Exp(a.GetValue()))
+  c.set(math.Exp(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Log(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Log(a.GetValue()))
+  c.set(math.Log(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Log1p(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Log1p(a.GetValue()))
+  c.set(math.Log1p(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Expm1(a Scalar) Scalar {
+  checkBare(a)
+  c.set(math.Expm1(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Logistic(a Scalar) Scalar {
+  checkBare(a)
+  c.set(special.Logistic(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Softplus(a Scalar) Scalar {
+  checkBare(a)
+  c.set(special.Softplus(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Erf(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Erf(a.GetValue()))
+  c.set(math.Erf(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Erfc(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Erfc(a.GetValue()))
+  c.set(math.Erfc(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) LogErfc(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(special.LogErfc(a.GetValue()))
+  c.set(special.LogErfc(a.GetValue()), a, nil)
   return c
 }
 
 func (c *BareReal) Gamma(a Scalar) Scalar {
   checkBare(a)
-  *c = BareReal(math.Gamma(a.GetValue()))
+  c.set(math.Gamma(a.GetValue()), a, nil)
   return c
 }
 
@@ -312,19 +377,69 @@ func (c *BareReal) Lgamma(a Scalar) Scalar {
   if s == -1 {
     v = math.NaN()
   }
-  *c = BareReal(v)
+  c.set(v, a, nil)
   return c
 }
 
 func (c *BareReal) Mlgamma(a Scalar, k int) Scalar {
   checkBare(a)
-  *c = BareReal(special.Mlgamma(a.GetValue(), k))
+  c.set(special.Mlgamma(a.GetValue(), k), a, nil)
   return c
 }
 
-func (c *BareReal) GammaP(a float64, x Scalar) Scalar {
+func (c *BareReal) GammaP(a, x Scalar) Scalar {
+  checkBare(a)
   checkBare(x)
-  *c = BareReal(special.GammaP(a, x.GetValue()))
+  c.set(special.GammaP(a.GetValue(), x.GetValue()), a, x)
+  return c
+}
+
+func (c *BareReal) GammaQ(a, x Scalar) Scalar {
+  checkBare(a)
+  checkBare(x)
+  c.set(special.GammaQ(a.GetValue(), x.GetValue()), a, x)
+  return c
+}
+
+func (c *BareReal) Digamma(a Scalar) Scalar {
+  checkBare(a)
+  c.set(special.Digamma(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Trigamma(a Scalar) Scalar {
+  checkBare(a)
+  c.set(special.Trigamma(a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Polygamma(n int, a Scalar) Scalar {
+  checkBare(a)
+  c.set(special.Polygamma(n, a.GetValue()), a, nil)
+  return c
+}
+
+func (c *BareReal) Beta(a, b Scalar) Scalar {
+  checkBare(a)
+  checkBare(b)
+  c.set(special.Beta(a.GetValue(), b.GetValue()), a, b)
+  return c
+}
+
+func (c *BareReal) Lbeta(a, b Scalar) Scalar {
+  checkBare(a)
+  checkBare(b)
+  v, s := special.Lbeta(a.GetValue(), b.GetValue())
+  if s == -1 {
+    v = math.NaN()
+  }
+  c.set(v, a, b)
+  return c
+}
+
+func (c *BareReal) Zeta(a Scalar) Scalar {
+  checkBare(a)
+  c.set(special.Zeta(a.GetValue()), a, nil)
   return c
 }
 
@@ -354,3 +469,40 @@ func (r *BareReal) Vnorm(a Vector) Scalar {
   r.Sqrt(r)
   return r
 }
+
+func (r *BareReal) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullBareReal()
+  t := NullBareReal()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *BareReal) StopGradient(a Scalar) Scalar {
+  *c = BareReal(a.GetValue())
+  return c
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *BareReal) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  checkBare(a)
+  c.set(v0, a, nil)
+  return c
+}
+
+func (c *BareReal) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  checkBare(a)
+  checkBare(b)
+  c.set(v0, a, b)
+  return c
+}
@@ -22,11 +22,15 @@ import "math"
 
 /* -------------------------------------------------------------------------- */
 
-// This is the basic state used by real and probability scalars.
+// This is the basic state used by real and probability scalars. First
+// derivatives are stored in a dense vector, second derivatives (including
+// all mixed partial derivatives) in a symmetric n x n matrix. The Hessian
+// is allocated only if the order is at least two.
 type BasicState struct {
   Value            float64
   Order            int
-  Derivative  [][2]float64
+  Derivative     []float64
+  Hessian      [][]float64
   N                int
 }
 
@@ -43,6 +47,15 @@ func NewBasicState(value float64) *BasicState {
   return &a
 }
 
+func newHessian(n int) [][]float64 {
+  h := make([][]float64, n)
+  v := make([]float64, n*n)
+  for i := 0; i < n; i++ {
+    h[i] = v[i*n:(i+1)*n]
+  }
+  return h
+}
+
 /* -------------------------------------------------------------------------- */
 
 // Copy the basic state from b. Allocate memory if needed.
@@ -51,28 +64,41 @@ func (a *BasicState) Copy(b Scalar) {
   a.Order = b.GetOrder()
   a.Alloc(b.GetN())
   for i := 0; i < b.GetN(); i++ {
-    a.Derivative[i][0] = b.GetDerivative(1, i)
-    a.Derivative[i][1] = b.GetDerivative(2, i)
+    a.Derivative[i] = b.GetDerivative(1, i)
+  }
+  if a.Order >= 2 {
+    for i := 0; i < b.GetN(); i++ {
+      for j := i; j < b.GetN(); j++ {
+        a.SetHessian(i, j, b.GetHessian(i, j))
+      }
+    }
   }
 }
 
-// Allocate memory for derivatives of n variables.
+// Allocate memory for derivatives of n variables. Memory for the Hessian
+// is allocated only if the order is at least two.
 func (a *BasicState) Alloc(n int) {
   if a.N != n {
-    a.Derivative = make([][2]float64, n)
+    a.Derivative = make([]float64, n)
+    a.Hessian    = nil
     a.N          = n
   }
+  if a.Order < 2 {
+    a.Hessian = nil
+  } else if a.Hessian == nil {
+    a.Hessian = newHessian(n)
+  }
 }
 
 // Allocate memory for the results of mathematical operations on
 // the given variables.
 func (c *BasicState) AllocForOne(a Scalar) {
-  c.Alloc(a.GetN())
   c.Order = a.GetOrder()
+  c.Alloc(a.GetN())
 }
 func (c *BasicState) AllocForTwo(a, b Scalar) {
-  c.Alloc(iMax(a.GetN(), b.GetN()))
   c.Order = iMax(a.GetOrder(), b.GetOrder())
+  c.Alloc(iMax(a.GetN(), b.GetN()))
 }
 
 /* read access
@@ -100,11 +126,24 @@ func (a *BasicState) GetDerivative(i, j int) float64 {
   if i != 1 && i != 2 {
     panic("Invalid order!")
   }
-  if j < a.N {
-    return a.Derivative[j][i-1]
-  } else {
+  if j >= a.N {
+    return 0.0
+  }
+  if i == 1 {
+    return a.Derivative[j]
+  }
+  if a.Hessian == nil {
     return 0.0
   }
+  return a.Hessian[j][j]
+}
+
+// Returns the second derivative with respect to the ith and jth variable.
+func (a *BasicState) GetHessian(i, j int) float64 {
+  if a.Hessian == nil || i >= a.N || j >= a.N {
+    return 0.0
+  }
+  return a.Hessian[i][j]
 }
 
 // Number of variables for which derivates are stored.
@@ -121,16 +160,17 @@ func (a *BasicState) SetN(n int) {
 
 func (a *BasicState) Reset() {
   a.Value = 0.0
-  for i := 0; i < a.N; i++ {
-    a.Derivative[i][0] = 0.0
-    a.Derivative[i][1] = 0.0
-  }
+  a.ResetDerivatives()
 }
 
 func (a *BasicState) ResetDerivatives() {
   for i := 0; i < a.N; i++ {
-    a.Derivative[i][0] = 0.0
-    a.Derivative[i][1] = 0.0
+    a.Derivative[i] = 0.0
+  }
+  for i := 0; i < len(a.Hessian); i++ {
+    for j := 0; j < a.N; j++ {
+      a.Hessian[i][j] = 0.0
+    }
   }
 }
 
@@ -142,29 +182,41 @@ func (a *BasicState) Set(b Scalar) {
 // Set only the value of the variable.
 func (a *BasicState) SetValue(v float64) {
   a.Value = v
-  // for i := 0; i < len(a.Derivative); i++ {
-  //   if a.Derivative[i][0] != 0.0 {
-  //     a.Derivative[i][0] = 1
-  //   }
-  //   a.Derivative[i][1] = 0
-  // }
 }
 
 // Set the ith derivative of the jth variable to v.
 func (a *BasicState) SetDerivative(i, j int, v float64) {
-  if i != 1 && i != 2 {
+  switch i {
+  case 1:
+    a.Derivative[j] = v
+  case 2:
+    a.SetHessian(j, j, v)
+  default:
     panic("Invalid order!")
   }
-  a.Derivative[j][i-1] = v
+}
+
+// Set the second derivative with respect to the ith and jth variable
+// to v. The Hessian is kept symmetric.
+func (a *BasicState) SetHessian(i, j int, v float64) {
+  if a.Hessian == nil {
+    a.Hessian = newHessian(a.N)
+  }
+  a.Hessian[i][j] = v
+  a.Hessian[j][i] = v
 }
 
 // Allocate memory for n variables and set the derivative
 // of the ith variable to 1 (initial value).
 func (a *BasicState) SetVariable(i, n, order int) {
-  a.Derivative = make([][2]float64, n)
+  a.Derivative = make([]float64, n)
+  a.Hessian    = nil
   a.N          = n
   a.Order      = order
+  if order >= 2 {
+    a.Hessian = newHessian(n)
+  }
   if order > 0 {
-    a.Derivative[i][0] = 1
+    a.Derivative[i] = 1
   }
 }
@@ -0,0 +1,270 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+
+/* -------------------------------------------------------------------------- */
+
+// A Complex is a complex-valued scalar. For each variable z_j the Wirtinger
+// derivatives
+//
+//   Derivative[j] = df/dz_j,  Conjugate[j] = df/dconj(z_j)
+//
+// are stored. Holomorphic functions have vanishing conjugate derivatives,
+// which become non-zero only through operations such as Conj or Abs. Only
+// first derivatives are supported.
+//
+// The Scalar interface gives access to real values only: GetValue returns
+// the real part and GetDerivative(1, j) the derivative of the real part with
+// respect to the real part of z_j, i.e. Re(df/dz_j + df/dconj(z_j)). Hence,
+// functions that operate on values, such as the optimization algorithms,
+// ignore imaginary parts.
+type Complex struct {
+  Value         complex128
+  Order         int
+  N             int
+  Derivative  []complex128
+  Conjugate   []complex128
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var ComplexType ScalarType = NewComplex(0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return NewComplex(complex(value, 0.0)) }
+  RegisterScalar(ComplexType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a new complex constant or variable.
+func NewComplex(v complex128) *Complex {
+  return &Complex{Value: v}
+}
+
+func NullComplex() *Complex {
+  return &Complex{Value: 0.0}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Complex) Clone() Scalar {
+  r := NullComplex()
+  r.Copy(a)
+  return r
+}
+
+func (a *Complex) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *Complex) String() string {
+  return fmt.Sprintf("%e", a.Value)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Returns the value of a as complex number.
+func complexValue(a Scalar) complex128 {
+  if r, ok := a.(*Complex); ok {
+    return r.Value
+  }
+  return complex(a.GetValue(), 0.0)
+}
+
+// Returns the Wirtinger derivatives of a with respect to the jth variable.
+// Real scalars are extended holomorphically.
+func complexDerivatives(a Scalar, j int) (complex128, complex128) {
+  if r, ok := a.(*Complex); ok {
+    if j >= r.N {
+      return 0.0, 0.0
+    }
+    return r.Derivative[j], r.Conjugate[j]
+  }
+  if a.GetOrder() < 1 || j >= a.GetN() {
+    return 0.0, 0.0
+  }
+  return complex(a.GetDerivative(1, j), 0.0), 0.0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Complex) Copy(b Scalar) {
+  a.Order = iMin(b.GetOrder(), 1)
+  a.Alloc(b.GetN())
+  for j := 0; j < a.N; j++ {
+    a.Derivative[j], a.Conjugate[j] = complexDerivatives(b, j)
+  }
+  a.Value = complexValue(b)
+}
+
+// Allocate memory for derivatives of n variables.
+func (a *Complex) Alloc(n int) {
+  if a.N != n {
+    a.Derivative = make([]complex128, n)
+    a.Conjugate  = make([]complex128, n)
+    a.N          = n
+  }
+}
+
+func (c *Complex) AllocForOne(a Scalar) {
+  c.Order = iMin(a.GetOrder(), 1)
+  c.Alloc(a.GetN())
+}
+
+func (c *Complex) AllocForTwo(a, b Scalar) {
+  c.Order = iMin(iMax(a.GetOrder(), b.GetOrder()), 1)
+  c.Alloc(iMax(a.GetN(), b.GetN()))
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *Complex) GetOrder() int {
+  return a.Order
+}
+
+// Returns the real part of the value. The imaginary part is only available
+// through GetComplexValue.
+func (a *Complex) GetValue() float64 {
+  return real(a.Value)
+}
+
+func (a *Complex) GetLogValue() float64 {
+  return math.Log(real(a.Value))
+}
+
+func (a *Complex) GetComplexValue() complex128 {
+  return a.Value
+}
+
+// Returns the derivative of the real part with respect to the real part
+// of the jth variable. Second derivatives are not available.
+func (a *Complex) GetDerivative(i, j int) float64 {
+  switch i {
+  case 1:
+    if j >= a.N {
+      return 0.0
+    }
+    return real(a.Derivative[j] + a.Conjugate[j])
+  case 2:
+    return 0.0
+  default:
+    panic("Invalid order!")
+  }
+}
+
+func (a *Complex) GetHessian(i, j int) float64 {
+  return 0.0
+}
+
+// Returns the derivative with respect to the jth variable.
+func (a *Complex) GetComplexDerivative(j int) complex128 {
+  if j >= a.N {
+    return 0.0
+  }
+  return a.Derivative[j]
+}
+
+// Returns the derivative with respect to the complex conjugate of the jth
+// variable.
+func (a *Complex) GetConjugateDerivative(j int) complex128 {
+  if j >= a.N {
+    return 0.0
+  }
+  return a.Conjugate[j]
+}
+
+func (a *Complex) GetN() int {
+  return a.N
+}
+
+func (a *Complex) SetN(n int) {
+  a.Alloc(n)
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *Complex) Reset() {
+  a.Value = 0.0
+  a.ResetDerivatives()
+}
+
+func (a *Complex) ResetDerivatives() {
+  for j := 0; j < a.N; j++ {
+    a.Derivative[j] = 0.0
+    a.Conjugate [j] = 0.0
+  }
+}
+
+func (a *Complex) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Set the value of the variable, the imaginary part is set to zero.
+func (a *Complex) SetValue(v float64) {
+  a.Value = complex(v, 0.0)
+}
+
+func (a *Complex) SetComplexValue(v complex128) {
+  a.Value = v
+}
+
+// Set the first derivative of the jth variable to v. The variable is
+// treated as holomorphic.
+func (a *Complex) SetDerivative(i, j int, v float64) {
+  if i != 1 {
+    panic("Invalid order!")
+  }
+  a.Derivative[j] = complex(v, 0.0)
+  a.Conjugate [j] = 0.0
+}
+
+func (a *Complex) SetComplexDerivative(j int, v complex128) {
+  a.Derivative[j] = v
+}
+
+func (a *Complex) SetConjugateDerivative(j int, v complex128) {
+  a.Conjugate[j] = v
+}
+
+func (a *Complex) SetHessian(i, j int, v float64) {
+}
+
+// Allocate memory for n variables and set the derivative
+// of the ith variable to 1 (initial value). Second order derivatives are
+// not supported, hence the order is at most one.
+func (a *Complex) SetVariable(i, n, order int) {
+  a.Order = iMin(order, 1)
+  a.Alloc(n)
+  a.ResetDerivatives()
+  if order > 0 {
+    a.Derivative[i] = 1.0
+  }
+}
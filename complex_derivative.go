@@ -0,0 +1,77 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math/cmplx"
+
+/* derivatives of monadic functions, where p = df/dw and q = df/dconj(w)
+ * are the Wirtinger derivatives of f(w) at w = a
+ * -------------------------------------------------------------------------- */
+
+func (c *Complex) monadic(a Scalar, v0, p, q complex128) Scalar {
+  c.AllocForOne(a)
+  if c.Order >= 1 {
+    for j := 0; j < c.N; j++ {
+      da, ca := complexDerivatives(a, j)
+      c.Derivative[j] = p*da + q*cmplx.Conj(ca)
+      c.Conjugate [j] = p*ca + q*cmplx.Conj(da)
+    }
+  }
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+/* derivatives of dyadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *Complex) dyadic(a, b Scalar, v0, pa, qa, pb, qb complex128) Scalar {
+  c.AllocForTwo(a, b)
+  if c.Order >= 1 {
+    for j := 0; j < c.N; j++ {
+      da, ca := complexDerivatives(a, j)
+      db, cb := complexDerivatives(b, j)
+      c.Derivative[j] = pa*da + qa*cmplx.Conj(ca) + pb*db + qb*cmplx.Conj(cb)
+      c.Conjugate [j] = pa*ca + qa*cmplx.Conj(da) + pb*cb + qb*cmplx.Conj(db)
+    }
+  }
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *Complex) StopGradient(a Scalar) Scalar {
+  return c.monadic(a, complexValue(a), 0.0, 0.0)
+}
+
+/* user-defined functions, which are extended holomorphically from the
+ * real line
+ * -------------------------------------------------------------------------- */
+
+func (c *Complex) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  return c.monadic(a, complex(v0, 0.0), complex(v1, 0.0), 0.0)
+}
+
+func (c *Complex) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  return c.dyadic(a, b, complex(v0, 0.0), complex(v10, 0.0), 0.0, complex(v01, 0.0), 0.0)
+}
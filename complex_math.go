@@ -0,0 +1,438 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "math/cmplx"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Complex) Equals(b Scalar) bool {
+  epsilon := 1e-12
+  return cmplx.Abs(a.Value - complexValue(b)) < epsilon
+}
+
+// Complex numbers are compared by their real parts.
+func (a *Complex) Greater(b Scalar) bool {
+  return a.GetValue() > b.GetValue()
+}
+
+func (a *Complex) Smaller(b Scalar) bool {
+  return a.GetValue() < b.GetValue()
+}
+
+func (a *Complex) Min(b Scalar) Scalar {
+  switch {
+  case a.GetValue() < b.GetValue():
+    return a
+  case a.GetValue() > b.GetValue():
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+func (a *Complex) Max(b Scalar) Scalar {
+  switch {
+  case a.GetValue() > b.GetValue():
+    return a
+  case a.GetValue() < b.GetValue():
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+// Modulus of a complex number. The result is not holomorphic, its
+// derivatives are given by the Wirtinger calculus.
+func (c *Complex) Abs(a Scalar) Scalar {
+  z := complexValue(a)
+  r := cmplx.Abs(z)
+  if r == 0.0 {
+    return c.monadic(a, 0.0, 0.0, 0.0)
+  }
+  return c.monadic(a, complex(r, 0.0), cmplx.Conj(z)/complex(2.0*r, 0.0), z/complex(2.0*r, 0.0))
+}
+
+// Sign of the real part.
+func (a *Complex) Sign() int {
+  if real(a.Value) < 0.0 {
+    return -1
+  }
+  if real(a.Value) > 0.0 {
+    return  1
+  }
+  return 0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Complex) Conj(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Conj(z), 0.0, 1.0)
+}
+
+func (c *Complex) RealPart(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, complex(real(z), 0.0), 0.5, 0.5)
+}
+
+func (c *Complex) ImagPart(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, complex(imag(z), 0.0), -0.5i, 0.5i)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Complex) Neg(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, -z, -1.0, 0.0)
+}
+
+func (c *Complex) Add(a, b Scalar) Scalar {
+  x := complexValue(a)
+  y := complexValue(b)
+  return c.dyadic(a, b, x+y, 1.0, 0.0, 1.0, 0.0)
+}
+
+func (c *Complex) Sub(a, b Scalar) Scalar {
+  x := complexValue(a)
+  y := complexValue(b)
+  return c.dyadic(a, b, x-y, 1.0, 0.0, -1.0, 0.0)
+}
+
+func (c *Complex) Mul(a, b Scalar) Scalar {
+  x := complexValue(a)
+  y := complexValue(b)
+  return c.dyadic(a, b, x*y, y, 0.0, x, 0.0)
+}
+
+func (c *Complex) Div(a, b Scalar) Scalar {
+  x := complexValue(a)
+  y := complexValue(b)
+  return c.dyadic(a, b, x/y, 1.0/y, 0.0, -x/(y*y), 0.0)
+}
+
+func (c *Complex) Pow(a, k Scalar) Scalar {
+  x := complexValue(a)
+  y := complexValue(k)
+  v0 := cmplx.Pow(x, y)
+  if k.GetOrder() >= 1 {
+    return c.dyadic(a, k, v0, cmplx.Pow(x, y-1)*y, 0.0, v0*cmplx.Log(x), 0.0)
+  } else {
+    return c.monadic(a, v0, cmplx.Pow(x, y-1)*y, 0.0)
+  }
+}
+
+func (c *Complex) Sqrt(a Scalar) Scalar {
+  z := complexValue(a)
+  v0 := cmplx.Sqrt(z)
+  return c.monadic(a, v0, 1.0/(2.0*v0), 0.0)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Complex) Sin(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Sin(z), cmplx.Cos(z), 0.0)
+}
+
+func (c *Complex) Sinh(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Sinh(z), cmplx.Cosh(z), 0.0)
+}
+
+func (c *Complex) Cos(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Cos(z), -cmplx.Sin(z), 0.0)
+}
+
+func (c *Complex) Cosh(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Cosh(z), cmplx.Sinh(z), 0.0)
+}
+
+func (c *Complex) Tan(a Scalar) Scalar {
+  z := complexValue(a)
+  v0 := cmplx.Tan(z)
+  return c.monadic(a, v0, 1.0 + v0*v0, 0.0)
+}
+
+func (c *Complex) Tanh(a Scalar) Scalar {
+  z := complexValue(a)
+  v0 := cmplx.Tanh(z)
+  return c.monadic(a, v0, 1.0 - v0*v0, 0.0)
+}
+
+func (c *Complex) Asin(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Asin(z), 1.0/cmplx.Sqrt(1.0-z*z), 0.0)
+}
+
+func (c *Complex) Acos(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Acos(z), -1.0/cmplx.Sqrt(1.0-z*z), 0.0)
+}
+
+func (c *Complex) Atan(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Atan(z), 1.0/(1.0+z*z), 0.0)
+}
+
+func (c *Complex) Atan2(a, b Scalar) Scalar {
+  return c.realDyadic(a, b, func(y, x float64) (float64, float64, float64) {
+    r := x*x + y*y
+    return math.Atan2(y, x), x/r, -y/r
+  })
+}
+
+func (c *Complex) Asinh(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Asinh(z), 1.0/cmplx.Sqrt(z*z+1.0), 0.0)
+}
+
+func (c *Complex) Acosh(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Acosh(z), 1.0/(cmplx.Sqrt(z-1.0)*cmplx.Sqrt(z+1.0)), 0.0)
+}
+
+func (c *Complex) Atanh(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Atanh(z), 1.0/(1.0-z*z), 0.0)
+}
+
+func (c *Complex) Exp(a Scalar) Scalar {
+  z := complexValue(a)
+  v0 := cmplx.Exp(z)
+  return c.monadic(a, v0, v0, 0.0)
+}
+
+func (c *Complex) Log(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Log(z), 1.0/z, 0.0)
+}
+
+func (c *Complex) Log1p(a Scalar) Scalar {
+  z := complexValue(a)
+  return c.monadic(a, cmplx.Log(1.0+z), 1.0/(1.0+z), 0.0)
+}
+
+func (c *Complex) Expm1(a Scalar) Scalar {
+  z := complexValue(a)
+  x, y := real(z), imag(z)
+  // exp(x+iy) - 1 = expm1(x) cos(y) - 2 sin(y/2)^2 + i exp(x) sin(y)
+  s := math.Sin(y/2.0)
+  v0 := complex(math.Expm1(x)*math.Cos(y) - 2.0*s*s, math.Exp(x)*math.Sin(y))
+  return c.monadic(a, v0, cmplx.Exp(z), 0.0)
+}
+
+func (c *Complex) Logistic(a Scalar) Scalar {
+  z := complexValue(a)
+  var v0 complex128
+  if real(z) >= 0.0 {
+    v0 = 1.0/(1.0+cmplx.Exp(-z))
+  } else {
+    e := cmplx.Exp(z)
+    v0 = e/(1.0+e)
+  }
+  return c.monadic(a, v0, v0*(1.0-v0), 0.0)
+}
+
+func (c *Complex) Softplus(a Scalar) Scalar {
+  z := complexValue(a)
+  var v0, v1 complex128
+  if real(z) > 0.0 {
+    e := cmplx.Exp(-z)
+    v0 = z + cmplx.Log(1.0+e)
+    v1 = 1.0/(1.0+e)
+  } else {
+    e := cmplx.Exp(z)
+    v0 = cmplx.Log(1.0+e)
+    v1 = e/(1.0+e)
+  }
+  return c.monadic(a, v0, v1, 0.0)
+}
+
+/* special functions are available on the real axis only, NaN is returned
+ * for arguments with non-zero imaginary part
+ * -------------------------------------------------------------------------- */
+
+func (c *Complex) realMonadic(a Scalar, f func(x float64) (float64, float64)) Scalar {
+  z := complexValue(a)
+  if imag(z) != 0.0 {
+    return c.monadic(a, cmplx.NaN(), cmplx.NaN(), 0.0)
+  }
+  v0, v1 := f(real(z))
+  return c.monadic(a, complex(v0, 0.0), complex(v1, 0.0), 0.0)
+}
+
+// Same as realMonadic for functions of two real arguments, where f returns
+// the value and both partial derivatives.
+func (c *Complex) realDyadic(a, b Scalar, f func(x, y float64) (float64, float64, float64)) Scalar {
+  x := complexValue(a)
+  y := complexValue(b)
+  if imag(x) != 0.0 || imag(y) != 0.0 {
+    return c.dyadic(a, b, cmplx.NaN(), cmplx.NaN(), 0.0, cmplx.NaN(), 0.0)
+  }
+  v0, v10, v01 := f(real(x), real(y))
+  return c.dyadic(a, b, complex(v0, 0.0), complex(v10, 0.0), 0.0, complex(v01, 0.0), 0.0)
+}
+
+func (c *Complex) Erf(a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    return math.Erf(x), 2.0/(math.Exp(x*x)*special.M_SQRTPI)
+  })
+}
+
+func (c *Complex) Erfc(a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    return math.Erfc(x), -2.0/(math.Exp(x*x)*special.M_SQRTPI)
+  })
+}
+
+func (c *Complex) LogErfc(a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    return special.LogErfc(x), -2.0/(math.Exp(x*x)*special.M_SQRTPI*math.Erfc(x))
+  })
+}
+
+func (c *Complex) Gamma(a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    v0 := math.Gamma(x)
+    return v0, v0*special.Digamma(x)
+  })
+}
+
+func (c *Complex) Lgamma(a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    v0, s := math.Lgamma(x)
+    if s == -1 {
+      v0 = math.NaN()
+    }
+    return v0, special.Digamma(x)
+  })
+}
+
+func (c *Complex) Mlgamma(a Scalar, k int) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    s := 0.0
+    for j := 1; j <= k; j++ {
+      s += special.Digamma(x + float64(1-j)/2.0)
+    }
+    return special.Mlgamma(x, k), s
+  })
+}
+
+func (c *Complex) GammaP(a, b Scalar) Scalar {
+  return c.realDyadic(a, b, func(s, x float64) (float64, float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return special.GammaP(s, x), v10, v01
+  })
+}
+
+func (c *Complex) GammaQ(a, b Scalar) Scalar {
+  return c.realDyadic(a, b, func(s, x float64) (float64, float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return special.GammaQ(s, x), -v10, -v01
+  })
+}
+
+func (c *Complex) Digamma(a Scalar) Scalar {
+  return c.Polygamma(0, a)
+}
+
+func (c *Complex) Trigamma(a Scalar) Scalar {
+  return c.Polygamma(1, a)
+}
+
+func (c *Complex) Polygamma(n int, a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    return special.Polygamma(n, x), special.Polygamma(n+1, x)
+  })
+}
+
+func (c *Complex) Beta(a, b Scalar) Scalar {
+  return c.realDyadic(a, b, func(x, y float64) (float64, float64, float64) {
+    v0 := special.Beta(x, y)
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v0, v0*v10, v0*v01
+  })
+}
+
+func (c *Complex) Lbeta(a, b Scalar) Scalar {
+  return c.realDyadic(a, b, func(x, y float64) (float64, float64, float64) {
+    v0, s := special.Lbeta(x, y)
+    if s == -1 {
+      v0 = math.NaN()
+    }
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v0, v10, v01
+  })
+}
+
+func (c *Complex) Zeta(a Scalar) Scalar {
+  return c.realMonadic(a, func(x float64) (float64, float64) {
+    return special.Zeta(x), special.ZetaDerivative(1, x)
+  })
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Bilinear product without complex conjugation.
+func (r *Complex) VdotV(a, b Vector) Scalar {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r.Reset()
+  t := NullComplex()
+  for i := 0; i < len(a); i++ {
+    t.Mul(a[i], b[i])
+    r.Add(r, t)
+  }
+  return r
+}
+
+// Euclidean norm sqrt(sum_i |a_i|^2).
+func (r *Complex) Vnorm(a Vector) Scalar {
+  r.Reset()
+  t := NullComplex()
+  for i := 0; i < len(a); i++ {
+    t.Abs(a[i])
+    t.Mul(t, t)
+    r.Add(r, t)
+  }
+  r.Sqrt(r)
+  return r
+}
+
+func (r *Complex) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullComplex()
+  t := NullComplex()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
@@ -0,0 +1,120 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "math/cmplx"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestComplex1(t *testing.T) {
+
+  z := NewComplex(0.3 + 0.7i)
+  z.SetVariable(0, 1, 1)
+
+  // f(z) = exp(z^2) sin(z), f'(z) = exp(z^2) (2z sin(z) + cos(z))
+  r := NullComplex()
+  r.Mul(z, z)
+  r.Exp(r)
+  r.Mul(r, Sin(z))
+
+  v := z.Value
+  if cmplx.Abs(r.Value - cmplx.Exp(v*v)*cmplx.Sin(v)) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+  if cmplx.Abs(r.GetComplexDerivative(0) - cmplx.Exp(v*v)*(2*v*cmplx.Sin(v) + cmplx.Cos(v))) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+  if r.GetConjugateDerivative(0) != 0.0 {
+    t.Error("Complex test failed!")
+  }
+}
+
+func TestComplex2(t *testing.T) {
+
+  z := NewComplex(1.5 - 2.0i)
+  z.SetVariable(0, 1, 1)
+
+  // f(z) = |z|^2 = z conj(z)
+  r := NullComplex()
+  r.Abs(z)
+  r.Mul(r, r)
+
+  if math.Abs(real(r.Value) - 6.25) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+  if cmplx.Abs(r.GetComplexDerivative(0) - cmplx.Conj(z.Value)) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+  if cmplx.Abs(r.GetConjugateDerivative(0) - z.Value) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+  // derivative with respect to the real part of z
+  if math.Abs(r.GetDerivative(1, 0) - 3.0) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+}
+
+func TestComplex3(t *testing.T) {
+
+  // on the real axis derivatives agree with Real
+  f := func(x Vector) Scalar {
+    y := Mul(Tanh(x[0]), Log(x[1]))
+    y  = Add(y, Div(Erf(x[1]), Sqrt(x[0])))
+    return y
+  }
+  x1 := NewVector(RealType,    []float64{0.8, 1.3})
+  x2 := NewVector(ComplexType, []float64{0.8, 1.3})
+
+  Variables(1, x1...)
+  Variables(1, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+    t.Error("Complex test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("Complex test failed!")
+    }
+  }
+}
+
+func TestComplex4(t *testing.T) {
+
+  z := NewComplex(complex(1.0, 2.0))
+
+  // second order derivatives are not supported
+  z.SetVariable(0, 1, 2)
+  if z.GetOrder() != 1 || z.GetDerivative(1, 0) != 1.0 || z.GetHessian(0, 0) != 0.0 {
+    t.Error("Complex test failed!")
+  }
+  // second derivatives are not available, hence the Hessian is zero
+  f := func(x Vector) Scalar {
+    return Mul(x[0], x[1])
+  }
+  x := NewVector(ComplexType, []float64{1.0, 2.0})
+  if Mnorm(Hessian(f, x)).GetValue() != 0.0 {
+    t.Error("Complex test failed!")
+  }
+}
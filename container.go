@@ -28,6 +28,7 @@ type ScalarContainer interface {
   ElementType() ScalarType
   ConvertElementType(ScalarType)
   Variables(int)
+  VariablesAt(int, int)
   // nice printing
   fmt.Stringer
 }
@@ -0,0 +1,370 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "sort"
+
+/* -------------------------------------------------------------------------- */
+
+// A Graph is a compiled expression graph of a function f: R^n -> R, which
+// is recorded once by NewGraph and may then be evaluated for new inputs
+// without any memory allocations. Branches of f that depend on comparisons
+// of values are fixed at the point where the graph was traced, whereas Min,
+// Max, and Abs are evaluated for each input.
+type Graph struct {
+  nodes    []graphNode
+  inputs   []int
+  order      int
+  value    []float64
+  // first and second partial derivatives of each node
+  partial  [][2]float64
+  hessian  [][3]float64
+  adjoint  []float64
+  // tangents of values and adjoints for Hessian-vector products
+  tangent  []float64
+  adjointT []float64
+  // temporary memory
+  r, a, b  *Real
+  x        []float64
+  gradient []float64
+  result   *Real
+  // constants carry derivatives of an enclosing context
+  outer    bool
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Trace f at x and compile the recorded operations into a graph. Constants
+// within f must be created with the element type of its argument (i.e.
+// NewScalar(x.ElementType(), v)) or as BareReal. An error is returned if
+// scalars of other types depend on the arguments of f.
+func NewGraph(f func(Vector) (Scalar, error), x Vector) (*Graph, error) {
+  t := &graphTape{}
+  v := NilVector(len(x))
+  for i := 0; i < len(x); i++ {
+    r := newGraphReal(x[i].GetValue())
+    r.tape = t
+    r.node = t.input(r.Value)
+    v[i] = r
+  }
+  y, err := f(v)
+  if err != nil {
+    return nil, err
+  }
+  k := t.nodeOf(y)
+  if t.escaped {
+    return nil, fmt.Errorf("NewGraph(): operations on scalars of other types cannot be recorded")
+  }
+  return newGraph(t, k), nil
+}
+
+// Keep only nodes that contribute to the output.
+func newGraph(t *graphTape, output int) *Graph {
+  used := make([]bool, output+1)
+  used[output] = true
+  for k := output; k >= 0; k-- {
+    if used[k] {
+      for _, j := range t.nodes[k].args {
+        if j >= 0 {
+          used[j] = true
+        }
+      }
+    }
+  }
+  index := make([]int, output+1)
+  g := Graph{}
+  for k := 0; k <= output; k++ {
+    if !used[k] {
+      continue
+    }
+    node := t.nodes[k]
+    for j, i := range node.args {
+      if i >= 0 {
+        node.args[j] = index[i]
+      }
+    }
+    index[k]  = len(g.nodes)
+    g.nodes   = append(g.nodes, node)
+    g.value   = append(g.value, t.values[k])
+  }
+  g.inputs = make([]int, len(t.inputs))
+  for i, k := range t.inputs {
+    if k <= output && used[k] {
+      g.inputs[i] = index[k]
+    } else {
+      g.inputs[i] = -1
+    }
+  }
+  n := len(g.nodes)
+  g.partial  = make([][2]float64, n)
+  g.hessian  = make([][3]float64, n)
+  g.adjoint  = make([]float64, n)
+  g.tangent  = make([]float64, n)
+  g.adjointT = make([]float64, n)
+  g.r        = NullReal()
+  g.a        = NullReal()
+  g.b        = NullReal()
+  g.x        = make([]float64, len(g.inputs))
+  g.gradient = make([]float64, len(g.inputs))
+  g.result   = NullReal()
+  g.outer    = t.outer
+  return &g
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Number of inputs.
+func (g *Graph) GetN() int {
+  return len(g.inputs)
+}
+
+// Evaluate all nodes. Partial derivatives are computed up to the given
+// order.
+func (g *Graph) forward(x []float64, order int) float64 {
+  if len(x) != len(g.inputs) {
+    panic("vector dimensions do not match")
+  }
+  if g.order != order {
+    g.a.SetVariable(0, 2, order)
+    g.b.SetVariable(1, 2, order)
+    g.order = order
+  }
+  for i, k := range g.inputs {
+    if k >= 0 {
+      g.value[k] = x[i]
+    }
+  }
+  for k := 0; k < len(g.nodes); k++ {
+    node := &g.nodes[k]
+    switch {
+    case node.f1 != nil:
+      g.a.SetValue(g.value[node.args[0]])
+      node.f1(g.r, g.a)
+    case node.f2 != nil:
+      g.a.SetValue(g.value[node.args[0]])
+      g.b.SetValue(g.value[node.args[1]])
+      node.f2(g.r, g.a, g.b)
+    default:
+      continue
+    }
+    g.value[k] = g.r.GetValue()
+    if order >= 1 {
+      g.partial[k][0] = g.r.GetDerivative(1, 0)
+      g.partial[k][1] = g.r.GetDerivative(1, 1)
+    }
+    if order >= 2 {
+      g.hessian[k][0] = g.r.GetHessian(0, 0)
+      g.hessian[k][1] = g.r.GetHessian(0, 1)
+      g.hessian[k][2] = g.r.GetHessian(1, 1)
+    }
+  }
+  return g.value[len(g.nodes)-1]
+}
+
+func (g *Graph) backward(gradient []float64) {
+  if len(gradient) != len(g.inputs) {
+    panic("vector dimensions do not match")
+  }
+  for k := 0; k < len(g.adjoint); k++ {
+    g.adjoint[k] = 0.0
+  }
+  g.adjoint[len(g.nodes)-1] = 1.0
+  for k := len(g.nodes)-1; k >= 0; k-- {
+    if g.adjoint[k] == 0.0 {
+      continue
+    }
+    for j, i := range g.nodes[k].args {
+      if i >= 0 {
+        g.adjoint[i] += g.adjoint[k]*g.partial[k][j]
+      }
+    }
+  }
+  for i, k := range g.inputs {
+    if k >= 0 {
+      gradient[i] = g.adjoint[k]
+    } else {
+      gradient[i] = 0.0
+    }
+  }
+}
+
+// Evaluate the graph at x.
+func (g *Graph) Value(x []float64) float64 {
+  return g.forward(x, 0)
+}
+
+// Evaluate the graph at x and store the gradient in the first argument.
+func (g *Graph) Gradient(gradient, x []float64) float64 {
+  v := g.forward(x, 1)
+  g.backward(gradient)
+  return v
+}
+
+// Evaluate the graph at x and store the product of the Hessian with v in
+// the first argument. The product is computed in forward-over-reverse mode
+// without computing the Hessian.
+func (g *Graph) Hvp(hv, x, v []float64) float64 {
+  if len(hv) != len(g.inputs) || len(v) != len(g.inputs) {
+    panic("vector dimensions do not match")
+  }
+  r := g.forward(x, 2)
+  // propagate tangents in direction v
+  for k := 0; k < len(g.nodes); k++ {
+    g.tangent [k] = 0.0
+    g.adjoint [k] = 0.0
+    g.adjointT[k] = 0.0
+  }
+  for i, k := range g.inputs {
+    if k >= 0 {
+      g.tangent[k] = v[i]
+    }
+  }
+  for k := 0; k < len(g.nodes); k++ {
+    for j, i := range g.nodes[k].args {
+      if i >= 0 {
+        g.tangent[k] += g.partial[k][j]*g.tangent[i]
+      }
+    }
+  }
+  // propagate adjoints and their tangents
+  g.adjoint[len(g.nodes)-1] = 1.0
+  for k := len(g.nodes)-1; k >= 0; k-- {
+    if g.adjoint[k] == 0.0 && g.adjointT[k] == 0.0 {
+      continue
+    }
+    args := g.nodes[k].args
+    // tangents of the partial derivatives
+    d := [2]float64{}
+    if args[0] >= 0 {
+      d[0] += g.hessian[k][0]*g.tangent[args[0]]
+      d[1] += g.hessian[k][1]*g.tangent[args[0]]
+    }
+    if args[1] >= 0 {
+      d[0] += g.hessian[k][1]*g.tangent[args[1]]
+      d[1] += g.hessian[k][2]*g.tangent[args[1]]
+    }
+    for j, i := range args {
+      if i >= 0 {
+        g.adjoint [i] += g.adjoint [k]*g.partial[k][j]
+        g.adjointT[i] += g.adjointT[k]*g.partial[k][j] + g.adjoint[k]*d[j]
+      }
+    }
+  }
+  for i, k := range g.inputs {
+    if k >= 0 {
+      hv[i] = g.adjointT[k]
+    } else {
+      hv[i] = 0.0
+    }
+  }
+  return r
+}
+
+// Evaluate the graph at x. Derivatives of the result are given with respect
+// to the variables of x, so that Eval can be used as objective function
+// for optimization algorithms. The returned scalar is overwritten by the
+// next call to Eval.
+func (g *Graph) Eval(x Vector) (Scalar, error) {
+  if len(x) != len(g.inputs) {
+    panic("vector dimensions do not match")
+  }
+  for i := 0; i < len(x); i++ {
+    g.x[i] = x[i].GetValue()
+  }
+  v := g.Gradient(g.gradient, g.x)
+  r := g.result
+  r.Order = 0
+  for i := 0; i < len(x); i++ {
+    r.Order = iMax(r.Order, iMin(x[i].GetOrder(), 1))
+  }
+  r.Alloc(x.GetN())
+  r.ResetDerivatives()
+  if r.Order >= 1 {
+    // chain rule
+    for i := 0; i < len(x); i++ {
+      if g.gradient[i] == 0.0 {
+        continue
+      }
+      for j := 0; j < x[i].GetN(); j++ {
+        r.Derivative[j] += g.gradient[i]*x[i].GetDerivative(1, j)
+      }
+    }
+  }
+  r.SetValue(v)
+  return r, nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Sparsity pattern of the Hessian. Pairs of inputs interact if they enter
+// an operation whose second partial derivative does not vanish identically,
+// hence the pattern does not depend on the point where it is evaluated.
+func (g *Graph) hessianSparsity() SparsityPattern {
+  n := len(g.inputs)
+  // inputs on which each node depends
+  deps := make([][]int, len(g.nodes))
+  for i, k := range g.inputs {
+    if k >= 0 {
+      deps[k] = []int{i}
+    }
+  }
+  pairs := make([]map[int]bool, n)
+  for i := 0; i < n; i++ {
+    pairs[i] = make(map[int]bool)
+  }
+  interact := func(a, b []int) {
+    for _, i := range a {
+      for _, j := range b {
+        pairs[i][j] = true
+        pairs[j][i] = true
+      }
+    }
+  }
+  for k, node := range g.nodes {
+    a, b := node.args[0], node.args[1]
+    if a < 0 {
+      continue
+    }
+    if b < 0 {
+      deps[k] = deps[a]
+    } else {
+      deps[k] = mergeIndices(deps[a], deps[b])
+    }
+    if !node.zero[0] {
+      interact(deps[a], deps[a])
+    }
+    if b >= 0 && !node.zero[1] {
+      interact(deps[a], deps[b])
+    }
+    if b >= 0 && !node.zero[2] {
+      interact(deps[b], deps[b])
+    }
+  }
+  p := make(SparsityPattern, n)
+  for i := 0; i < n; i++ {
+    p[i] = make([]int, 0, len(pairs[i]))
+    for j := range pairs[i] {
+      p[i] = append(p[i], j)
+    }
+    sort.Ints(p[i])
+  }
+  return p
+}
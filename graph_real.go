@@ -0,0 +1,239 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+
+/* -------------------------------------------------------------------------- */
+
+// A graphReal records operations on a graph tape while a function is
+// traced by NewGraph. Each node stores the operation as a function on Real
+// scalars, so that the graph can be evaluated again for new inputs and
+// partial derivatives of nodes are computed by the Real implementation.
+type graphReal struct {
+  Value float64
+  tape  *graphTape
+  node  int
+}
+
+type graphNode struct {
+  f1   func(r, a *Real)
+  f2   func(r, a, b *Real)
+  // operands, -1 if not present
+  args [2]int
+  // second partial derivatives with respect to (a,a), (a,b), and (b,b)
+  // that vanish identically
+  zero [3]bool
+}
+
+type graphTape struct {
+  nodes  []graphNode
+  values []float64
+  inputs []int
+  // derivatives of traced values were requested by scalars of other types
+  escaped bool
+  // constants carry derivatives of an enclosing context
+  outer   bool
+}
+
+func (t *graphTape) push(node graphNode, v float64) int {
+  t.nodes  = append(t.nodes,  node)
+  t.values = append(t.values, v)
+  return len(t.nodes)-1
+}
+
+func (t *graphTape) constant(v float64) int {
+  return t.push(graphNode{args: [2]int{-1, -1}}, v)
+}
+
+func (t *graphTape) input(v float64) int {
+  k := t.constant(v)
+  t.inputs = append(t.inputs, k)
+  return k
+}
+
+// Get the node of a scalar. Scalars of other types or other tapes are
+// recorded as constants.
+func (t *graphTape) nodeOf(a Scalar) int {
+  if r, ok := a.(*graphReal); ok && r.tape == t {
+    return r.node
+  }
+  t.checkConstant(a)
+  return t.constant(a.GetValue())
+}
+
+// Constants that carry derivatives depend on variables of an enclosing
+// context, which are not recorded.
+func (t *graphTape) checkConstant(a Scalar) {
+  if a.GetOrder() >= 1 {
+    for i := 0; i < a.GetN(); i++ {
+      if a.GetDerivative(1, i) != 0.0 {
+        t.outer = true
+      }
+    }
+  }
+}
+
+func graphTapeOf(a Scalar) *graphTape {
+  if r, ok := a.(*graphReal); ok {
+    return r.tape
+  }
+  return nil
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var graphRealType ScalarType = newGraphReal(0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return newGraphReal(value) }
+  RegisterScalar(graphRealType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+func newGraphReal(v float64) *graphReal {
+  return &graphReal{Value: v}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *graphReal) Clone() Scalar {
+  r := newGraphReal(0.0)
+  r.Copy(a)
+  return r
+}
+
+func (a *graphReal) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *graphReal) String() string {
+  return fmt.Sprintf("%e", a.GetValue())
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Copy value and graph position from b. Scalars of other types are
+// constants.
+func (a *graphReal) Copy(b Scalar) {
+  if r, ok := b.(*graphReal); ok {
+    a.Value = r.Value
+    a.tape  = r.tape
+    a.node  = r.node
+  } else {
+    a.Value = b.GetValue()
+    a.tape  = nil
+  }
+}
+
+func (a *graphReal) Alloc(n int) {
+}
+
+func (c *graphReal) AllocForOne(a Scalar) {
+}
+
+func (c *graphReal) AllocForTwo(a, b Scalar) {
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *graphReal) GetOrder() int {
+  if a.tape == nil {
+    return 0
+  }
+  return 1
+}
+
+func (a *graphReal) GetValue() float64 {
+  return a.Value
+}
+
+func (a *graphReal) GetLogValue() float64 {
+  return math.Log(a.Value)
+}
+
+// Derivatives are only known after the graph is evaluated, hence a
+// traced scalar carries no derivatives. Scalars of other types that request
+// them cannot be recorded, which is reported by NewGraph.
+func (a *graphReal) GetDerivative(i, j int) float64 {
+  if a.tape != nil {
+    a.tape.escaped = true
+  }
+  return 0.0
+}
+
+func (a *graphReal) GetHessian(i, j int) float64 {
+  if a.tape != nil {
+    a.tape.escaped = true
+  }
+  return 0.0
+}
+
+func (a *graphReal) GetN() int {
+  if a.tape == nil {
+    return 0
+  }
+  return len(a.tape.inputs)
+}
+
+func (a *graphReal) SetN(n int) {
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *graphReal) Reset() {
+  a.Value = 0.0
+  a.tape  = nil
+}
+
+func (a *graphReal) ResetDerivatives() {
+  a.tape = nil
+}
+
+func (a *graphReal) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Setting the value turns the scalar into a constant.
+func (a *graphReal) SetValue(v float64) {
+  a.Value = v
+  a.tape  = nil
+}
+
+func (a *graphReal) SetDerivative(i, j int, v float64) {
+  panic("derivatives cannot be set while tracing a graph")
+}
+
+func (a *graphReal) SetHessian(i, j int, v float64) {
+  panic("derivatives cannot be set while tracing a graph")
+}
+
+func (a *graphReal) SetVariable(i, n, order int) {
+  panic("variables of a graph are declared by NewGraph")
+}
@@ -0,0 +1,119 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* record monadic functions
+ * -------------------------------------------------------------------------- */
+
+// Record f, which evaluates the operation on Real scalars. If a is a
+// constant, the result is again a constant.
+func (c *graphReal) monadic(a Scalar, f func(r, a *Real)) Scalar {
+  return c.monadicZero(a, f, false)
+}
+
+// Record f, where zero indicates that the second derivative of f vanishes
+// identically.
+func (c *graphReal) monadicZero(a Scalar, f func(r, a *Real), zero bool) Scalar {
+  x := NewReal(a.GetValue())
+  r := NullReal()
+  f(r, x)
+  if t := graphTapeOf(a); t != nil {
+    c.node = t.push(graphNode{f1: f, args: [2]int{t.nodeOf(a), -1}, zero: [3]bool{zero, true, true}}, r.Value)
+    c.tape = t
+  } else {
+    c.tape = nil
+  }
+  c.Value = r.Value
+  return c
+}
+
+/* record dyadic functions
+ * -------------------------------------------------------------------------- */
+
+// Record f, which evaluates the operation on Real scalars. Constant
+// operands are bound to f, so that the operation is recorded as monadic
+// function.
+func (c *graphReal) dyadic(a, b Scalar, f func(r, a, b *Real)) Scalar {
+  return c.dyadicZero(a, b, f, [3]bool{})
+}
+
+// Record f, where zero indicates which second partial derivatives with
+// respect to (a,a), (a,b), and (b,b) vanish identically.
+func (c *graphReal) dyadicZero(a, b Scalar, f func(r, a, b *Real), zero [3]bool) Scalar {
+  x := NewReal(a.GetValue())
+  y := NewReal(b.GetValue())
+  t := graphTapeOf(a)
+  s := graphTapeOf(b)
+  switch {
+  case t != nil && s != nil && t != s:
+    panic("operands belong to different graphs")
+  case t == nil && s == nil:
+    r := NullReal()
+    f(r, x, y)
+    c.tape  = nil
+    c.Value = r.Value
+    return c
+  case s == nil:
+    t.checkConstant(b)
+    return c.monadicZero(a, func(r, a *Real) { f(r, a, y) }, zero[0])
+  case t == nil:
+    s.checkConstant(a)
+    return c.monadicZero(b, func(r, b *Real) { f(r, x, b) }, zero[2])
+  }
+  r := NullReal()
+  f(r, x, y)
+  c.node  = t.push(graphNode{f2: f, args: [2]int{t.nodeOf(a), t.nodeOf(b)}, zero: zero}, r.Value)
+  c.tape  = t
+  c.Value = r.Value
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+// The result is recorded as operation with zero derivatives, so that its
+// value is still updated when the graph is evaluated at a new point.
+func (c *graphReal) StopGradient(a Scalar) Scalar {
+  return c.monadicZero(a, func(r, a *Real) { r.StopGradient(a) }, true)
+}
+
+/* user-defined functions
+ * -------------------------------------------------------------------------- */
+
+// Values of user-defined functions are only known at a single point and
+// cannot be replayed.
+func (c *graphReal) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  if graphTapeOf(a) != nil {
+    panic("user-defined functions cannot be recorded")
+  }
+  c.Value = v0
+  c.tape  = nil
+  return c
+}
+
+func (c *graphReal) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  if graphTapeOf(a) != nil || graphTapeOf(b) != nil {
+    panic("user-defined functions cannot be recorded")
+  }
+  c.Value = v0
+  c.tape  = nil
+  return c
+}
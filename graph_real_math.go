@@ -0,0 +1,268 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
+func (a *graphReal) Equals(b Scalar) bool {
+  epsilon := 1e-12
+  return math.Abs(a.GetValue() - b.GetValue()) < epsilon
+}
+
+func (a *graphReal) Greater(b Scalar) bool {
+  return a.GetValue() > b.GetValue()
+}
+
+func (a *graphReal) Smaller(b Scalar) bool {
+  return a.GetValue() < b.GetValue()
+}
+
+// Min and Max are recorded as operations, so that the choice between a and
+// b is made whenever the graph is evaluated.
+func (a *graphReal) Min(b Scalar) Scalar {
+  return newGraphReal(0.0).dyadicZero(a, b, func(r, a, b *Real) { r.Set(a.Min(b)) }, [3]bool{true, true, true})
+}
+
+func (a *graphReal) Max(b Scalar) Scalar {
+  return newGraphReal(0.0).dyadicZero(a, b, func(r, a, b *Real) { r.Set(a.Max(b)) }, [3]bool{true, true, true})
+}
+
+func (c *graphReal) Abs(a Scalar) Scalar {
+  return c.monadicZero(a, func(r, a *Real) { r.Abs(a) }, true)
+}
+
+func (a *graphReal) Sign() int {
+  if a.GetValue() < 0.0 {
+    return -1
+  }
+  if a.GetValue() > 0.0 {
+    return  1
+  }
+  return 0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *graphReal) Neg(a Scalar) Scalar {
+  return c.monadicZero(a, func(r, a *Real) { r.Neg(a) }, true)
+}
+
+func (c *graphReal) Add(a, b Scalar) Scalar {
+  return c.dyadicZero(a, b, func(r, a, b *Real) { r.Add(a, b) }, [3]bool{true, true, true})
+}
+
+func (c *graphReal) Sub(a, b Scalar) Scalar {
+  return c.dyadicZero(a, b, func(r, a, b *Real) { r.Sub(a, b) }, [3]bool{true, true, true})
+}
+
+func (c *graphReal) Mul(a, b Scalar) Scalar {
+  return c.dyadicZero(a, b, func(r, a, b *Real) { r.Mul(a, b) }, [3]bool{true, false, true})
+}
+
+func (c *graphReal) Div(a, b Scalar) Scalar {
+  return c.dyadicZero(a, b, func(r, a, b *Real) { r.Div(a, b) }, [3]bool{true, false, false})
+}
+
+func (c *graphReal) Pow(a, k Scalar) Scalar {
+  return c.dyadic(a, k, func(r, a, k *Real) { r.Pow(a, k) })
+}
+
+func (c *graphReal) Sqrt(a Scalar) Scalar {
+  return c.Pow(a, NewBareReal(1.0/2.0))
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *graphReal) Sin(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Sin(a) })
+}
+
+func (c *graphReal) Sinh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Sinh(a) })
+}
+
+func (c *graphReal) Cos(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Cos(a) })
+}
+
+func (c *graphReal) Cosh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Cosh(a) })
+}
+
+func (c *graphReal) Tan(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Tan(a) })
+}
+
+func (c *graphReal) Tanh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Tanh(a) })
+}
+
+func (c *graphReal) Asin(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Asin(a) })
+}
+
+func (c *graphReal) Acos(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Acos(a) })
+}
+
+func (c *graphReal) Atan(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Atan(a) })
+}
+
+func (c *graphReal) Atan2(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b *Real) { r.Atan2(a, b) })
+}
+
+func (c *graphReal) Asinh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Asinh(a) })
+}
+
+func (c *graphReal) Acosh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Acosh(a) })
+}
+
+func (c *graphReal) Atanh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Atanh(a) })
+}
+
+func (c *graphReal) Exp(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Exp(a) })
+}
+
+func (c *graphReal) Log(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Log(a) })
+}
+
+func (c *graphReal) Log1p(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Log1p(a) })
+}
+
+func (c *graphReal) Expm1(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Expm1(a) })
+}
+
+func (c *graphReal) Logistic(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Logistic(a) })
+}
+
+func (c *graphReal) Softplus(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Softplus(a) })
+}
+
+func (c *graphReal) Erf(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Erf(a) })
+}
+
+func (c *graphReal) Erfc(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Erfc(a) })
+}
+
+func (c *graphReal) LogErfc(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.LogErfc(a) })
+}
+
+func (c *graphReal) Gamma(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Gamma(a) })
+}
+
+func (c *graphReal) Lgamma(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Lgamma(a) })
+}
+
+func (c *graphReal) Mlgamma(a Scalar, k int) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Mlgamma(a, k) })
+}
+
+func (c *graphReal) GammaP(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b *Real) { r.GammaP(a, b) })
+}
+
+func (c *graphReal) GammaQ(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b *Real) { r.GammaQ(a, b) })
+}
+
+func (c *graphReal) Digamma(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Digamma(a) })
+}
+
+func (c *graphReal) Trigamma(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Trigamma(a) })
+}
+
+func (c *graphReal) Polygamma(n int, a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Polygamma(n, a) })
+}
+
+func (c *graphReal) Beta(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b *Real) { r.Beta(a, b) })
+}
+
+func (c *graphReal) Lbeta(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b *Real) { r.Lbeta(a, b) })
+}
+
+func (c *graphReal) Zeta(a Scalar) Scalar {
+  return c.monadic(a, func(r, a *Real) { r.Zeta(a) })
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (r *graphReal) VdotV(a, b Vector) Scalar {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r.Reset()
+  t := newGraphReal(0.0)
+  for i := 0; i < len(a); i++ {
+    t.Mul(a[i], b[i])
+    r.Add(r, t)
+  }
+  return r
+}
+
+func (r *graphReal) Vnorm(a Vector) Scalar {
+  r.Reset()
+  c := NewBareReal(2.0)
+  t := newGraphReal(0.0)
+  for i := 0; i < len(a); i++ {
+    t.Pow(a[i], c)
+    r.Add(r, t)
+  }
+  r.Sqrt(r)
+  return r
+}
+
+// The maximum, which is subtracted to avoid overflow, is fixed when the
+// graph is traced.
+func (r *graphReal) LogSumExp(a Vector) Scalar {
+  m := NewBareReal(logSumExpMax(a))
+  s := newGraphReal(0.0)
+  t := newGraphReal(0.0)
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
@@ -0,0 +1,151 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestGraph1(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    // y = x0^3 sin(x1) + exp(x0 x1) / x2 + atan2(x0, x1)
+    c := NewScalar(x.ElementType(), 3.0)
+    y := Mul(Pow(x[0], c), Sin(x[1]))
+    y  = Add(y, Div(Exp(Mul(x[0], x[1])), x[2]))
+    y  = Add(y, Atan2(x[0], x[1]))
+    return y, nil
+  }
+  g, err := NewGraph(f, NewVector(RealType, []float64{1.2, 0.7, 2.3}))
+  if err != nil {
+    t.Fatal(err)
+  }
+  // evaluate at points different from the trace
+  for _, p := range [][]float64{{1.2, 0.7, 2.3}, {0.3, 1.9, 0.8}, {-1.1, 0.2, 1.5}} {
+    x1 := NewVector(RealType, p)
+    x2 := NewVector(RealType, p)
+    Variables(1, x1...)
+    Variables(1, x2...)
+
+    y1, _ := f(x1)
+    y2, _ := g.Eval(x2)
+
+    if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+      t.Error("Graph test failed!")
+    }
+    for i := 0; i < 3; i++ {
+      if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+        t.Error("Graph test failed!")
+      }
+    }
+  }
+}
+
+func TestGraph2(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    return Add(Vnorm(x), LogSumExp(x)), nil
+  }
+  g, err := NewGraph(f, NewVector(RealType, []float64{1, 2, 3, 4}))
+  if err != nil {
+    t.Fatal(err)
+  }
+  x := []float64{0.5, -1.0, 2.0, 0.0}
+  d := make([]float64, 4)
+
+  // evaluating the graph does not allocate memory
+  if n := testing.AllocsPerRun(10, func() { g.Gradient(d, x) }); n != 0 {
+    t.Error("Graph test failed!")
+  }
+  if n := testing.AllocsPerRun(10, func() { g.Hvp(d, x, x) }); n != 0 {
+    t.Error("Graph test failed!")
+  }
+  r := NewVector(RealType, x)
+  Variables(1, r...)
+  s, _ := f(r)
+  if math.Abs(g.Gradient(d, x) - s.GetValue()) > 1e-12 {
+    t.Error("Graph test failed!")
+  }
+  for i := 0; i < 4; i++ {
+    if math.Abs(d[i] - s.GetDerivative(1, i)) > 1e-10 {
+      t.Error("Graph test failed!")
+    }
+  }
+}
+
+func TestGraphStopGradient(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    // y = x0 x1 + sin(x0 x1), where the second term is constant
+    y := Mul(x[0], x[1])
+    return Add(y, StopGradient(Sin(y))), nil
+  }
+  g, err := NewGraph(f, NewVector(RealType, []float64{1.2, 0.7}))
+  if err != nil {
+    t.Fatal(err)
+  }
+  // the constant must be updated at new points
+  for _, p := range [][]float64{{1.2, 0.7}, {0.3, 1.9}} {
+    x1 := NewVector(RealType, p)
+    x2 := NewVector(RealType, p)
+    Variables(2, x1...)
+    Variables(2, x2...)
+
+    y1, _ := f(x1)
+    y2, _ := g.Eval(x2)
+
+    if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+      t.Error("Graph test failed!")
+    }
+    for i := 0; i < 2; i++ {
+      if math.Abs(y1.GetDerivative(1, i) - p[1-i]) > 1e-12 ||
+         math.Abs(y2.GetDerivative(1, i) - p[1-i]) > 1e-12 {
+        t.Error("Graph test failed!")
+      }
+    }
+    hv := make([]float64, 2)
+    g.Hvp(hv, p, []float64{1.0, 0.0})
+    if math.Abs(hv[0]) > 1e-12 || math.Abs(hv[1] - 1.0) > 1e-12 {
+      t.Error("Graph test failed!")
+    }
+  }
+}
+
+func TestGraphMinMax(t *testing.T) {
+
+  f := func(x Vector) (Scalar, error) {
+    return Add(Max(x[0], x[1]), Mul(Min(x[0], x[1]), x[1])), nil
+  }
+  g, err := NewGraph(f, NewVector(RealType, []float64{1.0, 2.0}))
+  if err != nil {
+    t.Fatal(err)
+  }
+  // the branch is chosen at evaluation time
+  x := []float64{5.0, 2.0}
+  d := make([]float64, 2)
+
+  if v := g.Gradient(d, x); math.Abs(v - 9.0) > 1e-12 {
+    t.Error("Graph test failed!")
+  }
+  if math.Abs(d[0] - 1.0) > 1e-12 || math.Abs(d[1] - 4.0) > 1e-12 {
+    t.Error("Graph test failed!")
+  }
+}
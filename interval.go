@@ -0,0 +1,276 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+
+/* -------------------------------------------------------------------------- */
+
+// An Interval is a scalar given by lower and upper bounds. All operations
+// use outward rounding, so that the result of a computation is guaranteed
+// to contain the exact result for every point in the input intervals. The
+// same holds for the first derivatives, which are also stored as intervals.
+// Second derivatives are not supported.
+//
+// The Scalar interface gives access to the midpoints of the intervals.
+type Interval struct {
+  Lower              float64
+  Upper              float64
+  Order              int
+  N                  int
+  DerivativeLower  []float64
+  DerivativeUpper  []float64
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var IntervalType ScalarType = NewInterval(0.0, 0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return NewInterval(value, value) }
+  RegisterScalar(IntervalType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a new interval [lower, upper].
+func NewInterval(lower, upper float64) *Interval {
+  return &Interval{Lower: lower, Upper: upper}
+}
+
+func NullInterval() *Interval {
+  return &Interval{}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Interval) Clone() Scalar {
+  r := NullInterval()
+  r.Copy(a)
+  return r
+}
+
+func (a *Interval) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *Interval) String() string {
+  return fmt.Sprintf("[%e, %e]", a.Lower, a.Upper)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Returns the enclosure of the value of a. Scalars of other types are
+// converted to point intervals.
+func intervalValue(a Scalar) ival {
+  if r, ok := a.(*Interval); ok {
+    return ival{r.Lower, r.Upper}
+  }
+  return ivalPoint(a.GetValue())
+}
+
+// Returns the enclosure of the derivative of a with respect to the jth
+// variable.
+func intervalDerivative(a Scalar, j int) ival {
+  if r, ok := a.(*Interval); ok {
+    if j >= r.N {
+      return ivalPoint(0.0)
+    }
+    return ival{r.DerivativeLower[j], r.DerivativeUpper[j]}
+  }
+  if a.GetOrder() < 1 || j >= a.GetN() {
+    return ivalPoint(0.0)
+  }
+  return ivalPoint(a.GetDerivative(1, j))
+}
+
+func (a *Interval) setValue(v ival) {
+  a.Lower = v.lo
+  a.Upper = v.hi
+}
+
+func (a *Interval) setDerivative(j int, v ival) {
+  a.DerivativeLower[j] = v.lo
+  a.DerivativeUpper[j] = v.hi
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Interval) Copy(b Scalar) {
+  a.Order = iMin(b.GetOrder(), 1)
+  a.Alloc(b.GetN())
+  for j := 0; j < a.N; j++ {
+    a.setDerivative(j, intervalDerivative(b, j))
+  }
+  a.setValue(intervalValue(b))
+}
+
+// Allocate memory for derivatives of n variables.
+func (a *Interval) Alloc(n int) {
+  if a.N != n {
+    a.DerivativeLower = make([]float64, n)
+    a.DerivativeUpper = make([]float64, n)
+    a.N               = n
+  }
+}
+
+func (c *Interval) AllocForOne(a Scalar) {
+  c.Order = iMin(a.GetOrder(), 1)
+  c.Alloc(a.GetN())
+}
+
+func (c *Interval) AllocForTwo(a, b Scalar) {
+  c.Order = iMin(iMax(a.GetOrder(), b.GetOrder()), 1)
+  c.Alloc(iMax(a.GetN(), b.GetN()))
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *Interval) GetOrder() int {
+  return a.Order
+}
+
+// Returns the midpoint of the interval.
+func (a *Interval) GetValue() float64 {
+  return a.Lower + (a.Upper - a.Lower)/2.0
+}
+
+func (a *Interval) GetLogValue() float64 {
+  return math.Log(a.GetValue())
+}
+
+func (a *Interval) GetLower() float64 {
+  return a.Lower
+}
+
+func (a *Interval) GetUpper() float64 {
+  return a.Upper
+}
+
+// Returns the midpoint of the enclosure of the first derivative with
+// respect to the jth variable. Second derivatives are not available.
+func (a *Interval) GetDerivative(i, j int) float64 {
+  switch i {
+  case 1:
+    if j >= a.N {
+      return 0.0
+    }
+    return a.DerivativeLower[j] + (a.DerivativeUpper[j] - a.DerivativeLower[j])/2.0
+  case 2:
+    return 0.0
+  default:
+    panic("Invalid order!")
+  }
+}
+
+func (a *Interval) GetDerivativeLower(j int) float64 {
+  if j >= a.N {
+    return 0.0
+  }
+  return a.DerivativeLower[j]
+}
+
+func (a *Interval) GetDerivativeUpper(j int) float64 {
+  if j >= a.N {
+    return 0.0
+  }
+  return a.DerivativeUpper[j]
+}
+
+func (a *Interval) GetHessian(i, j int) float64 {
+  return 0.0
+}
+
+func (a *Interval) GetN() int {
+  return a.N
+}
+
+func (a *Interval) SetN(n int) {
+  a.Alloc(n)
+}
+
+// Check if v is contained in the interval.
+func (a *Interval) Contains(v float64) bool {
+  return a.Lower <= v && v <= a.Upper
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *Interval) Reset() {
+  a.Lower = 0.0
+  a.Upper = 0.0
+  a.ResetDerivatives()
+}
+
+func (a *Interval) ResetDerivatives() {
+  for j := 0; j < a.N; j++ {
+    a.DerivativeLower[j] = 0.0
+    a.DerivativeUpper[j] = 0.0
+  }
+}
+
+func (a *Interval) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Set the interval to the single point v.
+func (a *Interval) SetValue(v float64) {
+  a.Lower = v
+  a.Upper = v
+}
+
+func (a *Interval) SetInterval(lower, upper float64) {
+  a.Lower = lower
+  a.Upper = upper
+}
+
+func (a *Interval) SetDerivative(i, j int, v float64) {
+  if i != 1 {
+    panic("Invalid order!")
+  }
+  a.DerivativeLower[j] = v
+  a.DerivativeUpper[j] = v
+}
+
+func (a *Interval) SetHessian(i, j int, v float64) {
+}
+
+// Allocate memory for n variables and set the derivative
+// of the ith variable to 1 (initial value).
+func (a *Interval) SetVariable(i, n, order int) {
+  if order > 1 {
+    panic("Invalid order!")
+  }
+  a.Order = order
+  a.Alloc(n)
+  a.ResetDerivatives()
+  if order > 0 {
+    a.DerivativeLower[i] = 1.0
+    a.DerivativeUpper[i] = 1.0
+  }
+}
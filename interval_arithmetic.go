@@ -0,0 +1,533 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "math"
+
+import "github.com/pbenner/autodiff/special"
+
+/* Interval arithmetic with outward rounding. Results of floating point
+ * operations are rounded outward by one ulp. Library functions are assumed
+ * to be accurate up to a relative error of ivalEpsElementary (elementary
+ * functions) or ivalEpsSpecial (special functions), results are widened
+ * accordingly.
+ * -------------------------------------------------------------------------- */
+
+const ivalEpsElementary = 1e-15
+const ivalEpsSpecial    = 1e-12
+
+// minimum of the gamma and log gamma function on the positive real line
+const ivalGammaMinimum  = 1.4616321449683623
+
+type ival struct {
+  lo float64
+  hi float64
+}
+
+/* -------------------------------------------------------------------------- */
+
+func ivalDown(x float64) float64 {
+  return math.Nextafter(x, math.Inf(-1))
+}
+
+func ivalUp(x float64) float64 {
+  return math.Nextafter(x, math.Inf(1))
+}
+
+// Create a new interval with outward rounding.
+func newIval(lo, hi float64) ival {
+  if math.IsNaN(lo) || math.IsNaN(hi) {
+    return ivalNaN()
+  }
+  return ival{ivalDown(lo), ivalUp(hi)}
+}
+
+func ivalPoint(x float64) ival {
+  return ival{x, x}
+}
+
+func ivalEntire() ival {
+  return ival{math.Inf(-1), math.Inf(1)}
+}
+
+func ivalNaN() ival {
+  return ival{math.NaN(), math.NaN()}
+}
+
+func (a ival) contains(x float64) bool {
+  return a.lo <= x && x <= a.hi
+}
+
+func (a ival) isNaN() bool {
+  return math.IsNaN(a.lo) || math.IsNaN(a.hi)
+}
+
+// Widen the interval by a relative error eps.
+func (a ival) widen(eps float64) ival {
+  return newIval(a.lo - eps*math.Abs(a.lo), a.hi + eps*math.Abs(a.hi))
+}
+
+// Intersect the interval with [lo, hi].
+func (a ival) clamp(lo, hi float64) ival {
+  return ival{math.Max(a.lo, lo), math.Min(a.hi, hi)}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func ivalHull(a, b ival) ival {
+  return ival{math.Min(a.lo, b.lo), math.Max(a.hi, b.hi)}
+}
+
+// Bounds of the elements of x, which may contain NaN values.
+func ivalBounds(x ...float64) ival {
+  r := ival{x[0], x[0]}
+  for _, v := range x {
+    if math.IsNaN(v) {
+      return ivalEntire()
+    }
+    r.lo = math.Min(r.lo, v)
+    r.hi = math.Max(r.hi, v)
+  }
+  return newIval(r.lo, r.hi)
+}
+
+func ivalIncreasing(a ival, f func(float64) float64, eps float64) ival {
+  return newIval(f(a.lo), f(a.hi)).widen(eps)
+}
+
+func ivalDecreasing(a ival, f func(float64) float64, eps float64) ival {
+  return newIval(f(a.hi), f(a.lo)).widen(eps)
+}
+
+// Check if a contains a point t0 + k*period for some integer k. For large
+// arguments the check is conservative.
+func ivalContainsPeriodic(a ival, t0, period float64) bool {
+  if math.IsInf(a.lo, 0) || math.IsInf(a.hi, 0) || a.hi - a.lo >= period {
+    return true
+  }
+  tol := 1e-12*(1.0 + math.Max(math.Abs(a.lo), math.Abs(a.hi)))
+  k := math.Ceil((a.lo - tol - t0)/period)
+  return t0 + k*period <= a.hi + tol
+}
+
+/* arithmetic
+ * -------------------------------------------------------------------------- */
+
+func ivalNeg(a ival) ival {
+  return ival{-a.hi, -a.lo}
+}
+
+func ivalAbs(a ival) ival {
+  switch {
+  case a.lo >= 0.0:
+    return a
+  case a.hi <= 0.0:
+    return ivalNeg(a)
+  default:
+    return ival{0.0, math.Max(-a.lo, a.hi)}
+  }
+}
+
+func ivalAdd(a, b ival) ival {
+  return newIval(a.lo + b.lo, a.hi + b.hi)
+}
+
+func ivalSub(a, b ival) ival {
+  return newIval(a.lo - b.hi, a.hi - b.lo)
+}
+
+// Product of two bounds, where 0*Inf = 0.
+func ivalMulBounds(x, y float64) float64 {
+  if x == 0.0 || y == 0.0 {
+    return 0.0
+  }
+  return x*y
+}
+
+func ivalMul(a, b ival) ival {
+  if a.isNaN() || b.isNaN() {
+    return ivalNaN()
+  }
+  return ivalBounds(
+    ivalMulBounds(a.lo, b.lo),
+    ivalMulBounds(a.lo, b.hi),
+    ivalMulBounds(a.hi, b.lo),
+    ivalMulBounds(a.hi, b.hi))
+}
+
+func ivalDiv(a, b ival) ival {
+  if a.isNaN() || b.isNaN() {
+    return ivalNaN()
+  }
+  if b.contains(0.0) {
+    if b.lo == 0.0 && b.hi == 0.0 {
+      return ivalNaN()
+    }
+    return ivalEntire()
+  }
+  return ivalBounds(a.lo/b.lo, a.lo/b.hi, a.hi/b.lo, a.hi/b.hi)
+}
+
+func ivalScale(a ival, c float64) ival {
+  return ivalMul(a, ivalPoint(c))
+}
+
+func ivalSqr(a ival) ival {
+  b := ivalAbs(a)
+  return newIval(b.lo*b.lo, b.hi*b.hi)
+}
+
+// Power with constant exponent p. For non-integer exponents the interval
+// is restricted to the non-negative real line.
+func ivalPow(a ival, p float64) ival {
+  f := func(x float64) float64 { return math.Pow(x, p) }
+  eps := ivalEpsElementary*(1.0 + math.Abs(p))
+  if p == math.Floor(p) && math.Abs(p) < 1e15 {
+    switch {
+    case p == 0.0:
+      return ivalPoint(1.0)
+    case p < 0.0:
+      return ivalDiv(ivalPoint(1.0), ivalPow(a, -p))
+    case math.Mod(p, 2.0) == 0.0:
+      return ivalIncreasing(ivalAbs(a), f, eps)
+    default:
+      return ivalIncreasing(a, f, eps)
+    }
+  }
+  if a.hi < 0.0 {
+    return ivalNaN()
+  }
+  a = a.clamp(0.0, math.Inf(1))
+  if p > 0.0 {
+    return ivalIncreasing(a, f, eps)
+  } else {
+    return ivalDecreasing(a, f, eps)
+  }
+}
+
+/* elementary functions
+ * -------------------------------------------------------------------------- */
+
+func ivalExp(a ival) ival {
+  return ivalIncreasing(a, math.Exp, ivalEpsElementary).clamp(0.0, math.Inf(1))
+}
+
+func ivalLog(a ival) ival {
+  if a.hi < 0.0 {
+    return ivalNaN()
+  }
+  return ivalIncreasing(a.clamp(0.0, math.Inf(1)), math.Log, ivalEpsElementary)
+}
+
+func ivalLog1p(a ival) ival {
+  if a.hi < -1.0 {
+    return ivalNaN()
+  }
+  return ivalIncreasing(a.clamp(-1.0, math.Inf(1)), math.Log1p, ivalEpsElementary)
+}
+
+func ivalExpm1(a ival) ival {
+  return ivalIncreasing(a, math.Expm1, ivalEpsElementary).clamp(-1.0, math.Inf(1))
+}
+
+func ivalLogistic(a ival) ival {
+  return ivalIncreasing(a, special.Logistic, ivalEpsElementary).clamp(0.0, 1.0)
+}
+
+func ivalSoftplus(a ival) ival {
+  return ivalIncreasing(a, special.Softplus, ivalEpsElementary).clamp(0.0, math.Inf(1))
+}
+
+func ivalSin(a ival) ival {
+  r := ivalBounds(math.Sin(a.lo), math.Sin(a.hi)).widen(ivalEpsElementary)
+  if ivalContainsPeriodic(a, math.Pi/2.0, 2.0*math.Pi) {
+    r.hi = 1.0
+  }
+  if ivalContainsPeriodic(a, -math.Pi/2.0, 2.0*math.Pi) {
+    r.lo = -1.0
+  }
+  return r.clamp(-1.0, 1.0)
+}
+
+func ivalCos(a ival) ival {
+  r := ivalBounds(math.Cos(a.lo), math.Cos(a.hi)).widen(ivalEpsElementary)
+  if ivalContainsPeriodic(a, 0.0, 2.0*math.Pi) {
+    r.hi = 1.0
+  }
+  if ivalContainsPeriodic(a, math.Pi, 2.0*math.Pi) {
+    r.lo = -1.0
+  }
+  return r.clamp(-1.0, 1.0)
+}
+
+func ivalTan(a ival) ival {
+  if ivalContainsPeriodic(a, math.Pi/2.0, math.Pi) {
+    return ivalEntire()
+  }
+  return ivalIncreasing(a, math.Tan, ivalEpsElementary)
+}
+
+func ivalSinh(a ival) ival {
+  return ivalIncreasing(a, math.Sinh, ivalEpsElementary)
+}
+
+func ivalCosh(a ival) ival {
+  return ivalIncreasing(ivalAbs(a), math.Cosh, ivalEpsElementary).clamp(1.0, math.Inf(1))
+}
+
+func ivalTanh(a ival) ival {
+  return ivalIncreasing(a, math.Tanh, ivalEpsElementary).clamp(-1.0, 1.0)
+}
+
+func ivalAsin(a ival) ival {
+  if a.hi < -1.0 || a.lo > 1.0 {
+    return ivalNaN()
+  }
+  return ivalIncreasing(a.clamp(-1.0, 1.0), math.Asin, ivalEpsElementary)
+}
+
+func ivalAcos(a ival) ival {
+  if a.hi < -1.0 || a.lo > 1.0 {
+    return ivalNaN()
+  }
+  return ivalDecreasing(a.clamp(-1.0, 1.0), math.Acos, ivalEpsElementary).clamp(0.0, math.Inf(1))
+}
+
+func ivalAtan(a ival) ival {
+  return ivalIncreasing(a, math.Atan, ivalEpsElementary)
+}
+
+// Enclosure of atan2(a, b). If b contains values on the branch cut, i.e. the
+// negative real axis, the result is [-pi, pi].
+func ivalAtan2(a, b ival) ival {
+  switch {
+  case b.lo > 0.0:
+    return ivalAtan(ivalDiv(a, b))
+  case a.lo > 0.0:
+    return ivalSub(newIval(math.Pi/2.0, math.Pi/2.0), ivalAtan(ivalDiv(b, a)))
+  case a.hi < 0.0:
+    return ivalSub(newIval(-math.Pi/2.0, -math.Pi/2.0), ivalAtan(ivalDiv(b, a)))
+  default:
+    return newIval(-math.Pi, math.Pi)
+  }
+}
+
+func ivalAsinh(a ival) ival {
+  return ivalIncreasing(a, math.Asinh, ivalEpsElementary)
+}
+
+func ivalAcosh(a ival) ival {
+  if a.hi < 1.0 {
+    return ivalNaN()
+  }
+  return ivalIncreasing(a.clamp(1.0, math.Inf(1)), math.Acosh, ivalEpsElementary).clamp(0.0, math.Inf(1))
+}
+
+func ivalAtanh(a ival) ival {
+  if a.hi < -1.0 || a.lo > 1.0 {
+    return ivalNaN()
+  }
+  return ivalIncreasing(a.clamp(-1.0, 1.0), math.Atanh, ivalEpsElementary)
+}
+
+/* special functions
+ * -------------------------------------------------------------------------- */
+
+func ivalErf(a ival) ival {
+  return ivalIncreasing(a, math.Erf, ivalEpsSpecial).clamp(-1.0, 1.0)
+}
+
+func ivalErfc(a ival) ival {
+  return ivalDecreasing(a, math.Erfc, ivalEpsSpecial).clamp(0.0, 2.0)
+}
+
+func ivalLogErfc(a ival) ival {
+  return ivalDecreasing(a, special.LogErfc, ivalEpsSpecial)
+}
+
+// Enclosure of 2/sqrt(pi) exp(-a^2), the derivative of erf.
+func ivalErfDerivative(a ival) ival {
+  return ivalScale(ivalExp(ivalNeg(ivalSqr(a))), 2.0/special.M_SQRTPI).widen(ivalEpsElementary)
+}
+
+// Enclosure of the derivative of log erfc, which is a decreasing function.
+func ivalLogErfcDerivative(a ival) ival {
+  f := func(x float64) float64 {
+    return -2.0/special.M_SQRTPI*math.Exp(-x*x - special.LogErfc(x))
+  }
+  return ivalDecreasing(a, f, ivalEpsSpecial)
+}
+
+// Gamma and log gamma function are convex on the positive real line with
+// a minimum at ivalGammaMinimum. For non-positive arguments no bounds are
+// computed.
+func ivalGammaConvex(a ival, f func(float64) float64) ival {
+  if a.lo <= 0.0 {
+    return ivalEntire()
+  }
+  switch {
+  case a.hi <= ivalGammaMinimum:
+    return ivalDecreasing(a, f, ivalEpsSpecial)
+  case a.lo >= ivalGammaMinimum:
+    return ivalIncreasing(a, f, ivalEpsSpecial)
+  default:
+    return ival{f(ivalGammaMinimum), math.Max(f(a.lo), f(a.hi))}.widen(ivalEpsSpecial)
+  }
+}
+
+func ivalGamma(a ival) ival {
+  return ivalGammaConvex(a, math.Gamma)
+}
+
+func ivalLgamma(a ival) ival {
+  return ivalGammaConvex(a, func(x float64) float64 {
+    v, _ := math.Lgamma(x)
+    return v
+  })
+}
+
+func ivalDigamma(a ival) ival {
+  return ivalPolygamma(0, a)
+}
+
+// On the positive real line the polygamma function of order n is
+// increasing for even n and decreasing for odd n.
+func ivalPolygamma(n int, a ival) ival {
+  if a.lo <= 0.0 {
+    return ivalEntire()
+  }
+  f := func(x float64) float64 { return special.Polygamma(n, x) }
+  if n % 2 == 0 {
+    return ivalIncreasing(a, f, ivalEpsSpecial)
+  } else {
+    return ivalDecreasing(a, f, ivalEpsSpecial)
+  }
+}
+
+func ivalLbeta(a, b ival) ival {
+  if a.lo <= 0.0 || b.lo <= 0.0 {
+    return ivalEntire()
+  }
+  return ivalSub(ivalAdd(ivalLgamma(a), ivalLgamma(b)), ivalLgamma(ivalAdd(a, b)))
+}
+
+func ivalBeta(a, b ival) ival {
+  if a.lo <= 0.0 || b.lo <= 0.0 {
+    return ivalEntire()
+  }
+  return ivalExp(ivalLbeta(a, b))
+}
+
+// The Riemann zeta function is decreasing on [0, 1) and (1, inf), and
+// convex on (1, inf).
+func ivalZeta(a ival) ival {
+  if (a.lo > 1.0) || (a.lo >= 0.0 && a.hi < 1.0) {
+    return ivalDecreasing(a, special.Zeta, ivalEpsSpecial)
+  }
+  return ivalEntire()
+}
+
+func ivalZetaDerivative(a ival) ival {
+  f := func(x float64) float64 { return special.ZetaDerivative(1, x) }
+  switch {
+  case a.lo > 1.0:
+    return ivalIncreasing(a, f, ivalEpsSpecial).clamp(math.Inf(-1), 0.0)
+  case a.lo >= 0.0 && a.hi < 1.0:
+    return ival{math.Inf(-1), 0.0}
+  default:
+    return ivalEntire()
+  }
+}
+
+func ivalMlgamma(a ival, k int) ival {
+  r := ivalPoint(float64(k*(k-1))/4.0*math.Log(math.Pi)).widen(ivalEpsElementary)
+  for j := 1; j <= k; j++ {
+    r = ivalAdd(r, ivalLgamma(ivalAdd(a, ivalPoint(float64(1-j)/2.0))))
+  }
+  return r
+}
+
+func ivalMlgammaDerivative(a ival, k int) ival {
+  r := ivalPoint(0.0)
+  for j := 1; j <= k; j++ {
+    r = ivalAdd(r, ivalDigamma(ivalAdd(a, ivalPoint(float64(1-j)/2.0))))
+  }
+  return r
+}
+
+// Regularized lower incomplete gamma function, which is decreasing in the
+// shape a and increasing in x.
+func ivalGammaP(a, x ival) ival {
+  if x.hi < 0.0 || a.hi <= 0.0 {
+    return ivalNaN()
+  }
+  x = x.clamp(0.0, math.Inf(1))
+  r := newIval(special.GammaP(a.hi, x.lo), 1.0)
+  if a.lo > 0.0 {
+    r.hi = special.GammaP(a.lo, x.hi)
+  }
+  return r.widen(ivalEpsSpecial).clamp(0.0, 1.0)
+}
+
+// Regularized upper incomplete gamma function, which is increasing in the
+// shape a and decreasing in x.
+func ivalGammaQ(a, x ival) ival {
+  if x.hi < 0.0 || a.hi <= 0.0 {
+    return ivalNaN()
+  }
+  x = x.clamp(0.0, math.Inf(1))
+  r := newIval(0.0, special.GammaQ(a.hi, x.lo))
+  if a.lo > 0.0 {
+    r.lo = special.GammaQ(a.lo, x.hi)
+  }
+  return r.widen(ivalEpsSpecial).clamp(0.0, 1.0)
+}
+
+// Derivative x^(a-1) exp(-x) / Gamma(a) of the regularized lower incomplete
+// gamma function, which has its maximum at x = a-1 if a > 1 and is
+// decreasing otherwise. For proper intervals of a only the sign is known.
+func ivalGammaPDerivative(s, x ival) ival {
+  if x.hi < 0.0 || s.hi <= 0.0 {
+    return ivalNaN()
+  }
+  if s.lo != s.hi {
+    return ival{0.0, math.Inf(1)}
+  }
+  a := s.lo
+  x = x.clamp(0.0, math.Inf(1))
+  f := func(x float64) float64 { return special.GammaPfirstDerivative(a, x) }
+  r := ivalBounds(f(x.lo), f(x.hi)).widen(ivalEpsSpecial)
+  if a > 1.0 && x.contains(a-1.0) {
+    r.hi = ivalUp(f(a-1.0)*(1.0 + ivalEpsSpecial))
+  }
+  return r.clamp(0.0, math.Inf(1))
+}
+
+// Derivative of the regularized lower incomplete gamma function with respect
+// to the shape a, which is negative. Bounds are only given at single points.
+func ivalGammaPShapeDerivative(a, x ival) ival {
+  if x.hi < 0.0 || a.hi <= 0.0 {
+    return ivalNaN()
+  }
+  if a.lo != a.hi || x.lo != x.hi {
+    return ival{math.Inf(-1), 0.0}
+  }
+  d, _ := special.GammaPshapeDerivatives(a.lo, x.lo)
+  return ivalPoint(d).widen(ivalEpsSpecial).clamp(math.Inf(-1), 0.0)
+}
@@ -0,0 +1,87 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* enclosures of derivatives of monadic functions, f1 returns an enclosure
+ * of the derivative over the interval a
+ * -------------------------------------------------------------------------- */
+
+func (c *Interval) monadicLazy(a Scalar, v0 ival, f1 func() ival) Scalar {
+  c.AllocForOne(a)
+  if c.Order >= 1 {
+    v1 := f1()
+    for j := 0; j < c.N; j++ {
+      c.setDerivative(j, ivalMul(intervalDerivative(a, j), v1))
+    }
+  }
+  // compute new value
+  c.setValue(v0)
+  return c
+}
+
+/* enclosures of derivatives of dyadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *Interval) dyadicLazy(a, b Scalar, v0 ival, f1 func() (ival, ival)) Scalar {
+  c.AllocForTwo(a, b)
+  if c.Order >= 1 {
+    v10, v01 := f1()
+    for j := 0; j < c.N; j++ {
+      c.setDerivative(j, ivalAdd(
+        ivalMul(intervalDerivative(a, j), v10),
+        ivalMul(intervalDerivative(b, j), v01)))
+    }
+  }
+  // compute new value
+  c.setValue(v0)
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *Interval) StopGradient(a Scalar) Scalar {
+  f1 := func() ival { return ivalPoint(0.0) }
+  return c.monadicLazy(a, intervalValue(a), f1)
+}
+
+/* user-defined functions are given only at single points, hence no
+ * enclosure can be computed for proper intervals and the entire real line
+ * is returned
+ * -------------------------------------------------------------------------- */
+
+func (c *Interval) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  if x := intervalValue(a); x.lo != x.hi {
+    f1 := func() ival { return ivalEntire() }
+    return c.monadicLazy(a, ivalEntire(), f1)
+  }
+  f1 := func() ival { return newIval(v1, v1) }
+  return c.monadicLazy(a, newIval(v0, v0), f1)
+}
+
+func (c *Interval) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  if x, y := intervalValue(a), intervalValue(b); x.lo != x.hi || y.lo != y.hi {
+    f1 := func() (ival, ival) { return ivalEntire(), ivalEntire() }
+    return c.dyadicLazy(a, b, ivalEntire(), f1)
+  }
+  f1 := func() (ival, ival) { return newIval(v10, v10), newIval(v01, v01) }
+  return c.dyadicLazy(a, b, newIval(v0, v0), f1)
+}
@@ -0,0 +1,433 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Interval) Equals(b Scalar) bool {
+  epsilon := 1e-12
+  x := intervalValue(b)
+  return math.Abs(a.Lower - x.lo) < epsilon && math.Abs(a.Upper - x.hi) < epsilon
+}
+
+// Returns true if all elements of a are greater than all elements of b.
+func (a *Interval) Greater(b Scalar) bool {
+  return a.Lower > intervalValue(b).hi
+}
+
+// Returns true if all elements of a are smaller than all elements of b.
+func (a *Interval) Smaller(b Scalar) bool {
+  return a.Upper < intervalValue(b).lo
+}
+
+// Enclosure of the minimum of a and b. If the intervals overlap, the
+// derivatives of both arguments are enclosed.
+func (a *Interval) Min(b Scalar) Scalar {
+  if a.Smaller(b) {
+    return a
+  }
+  if b.Smaller(a) {
+    return b
+  }
+  x := intervalValue(a)
+  y := intervalValue(b)
+  r := NullInterval()
+  r.intervalHull(a, b)
+  r.setValue(ival{math.Min(x.lo, y.lo), math.Min(x.hi, y.hi)})
+  return r
+}
+
+// Enclosure of the maximum of a and b.
+func (a *Interval) Max(b Scalar) Scalar {
+  if a.Greater(b) {
+    return a
+  }
+  if b.Greater(a) {
+    return b
+  }
+  x := intervalValue(a)
+  y := intervalValue(b)
+  r := NullInterval()
+  r.intervalHull(a, b)
+  r.setValue(ival{math.Max(x.lo, y.lo), math.Max(x.hi, y.hi)})
+  return r
+}
+
+// Set the derivatives of c to the hull of the derivatives of a and b.
+func (c *Interval) intervalHull(a, b Scalar) {
+  c.AllocForTwo(a, b)
+  for j := 0; j < c.N; j++ {
+    c.setDerivative(j, ivalHull(intervalDerivative(a, j), intervalDerivative(b, j)))
+  }
+}
+
+// The derivative of |x| at zero is enclosed by [-1, 1].
+func (c *Interval) Abs(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival {
+    switch {
+    case x.lo > 0.0:
+      return ivalPoint( 1.0)
+    case x.hi < 0.0:
+      return ivalPoint(-1.0)
+    default:
+      return ival{-1.0, 1.0}
+    }
+  }
+  return c.monadicLazy(a, ivalAbs(x), f1)
+}
+
+// Returns zero if the sign is not determined.
+func (a *Interval) Sign() int {
+  if a.Lower > 0.0 {
+    return  1
+  }
+  if a.Upper < 0.0 {
+    return -1
+  }
+  return 0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Interval) Neg(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalPoint(-1.0) }
+  return c.monadicLazy(a, ivalNeg(x), f1)
+}
+
+func (c *Interval) Add(a, b Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(b)
+  f1 := func() (ival, ival) { return ivalPoint(1.0), ivalPoint(1.0) }
+  return c.dyadicLazy(a, b, ivalAdd(x, y), f1)
+}
+
+func (c *Interval) Sub(a, b Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(b)
+  f1 := func() (ival, ival) { return ivalPoint(1.0), ivalPoint(-1.0) }
+  return c.dyadicLazy(a, b, ivalSub(x, y), f1)
+}
+
+func (c *Interval) Mul(a, b Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(b)
+  f1 := func() (ival, ival) { return y, x }
+  return c.dyadicLazy(a, b, ivalMul(x, y), f1)
+}
+
+func (c *Interval) Div(a, b Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(b)
+  f1 := func() (ival, ival) {
+    return ivalDiv(ivalPoint(1.0), y), ivalNeg(ivalDiv(x, ivalSqr(y)))
+  }
+  return c.dyadicLazy(a, b, ivalDiv(x, y), f1)
+}
+
+func (c *Interval) Pow(a, k Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(k)
+  if k.GetOrder() >= 1 || y.lo != y.hi {
+    // x^y = exp(y log x)
+    v0 := ivalExp(ivalMul(y, ivalLog(x)))
+    f1 := func() (ival, ival) {
+      f10 := ivalMul(y, ivalExp(ivalMul(ivalSub(y, ivalPoint(1.0)), ivalLog(x))))
+      f01 := ivalMul(v0, ivalLog(x))
+      return f10, f01
+    }
+    return c.dyadicLazy(a, k, v0, f1)
+  } else {
+    p := y.lo
+    f1 := func() ival {
+      return ivalScale(ivalPow(x, p-1.0), p)
+    }
+    return c.monadicLazy(a, ivalPow(x, p), f1)
+  }
+}
+
+func (c *Interval) Sqrt(a Scalar) Scalar {
+  return c.Pow(a, NewBareReal(1.0/2.0))
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Interval) Sin(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalCos(x) }
+  return c.monadicLazy(a, ivalSin(x), f1)
+}
+
+func (c *Interval) Sinh(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalCosh(x) }
+  return c.monadicLazy(a, ivalSinh(x), f1)
+}
+
+func (c *Interval) Cos(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalNeg(ivalSin(x)) }
+  return c.monadicLazy(a, ivalCos(x), f1)
+}
+
+func (c *Interval) Cosh(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalSinh(x) }
+  return c.monadicLazy(a, ivalCosh(x), f1)
+}
+
+func (c *Interval) Tan(a Scalar) Scalar {
+  x  := intervalValue(a)
+  v0 := ivalTan(x)
+  f1 := func() ival { return ivalAdd(ivalPoint(1.0), ivalSqr(v0)) }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *Interval) Tanh(a Scalar) Scalar {
+  x  := intervalValue(a)
+  v0 := ivalTanh(x)
+  f1 := func() ival { return ivalSub(ivalPoint(1.0), ivalSqr(v0)).clamp(0.0, 1.0) }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *Interval) Asin(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalPow(ivalSub(ivalPoint(1.0), ivalSqr(x)), -0.5) }
+  return c.monadicLazy(a, ivalAsin(x), f1)
+}
+
+func (c *Interval) Acos(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalNeg(ivalPow(ivalSub(ivalPoint(1.0), ivalSqr(x)), -0.5)) }
+  return c.monadicLazy(a, ivalAcos(x), f1)
+}
+
+func (c *Interval) Atan(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalDiv(ivalPoint(1.0), ivalAdd(ivalPoint(1.0), ivalSqr(x))) }
+  return c.monadicLazy(a, ivalAtan(x), f1)
+}
+
+func (c *Interval) Atan2(a, b Scalar) Scalar {
+  y := intervalValue(a)
+  x := intervalValue(b)
+  f1 := func() (ival, ival) {
+    r := ivalAdd(ivalSqr(x), ivalSqr(y))
+    return ivalDiv(x, r), ivalNeg(ivalDiv(y, r))
+  }
+  return c.dyadicLazy(a, b, ivalAtan2(y, x), f1)
+}
+
+func (c *Interval) Asinh(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalPow(ivalAdd(ivalSqr(x), ivalPoint(1.0)), -0.5) }
+  return c.monadicLazy(a, ivalAsinh(x), f1)
+}
+
+func (c *Interval) Acosh(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalPow(ivalSub(ivalSqr(x), ivalPoint(1.0)), -0.5) }
+  return c.monadicLazy(a, ivalAcosh(x), f1)
+}
+
+func (c *Interval) Atanh(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalDiv(ivalPoint(1.0), ivalSub(ivalPoint(1.0), ivalSqr(x))) }
+  return c.monadicLazy(a, ivalAtanh(x), f1)
+}
+
+func (c *Interval) Exp(a Scalar) Scalar {
+  x  := intervalValue(a)
+  v0 := ivalExp(x)
+  f1 := func() ival { return v0 }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *Interval) Log(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalDiv(ivalPoint(1.0), x) }
+  return c.monadicLazy(a, ivalLog(x), f1)
+}
+
+func (c *Interval) Log1p(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalDiv(ivalPoint(1.0), ivalAdd(ivalPoint(1.0), x)) }
+  return c.monadicLazy(a, ivalLog1p(x), f1)
+}
+
+func (c *Interval) Expm1(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalExp(x) }
+  return c.monadicLazy(a, ivalExpm1(x), f1)
+}
+
+func (c *Interval) Logistic(a Scalar) Scalar {
+  x  := intervalValue(a)
+  v0 := ivalLogistic(x)
+  f1 := func() ival { return ivalMul(v0, ivalSub(ivalPoint(1.0), v0)).clamp(0.0, 0.25) }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *Interval) Softplus(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalLogistic(x) }
+  return c.monadicLazy(a, ivalSoftplus(x), f1)
+}
+
+func (c *Interval) Erf(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalErfDerivative(x) }
+  return c.monadicLazy(a, ivalErf(x), f1)
+}
+
+func (c *Interval) Erfc(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalNeg(ivalErfDerivative(x)) }
+  return c.monadicLazy(a, ivalErfc(x), f1)
+}
+
+func (c *Interval) LogErfc(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalLogErfcDerivative(x) }
+  return c.monadicLazy(a, ivalLogErfc(x), f1)
+}
+
+func (c *Interval) Gamma(a Scalar) Scalar {
+  x  := intervalValue(a)
+  v0 := ivalGamma(x)
+  f1 := func() ival { return ivalMul(v0, ivalDigamma(x)) }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *Interval) Lgamma(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalDigamma(x) }
+  return c.monadicLazy(a, ivalLgamma(x), f1)
+}
+
+func (c *Interval) Mlgamma(a Scalar, k int) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalMlgammaDerivative(x, k) }
+  return c.monadicLazy(a, ivalMlgamma(x, k), f1)
+}
+
+func (c *Interval) GammaP(a, b Scalar) Scalar {
+  s := intervalValue(a)
+  x := intervalValue(b)
+  f1 := func() (ival, ival) {
+    return ivalGammaPShapeDerivative(s, x), ivalGammaPDerivative(s, x)
+  }
+  return c.dyadicLazy(a, b, ivalGammaP(s, x), f1)
+}
+
+func (c *Interval) GammaQ(a, b Scalar) Scalar {
+  s := intervalValue(a)
+  x := intervalValue(b)
+  f1 := func() (ival, ival) {
+    return ivalNeg(ivalGammaPShapeDerivative(s, x)), ivalNeg(ivalGammaPDerivative(s, x))
+  }
+  return c.dyadicLazy(a, b, ivalGammaQ(s, x), f1)
+}
+
+func (c *Interval) Digamma(a Scalar) Scalar {
+  return c.Polygamma(0, a)
+}
+
+func (c *Interval) Trigamma(a Scalar) Scalar {
+  return c.Polygamma(1, a)
+}
+
+func (c *Interval) Polygamma(n int, a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalPolygamma(n+1, x) }
+  return c.monadicLazy(a, ivalPolygamma(n, x), f1)
+}
+
+func (c *Interval) Beta(a, b Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(b)
+  v0 := ivalBeta(x, y)
+  f1 := func() (ival, ival) {
+    p := ivalDigamma(ivalAdd(x, y))
+    return ivalMul(v0, ivalSub(ivalDigamma(x), p)), ivalMul(v0, ivalSub(ivalDigamma(y), p))
+  }
+  return c.dyadicLazy(a, b, v0, f1)
+}
+
+func (c *Interval) Lbeta(a, b Scalar) Scalar {
+  x := intervalValue(a)
+  y := intervalValue(b)
+  f1 := func() (ival, ival) {
+    p := ivalDigamma(ivalAdd(x, y))
+    return ivalSub(ivalDigamma(x), p), ivalSub(ivalDigamma(y), p)
+  }
+  return c.dyadicLazy(a, b, ivalLbeta(x, y), f1)
+}
+
+func (c *Interval) Zeta(a Scalar) Scalar {
+  x := intervalValue(a)
+  f1 := func() ival { return ivalZetaDerivative(x) }
+  return c.monadicLazy(a, ivalZeta(x), f1)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (r *Interval) VdotV(a, b Vector) Scalar {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r.Reset()
+  t := NullInterval()
+  for i := 0; i < len(a); i++ {
+    t.Mul(a[i], b[i])
+    r.Add(r, t)
+  }
+  return r
+}
+
+func (r *Interval) Vnorm(a Vector) Scalar {
+  r.Reset()
+  c := NewBareReal(2.0)
+  t := NullInterval()
+  for i := 0; i < len(a); i++ {
+    t.Pow(a[i], c)
+    r.Add(r, t)
+  }
+  r.Sqrt(r)
+  return r
+}
+
+func (r *Interval) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullInterval()
+  t := NullInterval()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
@@ -0,0 +1,197 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "math/rand"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestInterval1(t *testing.T) {
+
+  // outward rounding
+  a, b := 0.1, 0.2
+  r := Add(NewInterval(a, a), NewInterval(b, b)).(*Interval)
+
+  if !(r.Lower < a+b && a+b < r.Upper) {
+    t.Error("Interval test failed!")
+  }
+  s := Sin(NewInterval(1.0, 2.0)).(*Interval)
+
+  if s.Upper != 1.0 || s.Lower > math.Sin(1.0) {
+    t.Error("Interval test failed!")
+  }
+  if Div(NewInterval(1.0, 2.0), NewInterval(-1.0, 1.0)).(*Interval).Contains(math.Inf(1)) != true {
+    t.Error("Interval test failed!")
+  }
+}
+
+func TestInterval2(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    y := Mul(Pow(x[0], NewBareReal(2)), Sin(x[1]))
+    y  = Add(y, Div(Exp(Mul(x[0], x[1])), x[2]))
+    y  = Sub(y, Tanh(Sqrt(x[2])))
+    return y
+  }
+  lower := []float64{-0.5, 0.2, 1.0}
+  upper := []float64{ 0.7, 2.1, 1.3}
+
+  x1 := NullVector(IntervalType, 3)
+  for i := 0; i < 3; i++ {
+    x1[i].(*Interval).SetInterval(lower[i], upper[i])
+  }
+  Variables(1, x1...)
+
+  y1 := f(x1).(*Interval)
+
+  rnd := rand.New(rand.NewSource(1))
+  for k := 0; k < 1000; k++ {
+    x2 := NullVector(RealType, 3)
+    for i := 0; i < 3; i++ {
+      x2[i].SetValue(lower[i] + rnd.Float64()*(upper[i] - lower[i]))
+    }
+    Variables(1, x2...)
+    y2 := f(x2)
+    if !y1.Contains(y2.GetValue()) {
+      t.Error("Interval test failed!")
+    }
+    for i := 0; i < 3; i++ {
+      if y1.GetDerivativeLower(i) > y2.GetDerivative(1, i) ||
+         y1.GetDerivativeUpper(i) < y2.GetDerivative(1, i) {
+        t.Error("Interval test failed!")
+      }
+    }
+  }
+}
+
+func TestInterval3(t *testing.T) {
+
+  // bounds of special functions over parameter ranges
+  g := []func(Scalar) Scalar{Lgamma, LogErfc, Gamma, Erf}
+  x := NewInterval(0.3, 4.5)
+  Variables(1, x)
+
+  for _, f := range g {
+    r := f(x).(*Interval)
+    for k := 0; k <= 100; k++ {
+      y := NewReal(0.3 + float64(k)/100.0*4.2)
+      Variables(1, y)
+      s := f(y)
+      if !r.Contains(s.GetValue()) {
+        t.Error("Interval test failed!")
+      }
+      if r.GetDerivativeLower(0) > s.GetDerivative(1, 0) ||
+         r.GetDerivativeUpper(0) < s.GetDerivative(1, 0) {
+        t.Error("Interval test failed!")
+      }
+    }
+  }
+}
+
+func TestInterval4(t *testing.T) {
+
+  // verified root isolation with the interval Newton method
+  f := func(x Scalar) Scalar {
+    return Sub(Mul(x, x), NewBareReal(2.0))
+  }
+  x := NewInterval(1.0, 2.0)
+
+  for k := 0; k < 10; k++ {
+    // N(x) = m - f(m)/f'(x)
+    m := NewInterval(x.GetValue(), x.GetValue())
+    y := x.Clone()
+    y.SetVariable(0, 1, 1)
+    d := f(y).(*Interval)
+    if d.GetDerivativeLower(0) <= 0.0 {
+      t.Fatal("Interval test failed!")
+    }
+    n := Sub(m, Div(f(m), NewInterval(d.GetDerivativeLower(0), d.GetDerivativeUpper(0)))).(*Interval)
+    x.SetInterval(math.Max(x.Lower, n.Lower), math.Min(x.Upper, n.Upper))
+  }
+  if !x.Contains(math.Sqrt(2.0)) || x.Upper - x.Lower > 1e-14 {
+    t.Error("Interval test failed!")
+  }
+}
+
+func TestInterval5(t *testing.T) {
+
+  // enclosures of inverse trigonometric and log-space functions
+  g := []func(Scalar) Scalar{Asin, Acos, Atan, Asinh, Acosh, Atanh, Expm1, Logistic, Softplus}
+  l := []float64{-0.9, -0.9, -3.0, -3.0, 1.1, -0.9, -3.0, -3.0, -3.0}
+  u := []float64{ 0.6,  0.6,  2.0,  2.0, 4.0,  0.6,  2.0,  2.0,  2.0}
+
+  for i, f := range g {
+    x := NewInterval(l[i], u[i])
+    Variables(1, x)
+    r := f(x).(*Interval)
+    for k := 0; k <= 100; k++ {
+      y := NewReal(l[i] + float64(k)/100.0*(u[i] - l[i]))
+      Variables(1, y)
+      s := f(y)
+      if !r.Contains(s.GetValue()) {
+        t.Error("Interval test failed!")
+      }
+      if r.GetDerivativeLower(0) > s.GetDerivative(1, 0) ||
+         r.GetDerivativeUpper(0) < s.GetDerivative(1, 0) {
+        t.Error("Interval test failed!")
+      }
+    }
+  }
+  // atan2 in all four quadrants
+  for _, p := range [][]float64{{0.5, 1.0}, {-0.5, 1.0}, {0.5, -1.0}, {-0.5, -1.0}} {
+    a := NewInterval(p[0]-0.1, p[0]+0.1)
+    b := NewInterval(p[1]-0.1, p[1]+0.1)
+    r := Atan2(a, b).(*Interval)
+    for k := 0; k <= 10; k++ {
+      if !r.Contains(math.Atan2(p[0]-0.1+0.02*float64(k), p[1]+0.1-0.02*float64(k))) {
+        t.Error("Interval test failed!")
+      }
+    }
+  }
+}
+
+func TestInterval6(t *testing.T) {
+
+  // enclosures of polygamma and zeta functions
+  g := []func(Scalar) Scalar{Digamma, Trigamma, Zeta,
+    func(x Scalar) Scalar { return Polygamma(2, x) },
+    func(x Scalar) Scalar { return GammaQ(NewBareReal(2.5), x) },
+    func(x Scalar) Scalar { return Lbeta(x, NewReal(0.5)) } }
+  x := NewInterval(1.2, 4.5)
+  Variables(1, x)
+
+  for _, f := range g {
+    r := f(x).(*Interval)
+    for k := 0; k <= 100; k++ {
+      y := NewReal(1.2 + float64(k)/100.0*3.3)
+      Variables(1, y)
+      s := f(y)
+      if !r.Contains(s.GetValue()) {
+        t.Error("Interval test failed!")
+      }
+      if r.GetDerivativeLower(0) > s.GetDerivative(1, 0) ||
+         r.GetDerivativeUpper(0) < s.GetDerivative(1, 0) {
+        t.Error("Interval test failed!")
+      }
+    }
+  }
+}
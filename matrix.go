@@ -24,6 +24,11 @@ type Matrix interface {
   // matrix access with improved performance
   At              (i, j int)           Scalar
   ReferenceAt     (i, j int)           Scalar
+  // access without inserting structural zeros, the result must not be
+  // modified
+  ConstAt         (i, j int)           Scalar
+  // call f for all elements except structural zeros
+  StoredElements  (f func(i, j int, a Scalar))
   Set             (v Scalar, i, j int)
   SetReference    (v Scalar, i, j int)
   // set to identity matrix
@@ -58,4 +63,105 @@ type Matrix interface {
   MdotM(a, b Matrix) Matrix
   Outer(a, b Vector) Matrix
   Jacobian(f func(Vector) Vector, x_ Vector) Matrix
+  Hessian(f func(Vector) Scalar, x_ Vector) Matrix
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Storage format of a matrix, which determines the type of matrices that
+// hold results of operations.
+type matrixStorage struct {
+  // elements are float64 values
+  float64   bool
+  // elements that are not stored are structural zeros
+  sparse    bool
+  // band matrix with the given bandwidth
+  band      bool
+  lower     int
+  upper     int
+  symmetric bool
+}
+
+// Matrices of this package specify their storage format, whereas matrices
+// of other packages are treated as dense matrices.
+type matrixStorageFormat interface {
+  storageFormat() matrixStorage
+}
+
+func storageFormatOf(a Matrix) matrixStorage {
+  if a, ok := a.(matrixStorageFormat); ok {
+    return a.storageFormat()
+  }
+  return matrixStorage{}
+}
+
+// Operations for which nullMatrix allocates results.
+const (
+  // elements at all positions, e.g. a + s for a scalar s
+  resultDense = iota
+  // elements stored in a or b, e.g. a + b
+  resultUnion
+  // elements stored in a and b, e.g. a * b
+  resultIntersection
+  // elements stored in a, e.g. a / b
+  resultLike
+  // matrix product of a and b
+  resultProduct
+)
+
+// Allocate a matrix for the result of operation op applied to a and b,
+// where b is nil for operations on a single matrix. A band matrix is
+// allocated if the result is a band matrix, a float64 matrix if all
+// operands are float64 matrices, and a sparse matrix if the result has
+// structural zeros.
+func nullMatrix(op int, t ScalarType, rows, cols int, a, b Matrix) Matrix {
+  s := storageFormatOf(a)
+  switch op {
+  case resultDense:
+    s = matrixStorage{float64: s.float64}
+  case resultUnion:
+    s = s.union(storageFormatOf(b))
+  case resultIntersection:
+    s = s.intersection(storageFormatOf(b))
+  case resultProduct:
+    s = s.product(storageFormatOf(b))
+  }
+  switch {
+  case s.band:
+    return nullBandMatrixFor(t, rows, s.lower, s.upper, s.symmetric)
+  case s.float64:
+    return NullDenseFloat64Matrix(rows, cols)
+  case s.sparse:
+    return NullSparseMatrix(t, rows, cols)
+  default:
+    return NullDenseMatrix(t, rows, cols)
+  }
+}
+
+func (a matrixStorage) union(b matrixStorage) matrixStorage {
+  r := matrixStorage{float64: a.float64 && b.float64, sparse: a.sparse && b.sparse}
+  if a.band && b.band {
+    r.band, r.lower, r.upper, r.symmetric = true, iMax(a.lower, b.lower), iMax(a.upper, b.upper), a.symmetric && b.symmetric
+  }
+  return r
+}
+
+func (a matrixStorage) intersection(b matrixStorage) matrixStorage {
+  switch {
+  case a.band && b.band:
+    return matrixStorage{band: true, lower: iMin(a.lower, b.lower), upper: iMin(a.upper, b.upper), symmetric: a.symmetric && b.symmetric}
+  case a.band:
+    return a
+  case b.band:
+    return b
+  }
+  return matrixStorage{float64: a.float64 && b.float64, sparse: a.sparse || b.sparse}
+}
+
+func (a matrixStorage) product(b matrixStorage) matrixStorage {
+  r := matrixStorage{float64: a.float64 && b.float64, sparse: a.sparse && b.sparse}
+  if a.band && b.band {
+    r.band, r.lower, r.upper = true, a.lower+b.lower, a.upper+b.upper
+  }
+  return r
 }
@@ -0,0 +1,512 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "bytes"
+import "bufio"
+import "errors"
+import "fmt"
+import "os"
+
+/* matrix type declaration
+ * -------------------------------------------------------------------------- */
+
+// Square band matrix with Lower sub-diagonals and Upper super-diagonals.
+// Elements within the band are stored row by row, i.e. row i stores the
+// columns max(0, i-Lower) to min(n-1, i+Upper) starting at Offset[i].
+// Triangular and diagonal matrices are band matrices with Upper = 0 and/or
+// Lower = 0, hence they use packed storage. A symmetric matrix stores only
+// its lower band (Upper = 0) and mirrors elements above the diagonal.
+//
+// Elements outside the band are structural zeros. Writing to a reference
+// of such an element has no effect, whereas Set panics unless the new
+// value is zero.
+type BandMatrix struct {
+  Values      Vector
+  Offset      []int
+  Rows        int
+  Lower       int
+  Upper       int
+  Symmetric   bool
+  Transposed  bool
+  elementType ScalarType
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+func newBandMatrix(t ScalarType, n, lower, upper int, symmetric bool, values []float64) *BandMatrix {
+  if lower < 0 || upper < 0 {
+    panic("invalid bandwidth")
+  }
+  lower = iMin(lower, iMax(n-1, 0))
+  upper = iMin(upper, iMax(n-1, 0))
+  m := BandMatrix{}
+  m.Offset      = make([]int, n+1)
+  m.Rows        = n
+  m.Lower       = lower
+  m.Upper       = upper
+  m.Symmetric   = symmetric
+  m.elementType = t
+  for i := 0; i < n; i++ {
+    m.Offset[i+1] = m.Offset[i] + iMin(n-1, i+upper) - iMax(0, i-lower) + 1
+  }
+  if values == nil {
+    m.Values = NullVector(t, m.Offset[n])
+  } else {
+    if len(values) != m.Offset[n] {
+      panic("matrix dimension does not fit input values!")
+    }
+    m.Values = NilVector(len(values))
+    f := ScalarConstructor(t)
+    for k := 0; k < len(values); k++ {
+      m.Values[k] = f(values[k])
+    }
+  }
+  return &m
+}
+
+// Create an n x n band matrix with the given number of sub- and
+// super-diagonals. Values contain the elements within the band row by row.
+func NewBandMatrix(t ScalarType, n, lower, upper int, values []float64) *BandMatrix {
+  return newBandMatrix(t, n, lower, upper, false, values)
+}
+
+func NullBandMatrix(t ScalarType, n, lower, upper int) *BandMatrix {
+  return newBandMatrix(t, n, lower, upper, false, nil)
+}
+
+// Create an n x n symmetric band matrix with k sub- and super-diagonals.
+// Values contain the lower band row by row.
+func NewSymmetricBandMatrix(t ScalarType, n, k int, values []float64) *BandMatrix {
+  return newBandMatrix(t, n, k, 0, true, values)
+}
+
+func NullSymmetricBandMatrix(t ScalarType, n, k int) *BandMatrix {
+  return newBandMatrix(t, n, k, 0, true, nil)
+}
+
+// Create an n x n symmetric matrix. Values contain the lower triangle row by
+// row.
+func NewSymmetricMatrix(t ScalarType, n int, values []float64) *BandMatrix {
+  return newBandMatrix(t, n, n-1, 0, true, values)
+}
+
+func NullSymmetricMatrix(t ScalarType, n int) *BandMatrix {
+  return newBandMatrix(t, n, n-1, 0, true, nil)
+}
+
+// Create an n x n lower triangular matrix. Values contain the lower triangle
+// row by row.
+func NewLowerTriangularMatrix(t ScalarType, n int, values []float64) *BandMatrix {
+  return newBandMatrix(t, n, n-1, 0, false, values)
+}
+
+func NullLowerTriangularMatrix(t ScalarType, n int) *BandMatrix {
+  return newBandMatrix(t, n, n-1, 0, false, nil)
+}
+
+// Create an n x n upper triangular matrix. Values contain the upper triangle
+// row by row.
+func NewUpperTriangularMatrix(t ScalarType, n int, values []float64) *BandMatrix {
+  return newBandMatrix(t, n, 0, n-1, false, values)
+}
+
+func NullUpperTriangularMatrix(t ScalarType, n int) *BandMatrix {
+  return newBandMatrix(t, n, 0, n-1, false, nil)
+}
+
+// Create a tridiagonal matrix. Values contain the band row by row, i.e.
+// d0, u0, l0, d1, u1, l1, d2, u2, ...
+func NewTridiagonalMatrix(t ScalarType, n int, values []float64) *BandMatrix {
+  return newBandMatrix(t, n, 1, 1, false, values)
+}
+
+func NullTridiagonalMatrix(t ScalarType, n int) *BandMatrix {
+  return newBandMatrix(t, n, 1, 1, false, nil)
+}
+
+// Create a diagonal matrix with the given diagonal.
+func NewDiagonalMatrix(t ScalarType, values []float64) *BandMatrix {
+  return newBandMatrix(t, len(values), 0, 0, false, values)
+}
+
+func NullDiagonalMatrix(t ScalarType, n int) *BandMatrix {
+  return newBandMatrix(t, n, 0, 0, false, nil)
+}
+
+/* copy and cloning
+ * -------------------------------------------------------------------------- */
+
+// Clone matrix including data.
+func (matrix *BandMatrix) Clone() Matrix {
+  r := *matrix
+  r.Values = matrix.Values.Clone()
+  return &r
+}
+
+// Clone matrix without duplicating data.
+func (matrix *BandMatrix) CloneShallow() Matrix {
+  r := *matrix
+  return &r
+}
+
+// Copy all elements of b within the band of a.
+func (a *BandMatrix) Copy(b Matrix) {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != n2 || m1 != m2 {
+    panic("Copy(): Matrix dimension does not match!")
+  }
+  a.storage(func(k, i, j int) {
+    a.Values[k].Set(b.ConstAt(i, j))
+  })
+}
+
+/* field access
+ * -------------------------------------------------------------------------- */
+
+// Returns the number of sub- and super-diagonals.
+func (matrix *BandMatrix) Bandwidth() (int, int) {
+  switch {
+  case matrix.Symmetric:
+    return matrix.Lower, matrix.Lower
+  case matrix.Transposed:
+    return matrix.Upper, matrix.Lower
+  default:
+    return matrix.Lower, matrix.Upper
+  }
+}
+
+// True if all elements above the diagonal are zero.
+func (matrix *BandMatrix) IsLowerTriangular() bool {
+  _, upper := matrix.Bandwidth()
+  return upper == 0
+}
+
+// True if all elements below the diagonal are zero.
+func (matrix *BandMatrix) IsUpperTriangular() bool {
+  lower, _ := matrix.Bandwidth()
+  return lower == 0
+}
+
+// True if all elements outside the diagonal are zero.
+func (matrix *BandMatrix) IsDiagonal() bool {
+  return matrix.IsLowerTriangular() && matrix.IsUpperTriangular()
+}
+
+// Returns the first and last column of row i within the band.
+func (matrix *BandMatrix) rowRange(i int) (int, int) {
+  lower, upper := matrix.Bandwidth()
+  return iMax(0, i-lower), iMin(matrix.Rows-1, i+upper)
+}
+
+// Position of element (i, j) in Values or -1 if the element is outside of
+// the band.
+func (matrix *BandMatrix) index(i, j int) int {
+  if matrix.Symmetric {
+    if j > i {
+      i, j = j, i
+    }
+  } else if matrix.Transposed {
+    i, j = j, i
+  }
+  if j < i-matrix.Lower || j > i+matrix.Upper {
+    return -1
+  }
+  return matrix.Offset[i] + j - iMax(0, i-matrix.Lower)
+}
+
+// Call f for all stored elements, where k is the position in Values and
+// (i, j) the position in the matrix.
+func (matrix *BandMatrix) storage(f func(k, i, j int)) {
+  for i := 0; i < matrix.Rows; i++ {
+    j0 := iMax(0, i-matrix.Lower)
+    for k := matrix.Offset[i]; k < matrix.Offset[i+1]; k++ {
+      if matrix.Transposed && !matrix.Symmetric {
+        f(k, j0+k-matrix.Offset[i], i)
+      } else {
+        f(k, i, j0+k-matrix.Offset[i])
+      }
+    }
+  }
+}
+
+func (matrix *BandMatrix) Dims() (int, int) {
+  return matrix.Rows, matrix.Rows
+}
+
+// Returns the elements within the band in storage order.
+func (matrix *BandMatrix) GetValues() Vector {
+  return matrix.Values
+}
+
+func (matrix *BandMatrix) SetValues(v Vector) {
+  if len(v) != len(matrix.Values) {
+    panic("SetValues(): vector dimension does not match number of stored elements!")
+  }
+  matrix.Values = v
+}
+
+// Returns a copy of the ith row.
+func (matrix *BandMatrix) Row(i int) Vector {
+  v := NullVector(matrix.elementType, matrix.Rows)
+  for j := 0; j < matrix.Rows; j++ {
+    if k := matrix.index(i, j); k >= 0 {
+      v[j].Set(matrix.Values[k])
+    }
+  }
+  return v
+}
+
+// Returns a copy of the jth column.
+func (matrix *BandMatrix) Col(j int) Vector {
+  v := NullVector(matrix.elementType, matrix.Rows)
+  for i := 0; i < matrix.Rows; i++ {
+    if k := matrix.index(i, j); k >= 0 {
+      v[i].Set(matrix.Values[k])
+    }
+  }
+  return v
+}
+
+// Returns a copy of the diagonal.
+func (matrix *BandMatrix) Diag() Vector {
+  v := NilVector(matrix.Rows)
+  for i := 0; i < matrix.Rows; i++ {
+    v[i] = matrix.Values[matrix.index(i, i)].Clone()
+  }
+  return v
+}
+
+// Returns a copy of the submatrix as dense matrix.
+func (matrix *BandMatrix) Submatrix(rfrom, rto, cfrom, cto int) Matrix {
+  n := rto-rfrom+1
+  m := cto-cfrom+1
+  r := NullDenseMatrix(matrix.elementType, n, m)
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      if k := matrix.index(rfrom+i, cfrom+j); k >= 0 {
+        r.Set(matrix.Values[k], i, j)
+      }
+    }
+  }
+  return r
+}
+
+func (matrix *BandMatrix) Reshape(rows, cols int) error {
+  return errors.New("Reshape(): not supported for band matrices")
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (matrix *BandMatrix) At(i, j int) Scalar {
+  if k := matrix.index(i, j); k >= 0 {
+    return matrix.Values[k].Clone()
+  }
+  return NullScalar(matrix.elementType)
+}
+
+// Returns a reference to element (i, j). Elements outside the band cannot
+// be referenced, use ConstAt instead.
+func (matrix *BandMatrix) ReferenceAt(i, j int) Scalar {
+  if k := matrix.index(i, j); k >= 0 {
+    return matrix.Values[k]
+  }
+  panic("ReferenceAt(): element is outside of band!")
+}
+
+// Returns element (i, j) or a new zero for elements outside the band.
+func (matrix *BandMatrix) ConstAt(i, j int) Scalar {
+  if k := matrix.index(i, j); k >= 0 {
+    return matrix.Values[k]
+  }
+  return NullScalar(matrix.elementType)
+}
+
+// Elements of symmetric matrices are passed to f at both positions.
+func (matrix *BandMatrix) StoredElements(f func(i, j int, a Scalar)) {
+  matrix.storage(func(k, i, j int) {
+    f(i, j, matrix.Values[k])
+    if matrix.Symmetric && i != j {
+      f(j, i, matrix.Values[k])
+    }
+  })
+}
+
+func (matrix *BandMatrix) storageFormat() matrixStorage {
+  lower, upper := matrix.Bandwidth()
+  return matrixStorage{band: true, lower: lower, upper: upper, symmetric: matrix.Symmetric}
+}
+
+func (matrix *BandMatrix) Set(s Scalar, i, j int) {
+  if k := matrix.index(i, j); k >= 0 {
+    matrix.Values[k].Copy(s)
+  } else if s.GetValue() != 0.0 {
+    panic("Set(): element is outside of band!")
+  }
+}
+
+func (matrix *BandMatrix) SetReference(s Scalar, i, j int) {
+  if k := matrix.index(i, j); k >= 0 {
+    matrix.Values[k] = s
+  } else {
+    panic("SetReference(): element is outside of band!")
+  }
+}
+
+func (matrix *BandMatrix) Reset() {
+  for i := 0; i < len(matrix.Values); i++ {
+    matrix.Values[i].Reset()
+  }
+}
+
+func (matrix *BandMatrix) ResetDerivatives() {
+  for i := 0; i < len(matrix.Values); i++ {
+    matrix.Values[i].ResetDerivatives()
+  }
+}
+
+func (matrix *BandMatrix) SetIdentity() {
+  matrix.storage(func(k, i, j int) {
+    if i == j {
+      matrix.Values[k].SetValue(1.0)
+    } else {
+      matrix.Values[k].Reset()
+    }
+  })
+}
+
+/* implement ScalarContainer
+ * -------------------------------------------------------------------------- */
+
+// Apply f to all elements within the band.
+func (matrix *BandMatrix) Map(f func(Scalar) Scalar) {
+  for k := 0; k < len(matrix.Values); k++ {
+    matrix.Values[k] = f(matrix.Values[k].Clone())
+  }
+}
+
+// Reduce all stored elements.
+func (matrix *BandMatrix) Reduce(f func(Scalar, Scalar) Scalar) Scalar {
+  if len(matrix.Values) == 0 {
+    return NullScalar(matrix.elementType)
+  }
+  r := matrix.Values[0].Clone()
+  for k := 1; k < len(matrix.Values); k++ {
+    r = f(r, matrix.Values[k])
+  }
+  return r
+}
+
+func (matrix *BandMatrix) ElementType() ScalarType {
+  return matrix.elementType
+}
+
+func (matrix *BandMatrix) ConvertElementType(t ScalarType) {
+  matrix.Map(func(x Scalar) Scalar {
+    return NewScalar(t, x.GetValue())
+  })
+  matrix.elementType = t
+}
+
+// Declare all stored elements as variables. Elements of a symmetric matrix
+// are stored only once.
+func (matrix *BandMatrix) Variables(order int) {
+  Variables(order, matrix.Values...)
+}
+
+func (matrix *BandMatrix) VariablesAt(offset, order int) {
+  VariablesAt(offset, order, matrix.Values...)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (m *BandMatrix) String() string {
+  var buffer bytes.Buffer
+
+  buffer.WriteString("[")
+  for i := 0; i < m.Rows; i++ {
+    if i != 0 {
+      buffer.WriteString(",\n ")
+    }
+    buffer.WriteString("[")
+    for j := 0; j < m.Rows; j++ {
+      if j != 0 {
+        buffer.WriteString(", ")
+      }
+      buffer.WriteString(m.At(i,j).String())
+    }
+    buffer.WriteString("]")
+  }
+  buffer.WriteString("]")
+
+  return buffer.String()
+}
+
+func (a *BandMatrix) Table() string {
+  var buffer bytes.Buffer
+
+  n, m := a.Dims()
+
+  for i := 0; i < n; i++ {
+    if i != 0 {
+      buffer.WriteString("\n")
+    }
+    for j := 0; j < m; j++ {
+      if j != 0 {
+        buffer.WriteString(" ")
+      }
+      buffer.WriteString(a.At(i,j).String())
+    }
+  }
+
+  return buffer.String()
+}
+
+func (m *BandMatrix) WriteMatrix(filename string) error {
+  f, err := os.Create(filename)
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  w := bufio.NewWriter(f)
+  defer w.Flush()
+
+  fmt.Fprintf(w, "%s\n", m.Table())
+
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Transpose without copying data.
+func (matrix *BandMatrix) T() Matrix {
+  r := *matrix
+  if !r.Symmetric {
+    r.Transposed = !r.Transposed
+  }
+  return &r
+}
+
+func (matrix *BandMatrix) PermuteRows(p []int) {
+  panic("PermuteRows(): not supported for band matrices")
+}
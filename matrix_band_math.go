@@ -0,0 +1,198 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* -------------------------------------------------------------------------- */
+
+// Returns the first and last column of row i of a that may contain
+// non-zero elements.
+func bandRowRange(a Matrix, i int) (int, int) {
+  if b, ok := a.(*BandMatrix); ok {
+    return b.rowRange(i)
+  }
+  _, m := a.Dims()
+  return 0, m-1
+}
+
+// Returns the first and last row of column j of a that may contain
+// non-zero elements.
+func bandColRange(a Matrix, j int) (int, int) {
+  if b, ok := a.(*BandMatrix); ok {
+    lower, upper := b.Bandwidth()
+    return iMax(0, j-upper), iMin(b.Rows-1, j+lower)
+  }
+  n, _ := a.Dims()
+  return 0, n-1
+}
+
+// Allocate a band matrix for the result of an operation. If symmetric is
+// true, only the lower bandwidth is used.
+func nullBandMatrixFor(t ScalarType, n, lower, upper int, symmetric bool) *BandMatrix {
+  if symmetric {
+    return NullSymmetricBandMatrix(t, n, lower)
+  } else {
+    return NullBandMatrix(t, n, lower, upper)
+  }
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute all elements of r within the band.
+func (r *BandMatrix) mapBand(f func(c Scalar, i, j int)) Matrix {
+  r.storage(func(k, i, j int) {
+    f(r.Values[k], i, j)
+  })
+  return r
+}
+
+// Element-wise addition of two matrices. The result is stored in r.
+// Elements outside the band of r are discarded.
+func (r *BandMatrix) MaddM(a, b Matrix) Matrix {
+  checkBandDims(r, a, b)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Add(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Add scalar b to all elements of a within the band of r. The result is
+// stored in r.
+func (r *BandMatrix) MaddS(a Matrix, b Scalar) Matrix {
+  checkBandDims(r, a, a)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Add(a.ConstAt(i, j), b)
+  })
+}
+
+// Element-wise substraction of two matrices. The result is stored in r.
+// Elements outside the band of r are discarded.
+func (r *BandMatrix) MsubM(a, b Matrix) Matrix {
+  checkBandDims(r, a, b)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Sub(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Substract b from all elements of a within the band of r. The result is
+// stored in r.
+func (r *BandMatrix) MsubS(a Matrix, b Scalar) Matrix {
+  checkBandDims(r, a, a)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Sub(a.ConstAt(i, j), b)
+  })
+}
+
+// Element-wise multiplication of two matrices. The result is stored in r.
+// Elements outside the band of r are discarded.
+func (r *BandMatrix) MmulM(a, b Matrix) Matrix {
+  checkBandDims(r, a, b)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Mul(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Multiply all elements of a with b. The result is stored in r.
+func (r *BandMatrix) MmulS(a Matrix, b Scalar) Matrix {
+  checkBandDims(r, a, a)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Mul(a.ConstAt(i, j), b)
+  })
+}
+
+// Element-wise division of two matrices. The result is stored in r.
+// Elements outside the band of r are discarded.
+func (r *BandMatrix) MdivM(a, b Matrix) Matrix {
+  checkBandDims(r, a, b)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Div(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Divide all elements of a by b. The result is stored in r.
+func (r *BandMatrix) MdivS(a Matrix, b Scalar) Matrix {
+  checkBandDims(r, a, a)
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Div(a.ConstAt(i, j), b)
+  })
+}
+
+func checkBandDims(r *BandMatrix, a, b Matrix) {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != r.Rows || m1 != r.Rows || n2 != r.Rows || m2 != r.Rows {
+    panic("matrix dimensions do not match!")
+  }
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Matrix product of a and b. The result is stored in r. Elements outside
+// the band of r are not computed. If a or b are band matrices, only
+// products of elements within their bands are evaluated.
+func (r *BandMatrix) MdotM(a, b Matrix) Matrix {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != r.Rows || m2 != r.Rows || m1 != n2 {
+    panic("matrix dimensions do not match!")
+  }
+  t1 := NullScalar(r.elementType)
+  t2 := NullVector(r.elementType, len(r.Values))
+  r.storage(func(k, i, j int) {
+    k1, k2 := bandRowRange(a, i)
+    l1, l2 := bandColRange(b, j)
+    for l := iMax(k1, l1); l <= iMin(k2, l2); l++ {
+      t1.Mul(a.ConstAt(i, l), b.ConstAt(l, j))
+      t2[k].Add(t2[k], t1)
+    }
+  })
+  for k := 0; k < len(t2); k++ {
+    r.Values[k].Set(t2[k])
+  }
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Outer product of two vectors. The result is stored in r. Elements outside
+// the band of r are not computed.
+func (r *BandMatrix) Outer(a, b Vector) Matrix {
+  if len(a) != r.Rows || len(b) != r.Rows {
+    panic("matrix/vector dimensions do not match!")
+  }
+  return r.mapBand(func(c Scalar, i, j int) {
+    c.Mul(a[i], b[j])
+  })
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Jacobian of f at x_. The result is stored in r. Elements
+// outside the band of r are discarded.
+func (r *BandMatrix) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
+  r.Copy(NullDenseMatrix(r.elementType, r.Rows, r.Rows).Jacobian(f, x_))
+  return r
+}
+
+// Compute the Hessian of f at x_. The result is stored in r. Elements
+// outside the band of r are discarded.
+func (r *BandMatrix) Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  r.Copy(NullDenseMatrix(r.elementType, r.Rows, r.Rows).Hessian(f, x_))
+  return r
+}
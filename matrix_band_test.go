@@ -0,0 +1,121 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestBandMatrix(t *testing.T) {
+  b := NewTridiagonalMatrix(RealType, 4, []float64{
+    1, 2,
+    3, 4, 5,
+    6, 7, 8,
+    9, 10})
+  d := NewDenseMatrix(RealType, 4, 4, []float64{
+    1, 2, 0,  0,
+    3, 4, 5,  0,
+    0, 6, 7,  8,
+    0, 0, 9, 10})
+  if !Mequal(b, d) || !Mequal(b.T(), d.T()) {
+    t.Error("Band matrix T() failed!")
+  }
+  if !Vequal(b.T().Row(1), d.T().Row(1)) || !Vequal(b.Col(2), d.Col(2)) {
+    t.Error("Band matrix Row() failed!")
+  }
+  if !Mequal(b.Submatrix(1, 3, 0, 1), d.Submatrix(1, 3, 0, 1)) {
+    t.Error("Band matrix Submatrix() failed!")
+  }
+  // symmetric matrix with packed storage
+  s := NewSymmetricMatrix(RealType, 3, []float64{
+    1,
+    2, 3,
+    4, 5, 6})
+  e := NewDenseMatrix(RealType, 3, 3, []float64{
+    1, 2, 4,
+    2, 3, 5,
+    4, 5, 6})
+  if len(s.GetValues()) != 6 || !Mequal(s, e) || !Mequal(s.T(), e) {
+    t.Error("Symmetric matrix storage failed!")
+  }
+  s.Set(NewReal(7), 0, 2)
+  if s.At(2, 0).GetValue() != 7 {
+    t.Error("Symmetric matrix Set() failed!")
+  }
+  if Mnorm(s).GetValue() != Mnorm(NewDenseMatrix(RealType, 3, 3, []float64{1, 2, 7, 2, 3, 5, 7, 5, 6})).GetValue() {
+    t.Error("Symmetric matrix Mnorm() failed!")
+  }
+  // elements outside of the band
+  u := NewUpperTriangularMatrix(RealType, 3, []float64{1, 2, 3, 4, 5, 6})
+  if u.At(2, 0).GetValue() != 0 || u.ConstAt(2, 0).GetValue() != 0 || u.At(1, 2).GetValue() != 5 {
+    t.Error("Band matrix At() failed!")
+  }
+}
+
+func TestBandMatrixReference(t *testing.T) {
+  defer func() {
+    if recover() == nil {
+      t.Error("Band matrix ReferenceAt() failed!")
+    }
+  }()
+  u := NewUpperTriangularMatrix(RealType, 3, []float64{1, 2, 3, 4, 5, 6})
+  u.ReferenceAt(2, 0)
+}
+
+func TestBandMatrixMath(t *testing.T) {
+  a := NewTridiagonalMatrix(RealType, 4, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+  b := NewLowerTriangularMatrix(RealType, 4, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+  c := NewDiagonalMatrix(RealType, []float64{1, 2, 3, 4})
+  s := NewSymmetricBandMatrix(RealType, 4, 1, []float64{1, 2, 3, 4, 5, 6, 7})
+  d := []*DenseMatrix{}
+  for _, m := range []Matrix{a, b, c, s} {
+    r := NullDenseMatrix(RealType, 4, 4)
+    r.Copy(m)
+    d = append(d, r)
+  }
+  if r := MaddM(a, s); !Mequal(r, MaddM(d[0], d[3])) {
+    t.Error("Band matrix MaddM() failed!")
+  }
+  if r := MsubM(s, b.T()); !Mequal(r, MsubM(d[3], d[1].T())) {
+    t.Error("Band matrix MsubM() failed!")
+  }
+  if r := MmulM(b, a); r.(*BandMatrix).Upper != 0 || !Mequal(r, MmulM(d[1], d[0])) {
+    t.Error("Band matrix MmulM() failed!")
+  }
+  if r := MdotM(a, b); !Mequal(r, MdotM(d[0], d[1])) {
+    t.Error("Band matrix MdotM() failed!")
+  }
+  if r := MdotM(c, c); !r.(*BandMatrix).IsDiagonal() || !Mequal(r, MdotM(d[2], d[2])) {
+    t.Error("Band matrix MdotM() failed!")
+  }
+  if r := MdotM(s, d[1]); !Mequal(r, MdotM(d[3], d[1])) {
+    t.Error("Band matrix MdotM() failed!")
+  }
+  v := NewVector(RealType, []float64{1, 2, 3, 4})
+  if !Vequal(MdotV(a.T(), v), MdotV(d[0].T(), v)) || !Vequal(VdotM(v, s), VdotM(v, d[3])) {
+    t.Error("Band matrix MdotV() failed!")
+  }
+  // derivatives with respect to the elements of a symmetric matrix
+  s.Variables(1)
+  w := MdotV(s, v)
+  if w[0].GetDerivative(1, 1) != 2 || w[1].GetDerivative(1, 1) != 1 {
+    t.Error("Band matrix derivatives failed!")
+  }
+}
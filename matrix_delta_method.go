@@ -0,0 +1,100 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* -------------------------------------------------------------------------- */
+
+// Propagate the mean and covariance of a random vector x through f using
+// the delta method. The mean of f(x) is approximated by f(mean) and its
+// covariance by J cov J^T, where J is the Jacobian of f at mean. For
+// order 2 the mean of the ith component is corrected by tr(H_i cov)/2 and
+// the covariance of components i and j by tr(H_i cov H_j cov)/2, where
+// H_i is the Hessian of the ith component. The second-order correction
+// is exact for quadratic functions of Gaussian random vectors.
+func DeltaMethod(f func(Vector) Vector, mean Vector, cov Matrix, order int) (Vector, Matrix) {
+  if order < 1 || order > 2 {
+    panic("DeltaMethod(): invalid order")
+  }
+  if n, m := cov.Dims(); n != len(mean) || m != len(mean) {
+    panic("matrix/vector dimensions do not match")
+  }
+  if order == 1 {
+    // f may return its argument
+    y := f(mean).Clone()
+    d := Jacobian(f, mean)
+    return y, MdotM(MdotM(d, cov), d.T())
+  }
+  y, d, h := deltaMethodDerivatives(f, mean)
+  s := MdotM(MdotM(d, cov), d.T())
+  // a[i] = H_i cov
+  a := make([]Matrix, len(y))
+  for i := 0; i < len(y); i++ {
+    a[i] = MdotM(h[i], cov)
+  }
+  t := NullScalar(y.ElementType())
+  c := NewBareReal(0.5)
+  for i := 0; i < len(y); i++ {
+    t.Mul(Mtrace(a[i]), c)
+    y[i].Add(y[i], t)
+    for j := 0; j <= i; j++ {
+      t.Mul(Mtrace(MdotM(a[i], a[j])), c)
+      s.ReferenceAt(i, j).Add(s.ReferenceAt(i, j), t)
+      if i != j {
+        s.ReferenceAt(j, i).Add(s.ReferenceAt(j, i), t)
+      }
+    }
+  }
+  return y, s
+}
+
+// Evaluate f at x_ once with second order derivatives and return the
+// value, the Jacobian, and the Hessians of all components. As in Jacobian,
+// the value and the Jacobian carry derivatives with respect to outer
+// variables, whereas the Hessians carry no derivatives.
+func deltaMethodDerivatives(f func(Vector) Vector, x_ Vector) (Vector, Matrix, []Matrix) {
+  x := x_.Clone()
+  c := DiffContext{}.nestedWithin(len(x), x_)
+  c.Variables(2, x...)
+  k := c.Offset
+  z := f(x)
+  n := len(z)
+  m := len(x)
+  y := NullVector(x.ElementType(), n)
+  d := NullDenseMatrix(x.ElementType(), n, m)
+  h := make([]Matrix, n)
+  for i := 0; i < n; i++ {
+    if k > 0 {
+      y[i].SetVariable(0, k, 1)
+    }
+    y[i].SetValue(z[i].GetValue())
+    for l := 0; l < k; l++ {
+      y[i].SetDerivative(1, l, z[i].GetDerivative(1, l))
+    }
+    h[i] = NullDenseMatrix(x.ElementType(), m, m)
+    for j := 0; j < m; j++ {
+      copyNestedDerivative(d.ReferenceAt(i, j), z[i], j, k)
+      for l := 0; l < m; l++ {
+        h[i].ReferenceAt(j, l).SetValue(z[i].GetHessian(k+j, k+l))
+      }
+    }
+  }
+  return y, d, h
+}
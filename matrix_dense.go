@@ -117,7 +117,11 @@ func (a *DenseMatrix) Copy(b Matrix) {
   if n1 != n2 || m1 != m2 {
     panic("Copy(): Matrix dimension does not match!")
   }
-  a.Values.Copy(b.GetValues())
+  for i := 0; i < n1; i++ {
+    for j := 0; j < m1; j++ {
+      a.ReferenceAt(i, j).Set(b.ConstAt(i, j))
+    }
+  }
 }
 
 /* constructors for special types of matrices
@@ -214,6 +218,22 @@ func (matrix *DenseMatrix) ReferenceAt(i, j int) Scalar {
   return matrix.Values[matrix.index(i, j)]
 }
 
+func (matrix *DenseMatrix) ConstAt(i, j int) Scalar {
+  return matrix.Values[matrix.index(i, j)]
+}
+
+func (matrix *DenseMatrix) StoredElements(f func(i, j int, a Scalar)) {
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      f(i, j, matrix.Values[matrix.index(i, j)])
+    }
+  }
+}
+
+func (matrix *DenseMatrix) storageFormat() matrixStorage {
+  return matrixStorage{}
+}
+
 func (matrix *DenseMatrix) RealReferenceAt(i, j int) *Real {
   return matrix.Values[matrix.index(i, j)].(*Real)
 }
@@ -304,6 +324,10 @@ func (matrix *DenseMatrix) Variables(order int) {
   Variables(order, matrix.Values...)
 }
 
+func (matrix *DenseMatrix) VariablesAt(offset, order int) {
+  VariablesAt(offset, order, matrix.Values...)
+}
+
 /* type conversion
  * -------------------------------------------------------------------------- */
 
@@ -0,0 +1,401 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "bytes"
+import "bufio"
+import "errors"
+import "fmt"
+import "os"
+
+/* matrix type declaration
+ * -------------------------------------------------------------------------- */
+
+// Dense matrix of float64 values stored in contiguous memory in row-major
+// order. Elements are accessed as BareReal scalars that refer to the
+// storage, hence references returned by ReferenceAt, Row, Col, Diag and
+// GetValues write through. Derivatives of scalars assigned to the matrix
+// are dropped, and references hold values only (see ReferenceAt).
+type DenseFloat64Matrix struct {
+  Values     []float64
+  Rows       int
+  Cols       int
+  Transposed bool
+  tmp        []float64
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+func NewDenseFloat64Matrix(rows, cols int, values []float64) *DenseFloat64Matrix {
+  m := NullDenseFloat64Matrix(rows, cols)
+  if len(values) == 1 {
+    for i := 0; i < rows*cols; i++ {
+      m.Values[i] = values[0]
+    }
+  } else if len(values) == rows*cols {
+    copy(m.Values, values)
+  } else {
+    panic("NewDenseFloat64Matrix(): Matrix dimension does not fit input values!")
+  }
+  return m
+}
+
+func NullDenseFloat64Matrix(rows, cols int) *DenseFloat64Matrix {
+  m := DenseFloat64Matrix{}
+  m.Values = make([]float64, rows*cols)
+  m.Rows   = rows
+  m.Cols   = cols
+  return &m
+}
+
+func IdentityDenseFloat64Matrix(dim int) *DenseFloat64Matrix {
+  m := NullDenseFloat64Matrix(dim, dim)
+  m.SetIdentity()
+  return m
+}
+
+/* copy and cloning
+ * -------------------------------------------------------------------------- */
+
+// Clone matrix including data.
+func (matrix *DenseFloat64Matrix) Clone() Matrix {
+  return &DenseFloat64Matrix{
+    Values    : append([]float64{}, matrix.Values...),
+    Rows      : matrix.Rows,
+    Cols      : matrix.Cols,
+    Transposed: matrix.Transposed}
+}
+
+// Clone matrix without duplicating data.
+func (matrix *DenseFloat64Matrix) CloneShallow() Matrix {
+  return &DenseFloat64Matrix{
+    Values    : matrix.Values,
+    Rows      : matrix.Rows,
+    Cols      : matrix.Cols,
+    Transposed: matrix.Transposed}
+}
+
+func (a *DenseFloat64Matrix) Copy(b Matrix) {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != n2 || m1 != m2 {
+    panic("Copy(): Matrix dimension does not match!")
+  }
+  if b, ok := b.(*DenseFloat64Matrix); ok && a.Transposed == b.Transposed {
+    copy(a.Values, b.Values)
+    return
+  }
+  for i := 0; i < n1; i++ {
+    for j := 0; j < m1; j++ {
+      a.Values[a.index(i, j)] = float64At(b, i, j)
+    }
+  }
+}
+
+/* field access
+ * -------------------------------------------------------------------------- */
+
+// Returns the distance of consecutive rows and columns in Values.
+func (matrix *DenseFloat64Matrix) strides() (int, int) {
+  if matrix.Transposed {
+    return 1, matrix.Rows
+  } else {
+    return matrix.Cols, 1
+  }
+}
+
+func (matrix *DenseFloat64Matrix) index(i, j int) int {
+  if matrix.Transposed {
+    return j*matrix.Rows + i
+  } else {
+    return i*matrix.Cols + j
+  }
+}
+
+// Returns the value of element (i, j) of an arbitrary matrix.
+func float64At(a Matrix, i, j int) float64 {
+  if a, ok := a.(*DenseFloat64Matrix); ok {
+    return a.Values[a.index(i, j)]
+  }
+  return a.ConstAt(i, j).GetValue()
+}
+
+func (matrix *DenseFloat64Matrix) Dims() (int, int) {
+  return matrix.Rows, matrix.Cols
+}
+
+// Returns BareReal scalars that refer to the storage of the matrix.
+func (matrix *DenseFloat64Matrix) GetValues() Vector {
+  return DenseFloat64Vector(matrix.Values).Vector()
+}
+
+// Copy values from v.
+func (matrix *DenseFloat64Matrix) SetValues(v Vector) {
+  DenseFloat64Vector(matrix.Values).Copy(v)
+}
+
+func (matrix *DenseFloat64Matrix) Row(i int) Vector {
+  v := NilVector(matrix.Cols)
+  for j := 0; j < matrix.Cols; j++ {
+    v[j] = matrix.ReferenceAt(i, j)
+  }
+  return v
+}
+
+func (matrix *DenseFloat64Matrix) Col(j int) Vector {
+  v := NilVector(matrix.Rows)
+  for i := 0; i < matrix.Rows; i++ {
+    v[i] = matrix.ReferenceAt(i, j)
+  }
+  return v
+}
+
+func (matrix *DenseFloat64Matrix) Diag() Vector {
+  n, m := matrix.Dims()
+  if n != m {
+    panic("Diag(): not a square matrix!")
+  }
+  v := NilVector(n)
+  for i := 0; i < n; i++ {
+    v[i] = matrix.ReferenceAt(i, i)
+  }
+  return v
+}
+
+func (matrix *DenseFloat64Matrix) Submatrix(rfrom, rto, cfrom, cto int) Matrix {
+  n := rto-rfrom+1
+  m := cto-cfrom+1
+  r := NullDenseFloat64Matrix(n, m)
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      r.Values[i*m+j] = matrix.Values[matrix.index(rfrom+i, cfrom+j)]
+    }
+  }
+  return r
+}
+
+// Reshape the matrix, where elements are taken in row-major order. The
+// values of a transposed matrix are first copied to row-major storage, hence
+// the result no longer shares its storage with the original matrix.
+func (matrix *DenseFloat64Matrix) Reshape(rows, cols int) error {
+  if len(matrix.Values) != rows*cols {
+    return errors.New("Reshape(): invalid parameters")
+  }
+  if matrix.Transposed {
+    v := make([]float64, len(matrix.Values))
+    for i := 0; i < matrix.Rows; i++ {
+      for j := 0; j < matrix.Cols; j++ {
+        v[i*matrix.Cols+j] = matrix.Values[matrix.index(i, j)]
+      }
+    }
+    matrix.Values     = v
+    matrix.Transposed = false
+  }
+  matrix.Rows = rows
+  matrix.Cols = cols
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (matrix *DenseFloat64Matrix) At(i, j int) Scalar {
+  return NewBareReal(matrix.Values[matrix.index(i, j)])
+}
+
+// Returns a BareReal that refers to element (i, j). The reference only
+// holds values, i.e. it may be assigned with Set or SetValue, whereas
+// operations with operands that carry derivatives panic (e.g. Add(a, b)
+// with a Real variable a).
+func (matrix *DenseFloat64Matrix) ReferenceAt(i, j int) Scalar {
+  return (*BareReal)(&matrix.Values[matrix.index(i, j)])
+}
+
+func (matrix *DenseFloat64Matrix) ConstAt(i, j int) Scalar {
+  return (*BareReal)(&matrix.Values[matrix.index(i, j)])
+}
+
+func (matrix *DenseFloat64Matrix) StoredElements(f func(i, j int, a Scalar)) {
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      f(i, j, (*BareReal)(&matrix.Values[matrix.index(i, j)]))
+    }
+  }
+}
+
+func (matrix *DenseFloat64Matrix) storageFormat() matrixStorage {
+  return matrixStorage{float64: true}
+}
+
+func (matrix *DenseFloat64Matrix) BareRealReferenceAt(i, j int) *BareReal {
+  return (*BareReal)(&matrix.Values[matrix.index(i, j)])
+}
+
+func (matrix *DenseFloat64Matrix) Set(s Scalar, i, j int) {
+  matrix.Values[matrix.index(i, j)] = s.GetValue()
+}
+
+// Elements are stored as values, hence the value of s is copied.
+func (matrix *DenseFloat64Matrix) SetReference(s Scalar, i, j int) {
+  matrix.Values[matrix.index(i, j)] = s.GetValue()
+}
+
+func (matrix *DenseFloat64Matrix) Reset() {
+  for i := 0; i < len(matrix.Values); i++ {
+    matrix.Values[i] = 0.0
+  }
+}
+
+func (matrix *DenseFloat64Matrix) ResetDerivatives() {
+}
+
+func (matrix *DenseFloat64Matrix) SetIdentity() {
+  n, m := matrix.Dims()
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      if i == j {
+        matrix.Values[matrix.index(i, j)] = 1.0
+      } else {
+        matrix.Values[matrix.index(i, j)] = 0.0
+      }
+    }
+  }
+}
+
+/* implement ScalarContainer
+ * -------------------------------------------------------------------------- */
+
+func (matrix *DenseFloat64Matrix) Map(f func(Scalar) Scalar) {
+  for i := 0; i < len(matrix.Values); i++ {
+    matrix.Values[i] = f(NewBareReal(matrix.Values[i])).GetValue()
+  }
+}
+
+func (matrix *DenseFloat64Matrix) Reduce(f func(Scalar, Scalar) Scalar) Scalar {
+  n, m := matrix.Dims()
+  r := matrix.At(0, 0)
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      if i != 0 || j != 0 {
+        r = f(r, matrix.ReferenceAt(i, j))
+      }
+    }
+  }
+  return r
+}
+
+func (matrix *DenseFloat64Matrix) ElementType() ScalarType {
+  return BareRealType
+}
+
+func (matrix *DenseFloat64Matrix) ConvertElementType(t ScalarType) {
+  if t != BareRealType {
+    panic("ConvertElementType(): float64 matrices can only hold BareReal scalars")
+  }
+}
+
+// BareReal scalars carry no derivatives, hence this method has no effect.
+func (matrix *DenseFloat64Matrix) Variables(order int) {
+}
+
+func (matrix *DenseFloat64Matrix) VariablesAt(offset, order int) {
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (m *DenseFloat64Matrix) String() string {
+  var buffer bytes.Buffer
+
+  buffer.WriteString("[")
+  for i := 0; i < m.Rows; i++ {
+    if i != 0 {
+      buffer.WriteString(",\n ")
+    }
+    buffer.WriteString("[")
+    for j := 0; j < m.Cols; j++ {
+      if j != 0 {
+        buffer.WriteString(", ")
+      }
+      buffer.WriteString(m.ReferenceAt(i,j).String())
+    }
+    buffer.WriteString("]")
+  }
+  buffer.WriteString("]")
+
+  return buffer.String()
+}
+
+func (a *DenseFloat64Matrix) Table() string {
+  var buffer bytes.Buffer
+
+  n, m := a.Dims()
+
+  for i := 0; i < n; i++ {
+    if i != 0 {
+      buffer.WriteString("\n")
+    }
+    for j := 0; j < m; j++ {
+      if j != 0 {
+        buffer.WriteString(" ")
+      }
+      buffer.WriteString(a.ReferenceAt(i,j).String())
+    }
+  }
+
+  return buffer.String()
+}
+
+func (m *DenseFloat64Matrix) WriteMatrix(filename string) error {
+  f, err := os.Create(filename)
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  w := bufio.NewWriter(f)
+  defer w.Flush()
+
+  fmt.Fprintf(w, "%s\n", m.Table())
+
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (matrix *DenseFloat64Matrix) T() Matrix {
+  return &DenseFloat64Matrix{
+    Values    :  matrix.Values,
+    Rows      :  matrix.Cols,
+    Cols      :  matrix.Rows,
+    Transposed: !matrix.Transposed}
+}
+
+// Row i of the result is row p[i] of the original matrix.
+func (matrix *DenseFloat64Matrix) PermuteRows(p []int) {
+  if len(p) != matrix.Rows {
+    panic("PermuteRows(): permutation vector has invalid length!")
+  }
+  r := matrix.Clone().(*DenseFloat64Matrix)
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      matrix.Values[matrix.index(i, j)] = r.Values[r.index(p[i], j)]
+    }
+  }
+}
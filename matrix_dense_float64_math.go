@@ -0,0 +1,223 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* -------------------------------------------------------------------------- */
+
+// Block size of the matrix product, chosen such that three blocks fit into
+// the L1 cache.
+const denseFloat64BlockSize = 48
+
+/* -------------------------------------------------------------------------- */
+
+func (r *DenseFloat64Matrix) dyadic(a, b Matrix, f func(float64, float64) float64) Matrix {
+  n,  m  := r.Dims()
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != n || m1 != m || n2 != n || m2 != m {
+    panic("matrix dimensions do not match!")
+  }
+  a1, ok1 := a.(*DenseFloat64Matrix)
+  b1, ok2 := b.(*DenseFloat64Matrix)
+  if ok1 && ok2 && a1.Transposed == r.Transposed && b1.Transposed == r.Transposed {
+    for k := 0; k < len(r.Values); k++ {
+      r.Values[k] = f(a1.Values[k], b1.Values[k])
+    }
+    return r
+  }
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      r.Values[r.index(i, j)] = f(float64At(a, i, j), float64At(b, i, j))
+    }
+  }
+  return r
+}
+
+func (r *DenseFloat64Matrix) monadic(a Matrix, f func(float64) float64) Matrix {
+  n,  m  := r.Dims()
+  n1, m1 := a.Dims()
+  if n1 != n || m1 != m {
+    panic("matrix dimensions do not match!")
+  }
+  if a1, ok := a.(*DenseFloat64Matrix); ok && a1.Transposed == r.Transposed {
+    for k := 0; k < len(r.Values); k++ {
+      r.Values[k] = f(a1.Values[k])
+    }
+    return r
+  }
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      r.Values[r.index(i, j)] = f(float64At(a, i, j))
+    }
+  }
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Element-wise addition of two matrices. The result is stored in r.
+func (r *DenseFloat64Matrix) MaddM(a, b Matrix) Matrix {
+  return r.dyadic(a, b, func(x, y float64) float64 { return x + y })
+}
+
+// Add scalar b to all elements of a. The result is stored in r.
+func (r *DenseFloat64Matrix) MaddS(a Matrix, b Scalar) Matrix {
+  y := b.GetValue()
+  return r.monadic(a, func(x float64) float64 { return x + y })
+}
+
+// Element-wise substraction of two matrices. The result is stored in r.
+func (r *DenseFloat64Matrix) MsubM(a, b Matrix) Matrix {
+  return r.dyadic(a, b, func(x, y float64) float64 { return x - y })
+}
+
+// Substract b from all elements of a. The result is stored in r.
+func (r *DenseFloat64Matrix) MsubS(a Matrix, b Scalar) Matrix {
+  y := b.GetValue()
+  return r.monadic(a, func(x float64) float64 { return x - y })
+}
+
+// Element-wise multiplication of two matrices. The result is stored in r.
+func (r *DenseFloat64Matrix) MmulM(a, b Matrix) Matrix {
+  return r.dyadic(a, b, func(x, y float64) float64 { return x * y })
+}
+
+// Multiply all elements of a with b. The result is stored in r.
+func (r *DenseFloat64Matrix) MmulS(a Matrix, b Scalar) Matrix {
+  y := b.GetValue()
+  return r.monadic(a, func(x float64) float64 { return x * y })
+}
+
+// Element-wise division of two matrices. The result is stored in r.
+func (r *DenseFloat64Matrix) MdivM(a, b Matrix) Matrix {
+  return r.dyadic(a, b, func(x, y float64) float64 { return x / y })
+}
+
+// Divide all elements of a by b. The result is stored in r.
+func (r *DenseFloat64Matrix) MdivS(a Matrix, b Scalar) Matrix {
+  y := b.GetValue()
+  return r.monadic(a, func(x float64) float64 { return x / y })
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Matrix product of a and b. The result is stored in r. If a and b are
+// float64 matrices, the product is computed block-wise on the raw storage.
+// Otherwise, the values of a and b are copied to float64 matrices first.
+func (r *DenseFloat64Matrix) MdotM(a, b Matrix) Matrix {
+  n,  m  := r.Dims()
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != n || m2 != m || m1 != n2 {
+    panic("matrix dimensions do not match!")
+  }
+  a1, ok1 := a.(*DenseFloat64Matrix)
+  b1, ok2 := b.(*DenseFloat64Matrix)
+  if !ok1 {
+    a1 = NullDenseFloat64Matrix(n1, m1); a1.Copy(a)
+  }
+  if !ok2 {
+    b1 = NullDenseFloat64Matrix(n2, m2); b1.Copy(b)
+  }
+  // the result is computed in a temporary buffer, since r may
+  // share storage with a or b
+  if len(r.tmp) != n*m {
+    r.tmp = make([]float64, n*m)
+  }
+  c := r.tmp
+  for i := 0; i < len(c); i++ {
+    c[i] = 0.0
+  }
+  denseFloat64MdotM(c, a1, b1, n, m, m1)
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      r.Values[r.index(i, j)] = c[i*m+j]
+    }
+  }
+  return r
+}
+
+// Compute c += a b, where c is an n x m matrix in row-major order.
+func denseFloat64MdotM(c []float64, a, b *DenseFloat64Matrix, n, m, l int) {
+  as1, as2 := a.strides()
+  bs1, bs2 := b.strides()
+  bs := denseFloat64BlockSize
+  for i0 := 0; i0 < n; i0 += bs {
+    i1 := iMin(i0+bs, n)
+    for k0 := 0; k0 < l; k0 += bs {
+      k1 := iMin(k0+bs, l)
+      for j0 := 0; j0 < m; j0 += bs {
+        j1 := iMin(j0+bs, m)
+        for i := i0; i < i1; i++ {
+          ci := c[i*m:(i+1)*m]
+          for k := k0; k < k1; k++ {
+            aik := a.Values[i*as1+k*as2]
+            if bs2 == 1 {
+              bk := b.Values[k*bs1+j0:k*bs1+j1]
+              ck := ci[j0:j1]
+              for j := range ck {
+                ck[j] += aik*bk[j]
+              }
+            } else {
+              for j := j0; j < j1; j++ {
+                ci[j] += aik*b.Values[k*bs1+j*bs2]
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Outer product of two vectors. The result is stored in r.
+func (r *DenseFloat64Matrix) Outer(a, b Vector) Matrix {
+  n, m := r.Dims()
+  if len(a) != n || len(b) != m {
+    panic("matrix/vector dimensions do not match!")
+  }
+  for i := 0; i < n; i++ {
+    x := a[i].GetValue()
+    for j := 0; j < m; j++ {
+      r.Values[r.index(i, j)] = x*b[j].GetValue()
+    }
+  }
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Jacobian of f at x_. The result is stored in r without
+// derivatives.
+func (r *DenseFloat64Matrix) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
+  n, m := r.Dims()
+  r.Copy(NullDenseMatrix(x_.ElementType(), n, m).Jacobian(f, x_))
+  return r
+}
+
+// Compute the Hessian of f at x_. The result is stored in r.
+func (r *DenseFloat64Matrix) Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  n, m := r.Dims()
+  r.Copy(NullDenseMatrix(x_.ElementType(), n, m).Hessian(f, x_))
+  return r
+}
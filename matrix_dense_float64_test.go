@@ -0,0 +1,121 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestDenseFloat64Matrix(t *testing.T) {
+
+  a := NewDenseFloat64Matrix(3, 4, []float64{
+    1,  2,  3,  4,
+    5,  6,  7,  8,
+    9, 10, 11, 12})
+
+  if a.At(1, 2).GetValue() != 7 || a.T().At(2, 1).GetValue() != 7 {
+    t.Error("Float64 matrix At() failed!")
+  }
+  if r := a.Submatrix(1, 2, 0, 2); r.At(1, 2).GetValue() != 11 {
+    t.Error("Float64 matrix Submatrix() failed!")
+  }
+  // references write through
+  a.ReferenceAt(1, 2).SetValue(10)
+  a.T().Row(3)[2].Add(NewBareReal(1), NewBareReal(2))
+  if a.Values[6] != 10 || a.Values[11] != 3 {
+    t.Error("Float64 matrix ReferenceAt() failed!")
+  }
+  a.PermuteRows([]int{2, 0, 1})
+  if a.At(0, 0).GetValue() != 9 || a.At(2, 2).GetValue() != 10 {
+    t.Error("Float64 matrix PermuteRows() failed!")
+  }
+}
+
+func TestDenseFloat64MatrixMath(t *testing.T) {
+
+  a := NewDenseFloat64Matrix(2, 3, []float64{1, 2, 3, 4, 5, 6})
+  b := NewDenseFloat64Matrix(2, 3, []float64{1, 0, 1, 0, 1, 0})
+  d := NewDenseMatrix(RealType, 2, 3, []float64{1, 0, 1, 0, 1, 0})
+
+  if r, ok := MdotM(a, b.T()).(*DenseFloat64Matrix); !ok || !Mequal(r, NewDenseMatrix(RealType, 2, 2, []float64{4, 2, 10, 5})) {
+    t.Error("Float64 matrix MdotM() failed!")
+  }
+  if r := MdotM(a.T(), d); !Mequal(r, NewDenseMatrix(RealType, 3, 3, []float64{1, 4, 1, 2, 5, 2, 3, 6, 3})) {
+    t.Error("Float64 matrix MdotM() failed!")
+  }
+  if r, ok := MaddM(a, b).(*DenseFloat64Matrix); !ok || !Mequal(r, NewDenseMatrix(RealType, 2, 3, []float64{2, 2, 4, 4, 6, 6})) {
+    t.Error("Float64 matrix MaddM() failed!")
+  }
+  if r := MsubM(a, d); !Mequal(r, NewDenseMatrix(RealType, 2, 3, []float64{0, 2, 2, 4, 4, 6})) {
+    t.Error("Float64 matrix MsubM() failed!")
+  }
+  if r := MdivS(a, NewReal(2)); r.At(1, 2).GetValue() != 3 {
+    t.Error("Float64 matrix MdivS() failed!")
+  }
+  // result shares storage with an argument
+  c := NewDenseFloat64Matrix(2, 2, []float64{1, 2, 3, 4})
+  c.MdotM(c, c)
+  if !Mequal(c, NewDenseMatrix(RealType, 2, 2, []float64{7, 10, 15, 22})) {
+    t.Error("Float64 matrix MdotM() failed!")
+  }
+  v := NullDenseFloat64Vector(2)
+  v.MdotV(a, NewDenseFloat64Vector([]float64{1, 1, 1}))
+  if v[0] != 6 || v[1] != 15 {
+    t.Error("Float64 matrix MdotV() failed!")
+  }
+}
+
+func TestDenseFloat64MatrixReshape(t *testing.T) {
+
+  a := NewDenseFloat64Matrix(2, 3, []float64{
+    1, 2, 3,
+    4, 5, 6})
+  // elements of the transposed matrix are taken in row-major order
+  b := a.T()
+  if err := b.Reshape(2, 3); err != nil {
+    t.Error(err)
+  }
+  if !Mequal(b, NewDenseMatrix(RealType, 2, 3, []float64{1, 4, 2, 5, 3, 6})) {
+    t.Error("Float64 matrix Reshape() failed!")
+  }
+  // the original matrix is not modified
+  if !Mequal(a, NewDenseMatrix(RealType, 2, 3, []float64{1, 2, 3, 4, 5, 6})) {
+    t.Error("Float64 matrix Reshape() failed!")
+  }
+}
+
+func TestDenseFloat64MatrixReference(t *testing.T) {
+
+  a := NullDenseFloat64Matrix(2, 2)
+  x := NewReal(3)
+  Variables(1, x)
+
+  // references hold values only
+  a.ReferenceAt(0, 1).Set(x)
+  if a.At(0, 1).GetValue() != 3 {
+    t.Error("Float64 matrix ReferenceAt() failed!")
+  }
+  defer func() {
+    if recover() == nil {
+      t.Error("Float64 matrix ReferenceAt() failed!")
+    }
+  }()
+  a.ReferenceAt(0, 1).Add(x, NewBareReal(1))
+}
@@ -29,14 +29,14 @@ func Mequal(a, b Matrix) bool {
   if n1 != n2 || m1 != m2 {
     panic("MEqual(): matrix dimensions do not match!")
   }
-  v1 := a.GetValues()
-  v2 := b.GetValues()
-  for i := 0; i < len(v1); i++ {
-    if !Equal(v1[i], v2[i]) {
-      return false
-    }
-  }
-  return true
+  r := true
+  a.StoredElements(func(i, j int, x Scalar) {
+    r = r && Equal(x, b.ConstAt(i, j))
+  })
+  b.StoredElements(func(i, j int, y Scalar) {
+    r = r && Equal(a.ConstAt(i, j), y)
+  })
+  return r
 }
 
 /* -------------------------------------------------------------------------- */
@@ -51,7 +51,7 @@ func (r *DenseMatrix) MaddM(a, b Matrix) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Add(a.ReferenceAt(i, j), b.ReferenceAt(i, j))
+      r.ReferenceAt(i, j).Add(a.ConstAt(i, j), b.ConstAt(i, j))
     }
   }
   return r
@@ -60,7 +60,7 @@ func (r *DenseMatrix) MaddM(a, b Matrix) Matrix {
 // Element-wise addition of two matrices.
 func MaddM(a, b Matrix) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultUnion, a.ElementType(), n, m, a, b)
   r.MaddM(a, b)
   return r
 }
@@ -76,7 +76,7 @@ func (r *DenseMatrix) MaddS(a Matrix, b Scalar) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Add(a.ReferenceAt(i, j), b)
+      r.ReferenceAt(i, j).Add(a.ConstAt(i, j), b)
     }
   }
   return r
@@ -85,7 +85,7 @@ func (r *DenseMatrix) MaddS(a Matrix, b Scalar) Matrix {
 // Add scalar b to all elements of a.
 func MaddS(a Matrix, b Scalar) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultDense, a.ElementType(), n, m, a, nil)
   r.MaddS(a, b)
   return r
 }
@@ -102,7 +102,7 @@ func (r *DenseMatrix) MsubM(a, b Matrix) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Sub(a.ReferenceAt(i, j), b.ReferenceAt(i, j))
+      r.ReferenceAt(i, j).Sub(a.ConstAt(i, j), b.ConstAt(i, j))
     }
   }
   return r
@@ -111,7 +111,7 @@ func (r *DenseMatrix) MsubM(a, b Matrix) Matrix {
 // Element-wise substraction of two matrices.
 func MsubM(a, b Matrix) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultUnion, a.ElementType(), n, m, a, b)
   r.MsubM(a, b)
   return r
 }
@@ -127,7 +127,7 @@ func (r *DenseMatrix) MsubS(a Matrix, b Scalar) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Sub(a.ReferenceAt(i, j), b)
+      r.ReferenceAt(i, j).Sub(a.ConstAt(i, j), b)
     }
   }
   return r
@@ -136,7 +136,7 @@ func (r *DenseMatrix) MsubS(a Matrix, b Scalar) Matrix {
 // Substract b from all elements of a.
 func MsubS(a Matrix, b Scalar) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultDense, a.ElementType(), n, m, a, nil)
   r.MsubS(a, b)
   return r
 }
@@ -153,7 +153,7 @@ func (r *DenseMatrix) MmulM(a, b Matrix) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Mul(a.ReferenceAt(i, j), b.ReferenceAt(i, j))
+      r.ReferenceAt(i, j).Mul(a.ConstAt(i, j), b.ConstAt(i, j))
     }
   }
   return r
@@ -162,7 +162,7 @@ func (r *DenseMatrix) MmulM(a, b Matrix) Matrix {
 // Element-wise multiplication of two matrices.
 func MmulM(a, b Matrix) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultIntersection, a.ElementType(), n, m, a, b)
   r.MmulM(a, b)
   return r
 }
@@ -178,7 +178,7 @@ func (r *DenseMatrix) MmulS(a Matrix, b Scalar) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Mul(a.ReferenceAt(i, j), b)
+      r.ReferenceAt(i, j).Mul(a.ConstAt(i, j), b)
     }
   }
   return r
@@ -187,7 +187,7 @@ func (r *DenseMatrix) MmulS(a Matrix, b Scalar) Matrix {
 // Multiply all elements of a with b.
 func MmulS(a Matrix, b Scalar) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultLike, a.ElementType(), n, m, a, nil)
   r.MmulS(a, b)
   return r
 }
@@ -204,7 +204,7 @@ func (r *DenseMatrix) MdivM(a, b Matrix) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Div(a.ReferenceAt(i, j), b.ReferenceAt(i, j))
+      r.ReferenceAt(i, j).Div(a.ConstAt(i, j), b.ConstAt(i, j))
     }
   }
   return r
@@ -213,7 +213,7 @@ func (r *DenseMatrix) MdivM(a, b Matrix) Matrix {
 // Element-wise division of two matrices.
 func MdivM(a, b Matrix) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultLike, a.ElementType(), n, m, a, nil)
   r.MdivM(a, b)
   return r
 }
@@ -229,7 +229,7 @@ func (r *DenseMatrix) MdivS(a Matrix, b Scalar) Matrix {
   }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).Div(a.ReferenceAt(i, j), b)
+      r.ReferenceAt(i, j).Div(a.ConstAt(i, j), b)
     }
   }
   return r
@@ -238,7 +238,7 @@ func (r *DenseMatrix) MdivS(a Matrix, b Scalar) Matrix {
 // Divide all elements of a by b.
 func MdivS(a Matrix, b Scalar) Matrix {
   n, m := a.Dims()
-  r := NullDenseMatrix(a.ElementType(), n, m)
+  r := nullMatrix(resultLike, a.ElementType(), n, m, a, nil)
   r.MdivS(a, b)
   return r
 }
@@ -253,13 +253,19 @@ func (r *DenseMatrix) MdotM(a, b Matrix) Matrix {
   t1 := NullScalar(a.ElementType())
   t2 := NullScalar(a.ElementType())
   t3 := r.Tmp2[0:m]
-  if n1 != n || m2 != m || n1 != m2 || m1 != n2 {
+  if n1 != n || m2 != m || m1 != n2 {
     panic("matrix dimensions do not match!")
   }
+  if storageFormatOf(a).sparse || storageFormatOf(b).sparse {
+    r.Copy(NullSparseMatrix(r.ElementType(), n, m).MdotM(a, b))
+    return r
+  }
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
       t2.Reset()
-      for k := 0; k < m1; k++ {
+      k1, k2 := bandRowRange(a, i)
+      l1, l2 := bandColRange(b, j)
+      for k := iMax(k1, l1); k <= iMin(k2, l2); k++ {
         t1.Mul(a.ReferenceAt(i, k), b.ReferenceAt(k, j))
         t2.Add(t2, t1)
       }
@@ -276,7 +282,7 @@ func (r *DenseMatrix) MdotM(a, b Matrix) Matrix {
 func MdotM(a, b Matrix) Matrix {
   n1, _  := a.Dims()
   _,  m2 := b.Dims()
-  r := NullDenseMatrix(a.ElementType(), n1, m2)
+  r := nullMatrix(resultProduct, a.ElementType(), n1, m2, a, b)
   r.MdotM(a, b)
   return r
 }
@@ -290,9 +296,24 @@ func (r Vector) MdotV(a Matrix, b Vector) Vector {
     panic("matrix/vector dimensions do not match!")
   }
   t := NullScalar(a.ElementType())
+  if s, ok := a.(*SparseMatrix); ok {
+    r.Reset()
+    for p := 0; p+1 < len(s.Offset); p++ {
+      for k := s.Offset[p]; k < s.Offset[p+1]; k++ {
+        i, j := p, s.Index[k]
+        if s.Transposed {
+          i, j = j, i
+        }
+        t.Mul(s.Values[k], b[j])
+        r[i].Add(r[i], t)
+      }
+    }
+    return r
+  }
   for i := 0; i < n; i++ {
     r[i].Reset()
-    for j := 0; j < m; j++ {
+    j1, j2 := bandRowRange(a, i)
+    for j := j1; j <= j2; j++ {
       t.Mul(a.ReferenceAt(i, j), b[j])
       r[i].Add(r[i], t)
     }
@@ -317,9 +338,24 @@ func (r Vector) VdotM(a Vector, b Matrix) Vector {
     panic("matrix/vector dimensions do not match!")
   }
   t := NullScalar(a.ElementType())
+  if s, ok := b.(*SparseMatrix); ok {
+    r.Reset()
+    for p := 0; p+1 < len(s.Offset); p++ {
+      for k := s.Offset[p]; k < s.Offset[p+1]; k++ {
+        i, j := p, s.Index[k]
+        if s.Transposed {
+          i, j = j, i
+        }
+        t.Mul(a[i], s.Values[k])
+        r[j].Add(r[j], t)
+      }
+    }
+    return r
+  }
   for i := 0; i < m; i++ {
     r[i].Reset()
-    for j := 0; j < n; j++ {
+    j1, j2 := bandColRange(b, i)
+    for j := j1; j <= j2; j++ {
       t.Mul(a[j], b.ReferenceAt(j, i))
       r[i].Add(r[i], t)
     }
@@ -371,7 +407,7 @@ func Mtrace(a Matrix) Scalar {
   }
   t := a.At(0, 0)
   for i := 1; i < n; i++ {
-    t.Add(t, a.ReferenceAt(i,i))
+    t.Add(t, a.ConstAt(i, i))
   }
   return t
 }
@@ -386,22 +422,42 @@ func Mnorm(a Matrix) Scalar {
   }
   c := NewBareReal(2.0)
   t := NewScalar(a.ElementType(), 0.0)
-  v := a.GetValues()
-  s := Pow(v[0], NewBareReal(2.0))
-  for i := 1; i < len(v); i++ {
-    t.Pow(v[i], c)
+  s := NewScalar(a.ElementType(), 0.0)
+  a.StoredElements(func(i, j int, x Scalar) {
+    t.Pow(x, c)
     s.Add(s, t)
-  }
+  })
   return s
 }
 
 /* -------------------------------------------------------------------------- */
 
-// Compute the Jacobian of f at x_. The result is stored in r.
+// Copy the derivative of y with respect to the jth variable of a nested
+// context at offset to r. Derivatives of r with respect to outer variables
+// are given by mixed second derivatives of y.
+func copyNestedDerivative(r, y Scalar, j, offset int) {
+  if offset == 0 {
+    r.SetValue(y.GetDerivative(1, j))
+    return
+  }
+  r.SetVariable(0, offset, 1)
+  r.SetValue(y.GetDerivative(1, offset+j))
+  for k := 0; k < offset; k++ {
+    r.SetDerivative(1, k, y.GetHessian(k, offset+j))
+  }
+}
+
+// Compute the Jacobian of f at x_. The result is stored in r. Derivatives
+// are computed in a context nested within all slots carried by x_ (see
+// ContextOf), and if such slots exist, the elements of r carry derivatives
+// with respect to them. In this case f is evaluated with second order
+// derivatives.
 func (r *DenseMatrix) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
   n, m := r.Dims()
   x := x_.Clone()
-  x.Variables(1)
+  c := DiffContext{}.nestedWithin(len(x), x_)
+  c.Variables(c.Order(), x...)
+  k := c.Offset
   // compute Jacobian
   y := f(x)
   if len(x) != m || len(y) != n {
@@ -410,29 +466,220 @@ func (r *DenseMatrix) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
   // copy derivatives
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).SetValue(y[i].GetDerivative(1, j))
+      copyNestedDerivative(r.ReferenceAt(i, j), y[i], j, k)
     }
   }
   return r
 }
 
-// Compute the Jacobian of f at x_.
+// Compute the Jacobian of f at x_ in a context nested within all slots
+// carried by x_.
 func Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
+  return DiffContext{}.Jacobian(f, x_)
+}
+
+// Compute the Jacobian of f at x_ in a context nested within ctx and within
+// all slots carried by x_. Elements of the result carry derivatives with
+// respect to the slots of enclosing contexts, which must be given explicitly
+// if f depends on variables that are not passed as arguments.
+func (ctx DiffContext) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
   x := x_.Clone()
-  x.Variables(1)
+  c := ctx.nestedWithin(len(x), x_)
+  c.Variables(c.Order(), x...)
+  k := c.Offset
   // compute Jacobian
   y := f(x)
   n := len(y)
   m := len(x)
   r := NullDenseMatrix(x.ElementType(), n, m)
-  if n != len(y) {
-    panic("Jacobian(): dimensions do not match!")
-  }
   // copy derivatives
   for i := 0; i < n; i++ {
     for j := 0; j < m; j++ {
-      r.ReferenceAt(i, j).SetValue(y[i].GetDerivative(1, j))
+      copyNestedDerivative(r.ReferenceAt(i, j), y[i], j, k)
+    }
+  }
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Hessian of f at x_. The result is stored in r. The Hessian is
+// computed in a context nested within all slots carried by x_, but the
+// elements of r carry no derivatives.
+func (r *DenseMatrix) Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  n, m := r.Dims()
+  x := x_.Clone()
+  c := DiffContext{}.nestedWithin(len(x), x_)
+  c.Variables(2, x...)
+  k := c.Offset
+  // compute Hessian
+  y := f(x)
+  if len(x) != n || len(x) != m {
+    panic("matrix/vector dimensions do not match")
+  }
+  // copy second derivatives
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      r.ReferenceAt(i, j).SetValue(y.GetHessian(k+i, k+j))
+    }
+  }
+  return r
+}
+
+// Compute the Hessian of f at x_ in a context nested within all slots
+// carried by x_.
+func Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  return DiffContext{}.Hessian(f, x_)
+}
+
+// Compute the Hessian of f at x_ in a context nested within ctx and within
+// all slots carried by x_. Elements of the result carry no derivatives.
+func (ctx DiffContext) Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  x := x_.Clone()
+  c := ctx.nestedWithin(len(x), x_)
+  c.Variables(2, x...)
+  k := c.Offset
+  // compute Hessian
+  y := f(x)
+  n := len(x)
+  r := NullDenseMatrix(x.ElementType(), n, n)
+  // copy second derivatives
+  for i := 0; i < n; i++ {
+    for j := 0; j < n; j++ {
+      r.ReferenceAt(i, j).SetValue(y.GetHessian(k+i, k+j))
+    }
+  }
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the product J v of the Jacobian of f at x_ with v in forward mode
+// in a context nested within all slots carried by x_.
+func Jvp(f func(Vector) Vector, x_, v Vector) Vector {
+  return DiffContext{}.Jvp(f, x_, v)
+}
+
+// Compute the product J v of the Jacobian of f at x_ with v in forward mode,
+// where derivatives with respect to a single direction are propagated. As in
+// Jacobian, elements of the result carry derivatives with respect to the
+// slots of enclosing contexts.
+func (ctx DiffContext) Jvp(f func(Vector) Vector, x_, v Vector) Vector {
+  if len(x_) != len(v) {
+    panic("vector dimensions do not match")
+  }
+  x := x_.Clone()
+  c := ctx.nestedWithin(1, x_)
+  k := c.Offset
+  for i := 0; i < len(x); i++ {
+    setNestedVariable(x[i], k, k+1, k, c.Order())
+    x[i].SetDerivative(1, k, v[i].GetValue())
+  }
+  y := f(x)
+  r := NullVector(x.ElementType(), len(y))
+  for i := 0; i < len(y); i++ {
+    copyNestedDerivative(r[i], y[i], 0, k)
+  }
+  return r
+}
+
+// Compute the product w^T J of w with the Jacobian of f at x_ in a context
+// nested within all slots carried by x_.
+func Vjp(f func(Vector) Vector, x_, w Vector) Vector {
+  return DiffContext{}.Vjp(f, x_, w)
+}
+
+// Compute the product w^T J of w with the Jacobian of f at x_. If there are
+// no enclosing contexts, f is evaluated on TapeReal scalars, so that the
+// product is obtained by a single backward sweep. Otherwise f is evaluated
+// in a nested context and as in Jacobian, the elements of the result carry
+// derivatives with respect to the slots of enclosing contexts.
+func (ctx DiffContext) Vjp(f func(Vector) Vector, x_, w Vector) Vector {
+  c := ctx.nestedWithin(len(x_), x_)
+  k := c.Offset
+  if k == 0 {
+    x := NullVector(TapeRealType, len(x_))
+    for i := 0; i < len(x); i++ {
+      x[i].SetValue(x_[i].GetValue())
+    }
+    c.Variables(1, x...)
+    y := f(x)
+    if len(y) != len(w) {
+      panic("vector dimensions do not match")
+    }
+    s := NullTapeReal().VdotV(w, y)
+    r := NullVector(x_.ElementType(), len(x))
+    for i := 0; i < len(x); i++ {
+      r[i].SetValue(s.GetDerivative(1, i))
     }
+    return r
+  }
+  x := x_.Clone()
+  c.Variables(2, x...)
+  y := f(x)
+  if len(y) != len(w) {
+    panic("vector dimensions do not match")
+  }
+  s := VdotV(y, w)
+  r := NullVector(x.ElementType(), len(x))
+  for i := 0; i < len(x); i++ {
+    copyNestedDerivative(r[i], s, i, k)
+  }
+  return r
+}
+
+// Compute the product H v of the Hessian of f at x_ with v. The function f
+// is recorded as Graph and the product is computed in forward-over-reverse
+// mode. If f cannot be recorded, e.g. because constants of other scalar
+// types are combined with its argument, the product is computed in forward
+// mode with one pass for each element of the result. In both cases memory
+// grows only linearly with the length of x_. Elements of the result carry
+// no derivatives and derivatives carried by x_ and v are ignored. Since
+// derivatives with respect to variables of enclosing contexts would require
+// third order derivatives, Hvp panics if f depends on such variables.
+func Hvp(f func(Vector) Scalar, x_, v Vector) Vector {
+  if len(x_) != len(v) {
+    panic("vector dimensions do not match")
+  }
+  g, err := NewGraph(func(x Vector) (Scalar, error) { return f(x), nil }, x_)
+  if err != nil {
+    return hvpForward(f, x_, v)
+  }
+  if g.outer {
+    panic("Hvp(): derivatives with respect to outer variables are not supported")
+  }
+  hv := make([]float64, len(x_))
+  g.Hvp(hv, x_.Slice(), v.Slice())
+  return NewVector(x_.ElementType(), hv)
+}
+
+// Forward mode, where the ith element of H v is the mixed second derivative
+// of f in directions e_i and v. Both directions are assigned to two slots
+// of a context nested within all slots on which f depends.
+func hvpForward(f func(Vector) Scalar, x_, v Vector) Vector {
+  n := len(x_)
+  x := NullVector(x_.ElementType(), n)
+  for i := 0; i < n; i++ {
+    x[i].SetValue(x_[i].GetValue())
+  }
+  y := f(x)
+  for j := 0; j < y.GetN() && y.GetOrder() >= 1; j++ {
+    if y.GetDerivative(1, j) != 0.0 {
+      panic("Hvp(): derivatives with respect to outer variables are not supported")
+    }
+  }
+  c := ContextOf(Vector{y}).Nested(2)
+  k := c.Offset
+  for i := 0; i < n; i++ {
+    x[i].SetVariable(k, k+2, 2)
+    x[i].SetDerivative(1, k,   0.0)
+    x[i].SetDerivative(1, k+1, v[i].GetValue())
+  }
+  r := NullVector(x_.ElementType(), n)
+  for i := 0; i < n; i++ {
+    x[i].SetDerivative(1, k, 1.0)
+    r[i].SetValue(f(x).GetHessian(k, k+1))
+    x[i].SetDerivative(1, k, 0.0)
   }
   return r
 }
@@ -0,0 +1,391 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "bytes"
+import "bufio"
+import "errors"
+import "fmt"
+import "reflect"
+import "os"
+
+/* matrix type declaration
+ * -------------------------------------------------------------------------- */
+
+// View of a dense matrix that shares storage with its parent. Element (i, j)
+// is stored at Values[Offset + i*RowStride + j*ColStride], where Values is
+// the storage of the parent. Views of submatrices, rows, columns and the
+// diagonal are obtained without copying data, and all methods, including
+// in-place operations such as MaddM, write through to the parent.
+type DenseMatrixView struct {
+  Values    Vector
+  Offset    int
+  Rows      int
+  Cols      int
+  RowStride int
+  ColStride int
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Returns a view of the full matrix.
+func (matrix *DenseMatrix) View() *DenseMatrixView {
+  if matrix.Transposed {
+    return &DenseMatrixView{matrix.Values, 0, matrix.Rows, matrix.Cols, 1, matrix.Rows}
+  } else {
+    return &DenseMatrixView{matrix.Values, 0, matrix.Rows, matrix.Cols, matrix.Cols, 1}
+  }
+}
+
+// Returns a view of the submatrix with rows rfrom to rto and columns cfrom
+// to cto.
+func (matrix *DenseMatrix) SubmatrixView(rfrom, rto, cfrom, cto int) *DenseMatrixView {
+  return matrix.View().SubmatrixView(rfrom, rto, cfrom, cto)
+}
+
+// Returns a view of the ith row as 1 x m matrix.
+func (matrix *DenseMatrix) RowView(i int) *DenseMatrixView {
+  return matrix.View().RowView(i)
+}
+
+// Returns a view of the jth column as n x 1 matrix.
+func (matrix *DenseMatrix) ColView(j int) *DenseMatrixView {
+  return matrix.View().ColView(j)
+}
+
+// Returns a view of the diagonal as n x 1 matrix.
+func (matrix *DenseMatrix) DiagView() *DenseMatrixView {
+  return matrix.View().DiagView()
+}
+
+func (matrix *DenseMatrixView) SubmatrixView(rfrom, rto, cfrom, cto int) *DenseMatrixView {
+  if rfrom < 0 || cfrom < 0 || rto >= matrix.Rows || cto >= matrix.Cols || rfrom > rto+1 || cfrom > cto+1 {
+    panic("SubmatrixView(): index out of range!")
+  }
+  return &DenseMatrixView{
+    Values   : matrix.Values,
+    Offset   : matrix.index(rfrom, cfrom),
+    Rows     : rto-rfrom+1,
+    Cols     : cto-cfrom+1,
+    RowStride: matrix.RowStride,
+    ColStride: matrix.ColStride}
+}
+
+func (matrix *DenseMatrixView) RowView(i int) *DenseMatrixView {
+  return matrix.SubmatrixView(i, i, 0, matrix.Cols-1)
+}
+
+func (matrix *DenseMatrixView) ColView(j int) *DenseMatrixView {
+  return matrix.SubmatrixView(0, matrix.Rows-1, j, j)
+}
+
+func (matrix *DenseMatrixView) DiagView() *DenseMatrixView {
+  if matrix.Rows != matrix.Cols {
+    panic("DiagView(): not a square matrix!")
+  }
+  return &DenseMatrixView{
+    Values   : matrix.Values,
+    Offset   : matrix.Offset,
+    Rows     : matrix.Rows,
+    Cols     : 1,
+    RowStride: matrix.RowStride + matrix.ColStride,
+    ColStride: 0}
+}
+
+/* copy and cloning
+ * -------------------------------------------------------------------------- */
+
+// Returns a dense matrix with copies of all elements.
+func (matrix *DenseMatrixView) Clone() Matrix {
+  r := NilDenseMatrix(matrix.Rows, matrix.Cols)
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      r.Values[i*matrix.Cols+j] = matrix.At(i, j)
+    }
+  }
+  r.initTmp()
+  return r
+}
+
+// Returns a copy of the view that shares storage with the parent.
+func (matrix *DenseMatrixView) CloneShallow() Matrix {
+  r := *matrix
+  return &r
+}
+
+func (a *DenseMatrixView) Copy(b Matrix) {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != n2 || m1 != m2 {
+    panic("Copy(): Matrix dimension does not match!")
+  }
+  a.mapView(func(c Scalar, i, j int) {
+    c.Copy(b.ConstAt(i, j))
+  })
+}
+
+/* field access
+ * -------------------------------------------------------------------------- */
+
+func (matrix *DenseMatrixView) index(i, j int) int {
+  return matrix.Offset + i*matrix.RowStride + j*matrix.ColStride
+}
+
+// Call f for all elements of the view.
+func (matrix *DenseMatrixView) mapView(f func(c Scalar, i, j int)) Matrix {
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      f(matrix.Values[matrix.index(i, j)], i, j)
+    }
+  }
+  return matrix
+}
+
+func (matrix *DenseMatrixView) Dims() (int, int) {
+  return matrix.Rows, matrix.Cols
+}
+
+// Returns references to all elements in row-major order.
+func (matrix *DenseMatrixView) GetValues() Vector {
+  v := NilVector(matrix.Rows*matrix.Cols)
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      v[i*matrix.Cols+j] = matrix.Values[matrix.index(i, j)]
+    }
+  }
+  return v
+}
+
+// Set references to all elements in row-major order.
+func (matrix *DenseMatrixView) SetValues(v Vector) {
+  if len(v) != matrix.Rows*matrix.Cols {
+    panic("SetValues(): vector dimension does not match!")
+  }
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      matrix.Values[matrix.index(i, j)] = v[i*matrix.Cols+j]
+    }
+  }
+}
+
+func (matrix *DenseMatrixView) Row(i int) Vector {
+  return matrix.RowView(i).GetValues()
+}
+
+func (matrix *DenseMatrixView) Col(j int) Vector {
+  return matrix.ColView(j).GetValues()
+}
+
+func (matrix *DenseMatrixView) Diag() Vector {
+  return matrix.DiagView().GetValues()
+}
+
+// Returns a dense copy of the submatrix. Use SubmatrixView to obtain a
+// view.
+func (matrix *DenseMatrixView) Submatrix(rfrom, rto, cfrom, cto int) Matrix {
+  return matrix.SubmatrixView(rfrom, rto, cfrom, cto).Clone()
+}
+
+func (matrix *DenseMatrixView) Reshape(rows, cols int) error {
+  return errors.New("Reshape(): not supported for matrix views")
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (matrix *DenseMatrixView) At(i, j int) Scalar {
+  return matrix.Values[matrix.index(i, j)].Clone()
+}
+
+func (matrix *DenseMatrixView) ReferenceAt(i, j int) Scalar {
+  return matrix.Values[matrix.index(i, j)]
+}
+
+func (matrix *DenseMatrixView) ConstAt(i, j int) Scalar {
+  return matrix.Values[matrix.index(i, j)]
+}
+
+func (matrix *DenseMatrixView) StoredElements(f func(i, j int, a Scalar)) {
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      f(i, j, matrix.Values[matrix.index(i, j)])
+    }
+  }
+}
+
+func (matrix *DenseMatrixView) storageFormat() matrixStorage {
+  return matrixStorage{}
+}
+
+func (matrix *DenseMatrixView) Set(s Scalar, i, j int) {
+  matrix.Values[matrix.index(i, j)].Copy(s)
+}
+
+func (matrix *DenseMatrixView) SetReference(s Scalar, i, j int) {
+  matrix.Values[matrix.index(i, j)] = s
+}
+
+func (matrix *DenseMatrixView) Reset() {
+  matrix.mapView(func(c Scalar, i, j int) {
+    c.Reset()
+  })
+}
+
+func (matrix *DenseMatrixView) ResetDerivatives() {
+  matrix.mapView(func(c Scalar, i, j int) {
+    c.ResetDerivatives()
+  })
+}
+
+func (matrix *DenseMatrixView) SetIdentity() {
+  matrix.mapView(func(c Scalar, i, j int) {
+    c.Reset()
+    if i == j {
+      c.SetValue(1.0)
+    }
+  })
+}
+
+/* implement ScalarContainer
+ * -------------------------------------------------------------------------- */
+
+func (matrix *DenseMatrixView) Map(f func(Scalar) Scalar) {
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      matrix.SetReference(f(matrix.At(i, j)), i, j)
+    }
+  }
+}
+
+func (matrix *DenseMatrixView) Reduce(f func(Scalar, Scalar) Scalar) Scalar {
+  v := matrix.GetValues()
+  r := v[0].Clone()
+  for i := 1; i < len(v); i++ {
+    r = f(r, v[i])
+  }
+  return r
+}
+
+func (matrix *DenseMatrixView) ElementType() ScalarType {
+  if matrix.Rows > 0 && matrix.Cols > 0 {
+    return reflect.TypeOf(matrix.Values[matrix.Offset])
+  }
+  return nil
+}
+
+func (matrix *DenseMatrixView) ConvertElementType(t ScalarType) {
+  matrix.Map(func(x Scalar) Scalar {
+    return NewScalar(t, x.GetValue())
+  })
+}
+
+func (matrix *DenseMatrixView) Variables(order int) {
+  Variables(order, matrix.GetValues()...)
+}
+
+func (matrix *DenseMatrixView) VariablesAt(offset, order int) {
+  VariablesAt(offset, order, matrix.GetValues()...)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (m *DenseMatrixView) String() string {
+  var buffer bytes.Buffer
+
+  buffer.WriteString("[")
+  for i := 0; i < m.Rows; i++ {
+    if i != 0 {
+      buffer.WriteString(",\n ")
+    }
+    buffer.WriteString("[")
+    for j := 0; j < m.Cols; j++ {
+      if j != 0 {
+        buffer.WriteString(", ")
+      }
+      buffer.WriteString(m.ReferenceAt(i,j).String())
+    }
+    buffer.WriteString("]")
+  }
+  buffer.WriteString("]")
+
+  return buffer.String()
+}
+
+func (a *DenseMatrixView) Table() string {
+  var buffer bytes.Buffer
+
+  n, m := a.Dims()
+
+  for i := 0; i < n; i++ {
+    if i != 0 {
+      buffer.WriteString("\n")
+    }
+    for j := 0; j < m; j++ {
+      if j != 0 {
+        buffer.WriteString(" ")
+      }
+      buffer.WriteString(a.ReferenceAt(i,j).String())
+    }
+  }
+
+  return buffer.String()
+}
+
+func (m *DenseMatrixView) WriteMatrix(filename string) error {
+  f, err := os.Create(filename)
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  w := bufio.NewWriter(f)
+  defer w.Flush()
+
+  fmt.Fprintf(w, "%s\n", m.Table())
+
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Transpose without copying data.
+func (matrix *DenseMatrixView) T() Matrix {
+  return &DenseMatrixView{
+    Values   : matrix.Values,
+    Offset   : matrix.Offset,
+    Rows     : matrix.Cols,
+    Cols     : matrix.Rows,
+    RowStride: matrix.ColStride,
+    ColStride: matrix.RowStride}
+}
+
+// Row i of the result is row p[i] of the original matrix. Elements are
+// permuted by reference.
+func (matrix *DenseMatrixView) PermuteRows(p []int) {
+  if len(p) != matrix.Rows {
+    panic("PermuteRows(): permutation vector has invalid length!")
+  }
+  v := matrix.GetValues()
+  for i := 0; i < matrix.Rows; i++ {
+    for j := 0; j < matrix.Cols; j++ {
+      matrix.Values[matrix.index(i, j)] = v[p[i]*matrix.Cols+j]
+    }
+  }
+}
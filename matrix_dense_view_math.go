@@ -0,0 +1,130 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* -------------------------------------------------------------------------- */
+
+func (r *DenseMatrixView) checkDims(a, b Matrix) {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != r.Rows || m1 != r.Cols || n2 != r.Rows || m2 != r.Cols {
+    panic("matrix dimensions do not match!")
+  }
+}
+
+// Element-wise addition of two matrices. The result is stored in r.
+func (r *DenseMatrixView) MaddM(a, b Matrix) Matrix {
+  r.checkDims(a, b)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Add(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Add scalar b to all elements of a. The result is stored in r.
+func (r *DenseMatrixView) MaddS(a Matrix, b Scalar) Matrix {
+  r.checkDims(a, a)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Add(a.ConstAt(i, j), b)
+  })
+}
+
+// Element-wise substraction of two matrices. The result is stored in r.
+func (r *DenseMatrixView) MsubM(a, b Matrix) Matrix {
+  r.checkDims(a, b)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Sub(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Substract b from all elements of a. The result is stored in r.
+func (r *DenseMatrixView) MsubS(a Matrix, b Scalar) Matrix {
+  r.checkDims(a, a)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Sub(a.ConstAt(i, j), b)
+  })
+}
+
+// Element-wise multiplication of two matrices. The result is stored in r.
+func (r *DenseMatrixView) MmulM(a, b Matrix) Matrix {
+  r.checkDims(a, b)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Mul(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Multiply all elements of a with b. The result is stored in r.
+func (r *DenseMatrixView) MmulS(a Matrix, b Scalar) Matrix {
+  r.checkDims(a, a)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Mul(a.ConstAt(i, j), b)
+  })
+}
+
+// Element-wise division of two matrices. The result is stored in r.
+func (r *DenseMatrixView) MdivM(a, b Matrix) Matrix {
+  r.checkDims(a, b)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Div(a.ConstAt(i, j), b.ConstAt(i, j))
+  })
+}
+
+// Divide all elements of a by b. The result is stored in r.
+func (r *DenseMatrixView) MdivS(a Matrix, b Scalar) Matrix {
+  r.checkDims(a, a)
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Div(a.ConstAt(i, j), b)
+  })
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Matrix product of a and b. The result is stored in r. Since views may
+// overlap with a or b, the product is computed in a temporary matrix.
+func (r *DenseMatrixView) MdotM(a, b Matrix) Matrix {
+  t := NullDenseMatrix(r.ElementType(), r.Rows, r.Cols)
+  t.MdotM(a, b)
+  r.Copy(t)
+  return r
+}
+
+// Outer product of two vectors. The result is stored in r.
+func (r *DenseMatrixView) Outer(a, b Vector) Matrix {
+  if len(a) != r.Rows || len(b) != r.Cols {
+    panic("matrix/vector dimensions do not match!")
+  }
+  return r.mapView(func(c Scalar, i, j int) {
+    c.Mul(a[i], b[j])
+  })
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Jacobian of f at x_. The result is stored in r.
+func (r *DenseMatrixView) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
+  r.Copy(NullDenseMatrix(r.ElementType(), r.Rows, r.Cols).Jacobian(f, x_))
+  return r
+}
+
+// Compute the Hessian of f at x_. The result is stored in r.
+func (r *DenseMatrixView) Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  r.Copy(NullDenseMatrix(r.ElementType(), r.Rows, r.Cols).Hessian(f, x_))
+  return r
+}
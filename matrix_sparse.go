@@ -0,0 +1,570 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "bytes"
+import "bufio"
+import "errors"
+import "fmt"
+import "sort"
+import "os"
+
+/* matrix type declaration
+ * -------------------------------------------------------------------------- */
+
+// Sparse matrix in compressed sparse row (CSR) format. The column indices
+// of row i are stored in Index[Offset[i]:Offset[i+1]] in ascending order
+// and the corresponding elements in Values. A transposed matrix shares
+// the data of the original matrix, i.e. it is stored in compressed sparse
+// column (CSC) format.
+//
+// Elements that are not stored are structural zeros. At returns a new
+// zero for such elements, whereas ReferenceAt inserts a zero into the
+// matrix, so that in-place algorithms work as for dense matrices. Inserted
+// elements are visible in all matrices that share data (see T and
+// CloneShallow).
+type SparseMatrix struct {
+  *sparseStorage
+  Rows        int
+  Cols        int
+  Transposed  bool
+  elementType ScalarType
+}
+
+// Data of a sparse matrix, which is shared by transposed matrices and
+// shallow clones.
+type sparseStorage struct {
+  Values      Vector
+  Index       []int
+  Offset      []int
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a sparse matrix from triplets, where values[k] is the element at
+// row rowIndices[k] and column colIndices[k]. Duplicate elements are summed.
+func NewSparseMatrix(t ScalarType, rows, cols int, rowIndices, colIndices []int, values []float64) *SparseMatrix {
+  if len(rowIndices) != len(values) || len(colIndices) != len(values) {
+    panic("NewSparseMatrix(): number of indices does not match number of values!")
+  }
+  v := NilVector(len(values))
+  f := ScalarConstructor(t)
+  for k := 0; k < len(values); k++ {
+    if rowIndices[k] < 0 || rowIndices[k] >= rows || colIndices[k] < 0 || colIndices[k] >= cols {
+      panic("NewSparseMatrix(): index out of range!")
+    }
+    v[k] = f(values[k])
+  }
+  return newSparseMatrix(t, rows, cols, rowIndices, colIndices, v)
+}
+
+func NullSparseMatrix(t ScalarType, rows, cols int) *SparseMatrix {
+  m := SparseMatrix{}
+  m.sparseStorage = &sparseStorage{}
+  m.Values      = NilVector(0)
+  m.Offset      = make([]int, rows+1)
+  m.Rows        = rows
+  m.Cols        = cols
+  m.elementType = t
+  return &m
+}
+
+// Create a sparse matrix with zeros at all positions of the sparsity
+// pattern p.
+func newSparseMatrixFromPattern(t ScalarType, rows, cols int, p SparsityPattern) *SparseMatrix {
+  r := NullSparseMatrix(t, rows, cols)
+  for i := 0; i < len(p); i++ {
+    r.Index       = append(r.Index, p[i]...)
+    r.Offset[i+1] = len(r.Index)
+  }
+  r.Values = NullVector(t, len(r.Index))
+  return r
+}
+
+// Create a sparse matrix in CSR format from triplets. The scalars in values
+// are used as elements without copying them, except for duplicates, which
+// are summed into the first occurrence.
+func newSparseMatrix(t ScalarType, rows, cols int, ri, ci []int, values Vector) *SparseMatrix {
+  offset := make([]int, rows+1)
+  for _, i := range ri {
+    offset[i+1]++
+  }
+  for i := 0; i < rows; i++ {
+    offset[i+1] += offset[i]
+  }
+  // sort elements by row
+  perm := make([]int, len(ri))
+  next := append([]int{}, offset[0:rows]...)
+  for k, i := range ri {
+    perm[next[i]] = k; next[i]++
+  }
+  // sort rows by column and sum duplicates
+  r := NullSparseMatrix(t, rows, cols)
+  r.Index  = make([]int, 0, len(ri))
+  r.Values = make(Vector, 0, len(ri))
+  for i := 0; i < rows; i++ {
+    row := perm[offset[i]:offset[i+1]]
+    sort.Slice(row, func(a, b int) bool { return ci[row[a]] < ci[row[b]] })
+    for _, k := range row {
+      if n := len(r.Index); n > r.Offset[i] && r.Index[n-1] == ci[k] {
+        r.Values[n-1].Add(r.Values[n-1], values[k])
+      } else {
+        r.Index  = append(r.Index,  ci[k])
+        r.Values = append(r.Values, values[k])
+      }
+    }
+    r.Offset[i+1] = len(r.Index)
+  }
+  return r
+}
+
+// Returns the elements of a as triplets. Scalars are not copied.
+func (matrix *SparseMatrix) triplets() ([]int, []int, Vector) {
+  ri := make([]int, len(matrix.Index))
+  ci := make([]int, len(matrix.Index))
+  for p := 0; p+1 < len(matrix.Offset); p++ {
+    for k := matrix.Offset[p]; k < matrix.Offset[p+1]; k++ {
+      if matrix.Transposed {
+        ri[k], ci[k] = matrix.Index[k], p
+      } else {
+        ri[k], ci[k] = p, matrix.Index[k]
+      }
+    }
+  }
+  return ri, ci, matrix.Values
+}
+
+// Returns a in CSR format without copying elements. Dense matrices are
+// converted such that all elements are structural non-zeros.
+func asSparseMatrix(a Matrix) *SparseMatrix {
+  if s, ok := a.(*SparseMatrix); ok {
+    if !s.Transposed {
+      return s
+    }
+    ri, ci, v := s.triplets()
+    return newSparseMatrix(s.elementType, s.Rows, s.Cols, ri, ci, v)
+  }
+  n, m := a.Dims()
+  r := NullSparseMatrix(a.ElementType(), n, m)
+  r.Index  = make([]int, n*m)
+  r.Values = NilVector(n*m)
+  for i := 0; i < n; i++ {
+    for j := 0; j < m; j++ {
+      r.Index [i*m+j] = j
+      r.Values[i*m+j] = a.ReferenceAt(i, j)
+    }
+    r.Offset[i+1] = (i+1)*m
+  }
+  return r
+}
+
+// True if a is zero and carries no derivatives.
+func isStructuralZero(a Scalar) bool {
+  return a.GetValue() == 0.0 && a.GetN() == 0
+}
+
+/* copy and cloning
+ * -------------------------------------------------------------------------- */
+
+// Clone matrix including data.
+func (matrix *SparseMatrix) Clone() Matrix {
+  return &SparseMatrix{
+    sparseStorage: &sparseStorage{
+      Values     : matrix.Values.Clone(),
+      Index      : append([]int{}, matrix.Index...),
+      Offset     : append([]int{}, matrix.Offset...)},
+    Rows       : matrix.Rows,
+    Cols       : matrix.Cols,
+    Transposed : matrix.Transposed,
+    elementType: matrix.elementType}
+}
+
+// Clone matrix without duplicating data.
+func (matrix *SparseMatrix) CloneShallow() Matrix {
+  return &SparseMatrix{
+    sparseStorage: matrix.sparseStorage,
+    Rows       : matrix.Rows,
+    Cols       : matrix.Cols,
+    Transposed : matrix.Transposed,
+    elementType: matrix.elementType}
+}
+
+// Copy elements of b to a. Zeros without derivatives are not stored. The
+// elements are visible in all matrices that share data with a.
+func (a *SparseMatrix) Copy(b Matrix) {
+  n1, m1 := a.Dims()
+  n2, m2 := b.Dims()
+  if n1 != n2 || m1 != m2 {
+    panic("Copy(): Matrix dimensions do not match!")
+  }
+  ri := []int{}
+  ci := []int{}
+  v  := Vector{}
+  b.StoredElements(func(i, j int, x Scalar) {
+    if !isStructuralZero(x) {
+      ri = append(ri, i)
+      ci = append(ci, j)
+      v  = append(v, x.Clone())
+    }
+  })
+  a.assignTriplets(ri, ci, v)
+}
+
+/* field access
+ * -------------------------------------------------------------------------- */
+
+// Returns the position of element (i, j) in Values and whether the element
+// is stored. If not, the position is where it would have to be inserted.
+func (matrix *SparseMatrix) index(i, j int) (int, bool) {
+  if matrix.Transposed {
+    i, j = j, i
+  }
+  k1 := matrix.Offset[i]
+  k2 := matrix.Offset[i+1]
+  k  := k1 + sort.SearchInts(matrix.Index[k1:k2], j)
+  return k, k < k2 && matrix.Index[k] == j
+}
+
+// Insert s at position (i, j). The arrays grow in place with amortized
+// reallocation, and matrices that share data with this matrix (see T and
+// CloneShallow) see the new element.
+func (matrix *SparseMatrix) insert(s Scalar, i, j int) {
+  k, _ := matrix.index(i, j)
+  if matrix.Transposed {
+    i, j = j, i
+  }
+  matrix.Values = append(matrix.Values, nil)
+  matrix.Index  = append(matrix.Index,  0)
+  copy(matrix.Values[k+1:], matrix.Values[k:])
+  copy(matrix.Index [k+1:], matrix.Index [k:])
+  matrix.Values[k] = s
+  matrix.Index [k] = j
+  for l := i+1; l < len(matrix.Offset); l++ {
+    matrix.Offset[l]++
+  }
+}
+
+func (matrix *SparseMatrix) Dims() (int, int) {
+  return matrix.Rows, matrix.Cols
+}
+
+// Number of stored elements.
+func (matrix *SparseMatrix) GetNnz() int {
+  return len(matrix.Values)
+}
+
+// Returns the stored elements.
+func (matrix *SparseMatrix) GetValues() Vector {
+  return matrix.Values
+}
+
+func (matrix *SparseMatrix) SetValues(v Vector) {
+  if len(v) != len(matrix.Values) {
+    panic("SetValues(): vector dimension does not match number of stored elements!")
+  }
+  matrix.Values = v
+}
+
+// Returns a copy of the ith row.
+func (matrix *SparseMatrix) Row(i int) Vector {
+  v := NullVector(matrix.elementType, matrix.Cols)
+  for j := 0; j < matrix.Cols; j++ {
+    if k, ok := matrix.index(i, j); ok {
+      v[j].Set(matrix.Values[k])
+    }
+  }
+  return v
+}
+
+// Returns a copy of the jth column.
+func (matrix *SparseMatrix) Col(j int) Vector {
+  v := NullVector(matrix.elementType, matrix.Rows)
+  for i := 0; i < matrix.Rows; i++ {
+    if k, ok := matrix.index(i, j); ok {
+      v[i].Set(matrix.Values[k])
+    }
+  }
+  return v
+}
+
+// Returns a copy of the diagonal.
+func (matrix *SparseMatrix) Diag() Vector {
+  n, m := matrix.Dims()
+  if n != m {
+    panic("Diag(): not a square matrix!")
+  }
+  v := NullVector(matrix.elementType, n)
+  for i := 0; i < n; i++ {
+    if k, ok := matrix.index(i, i); ok {
+      v[i].Set(matrix.Values[k])
+    }
+  }
+  return v
+}
+
+func (matrix *SparseMatrix) Submatrix(rfrom, rto, cfrom, cto int) Matrix {
+  ri := []int{}
+  ci := []int{}
+  v  := Vector{}
+  r1, c1, v1 := matrix.triplets()
+  for k := 0; k < len(v1); k++ {
+    if r1[k] >= rfrom && r1[k] <= rto && c1[k] >= cfrom && c1[k] <= cto {
+      ri = append(ri, r1[k]-rfrom)
+      ci = append(ci, c1[k]-cfrom)
+      v  = append(v, v1[k].Clone())
+    }
+  }
+  return newSparseMatrix(matrix.elementType, rto-rfrom+1, cto-cfrom+1, ri, ci, v)
+}
+
+func (matrix *SparseMatrix) Reshape(rows, cols int) error {
+  if matrix.Rows*matrix.Cols != rows*cols {
+    return errors.New("Reshape(): invalid parameters")
+  }
+  ri, ci, v := matrix.triplets()
+  for k := 0; k < len(v); k++ {
+    l := ri[k]*matrix.Cols + ci[k]
+    ri[k], ci[k] = l/cols, l%cols
+  }
+  *matrix = *newSparseMatrix(matrix.elementType, rows, cols, ri, ci, v)
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (matrix *SparseMatrix) At(i, j int) Scalar {
+  if k, ok := matrix.index(i, j); ok {
+    return matrix.Values[k].Clone()
+  }
+  return NullScalar(matrix.elementType)
+}
+
+// Returns element (i, j) or a new zero for structural zeros.
+func (matrix *SparseMatrix) ConstAt(i, j int) Scalar {
+  if k, ok := matrix.index(i, j); ok {
+    return matrix.Values[k]
+  }
+  return NullScalar(matrix.elementType)
+}
+
+func (matrix *SparseMatrix) StoredElements(f func(i, j int, a Scalar)) {
+  for p := 0; p+1 < len(matrix.Offset); p++ {
+    for k := matrix.Offset[p]; k < matrix.Offset[p+1]; k++ {
+      if matrix.Transposed {
+        f(matrix.Index[k], p, matrix.Values[k])
+      } else {
+        f(p, matrix.Index[k], matrix.Values[k])
+      }
+    }
+  }
+}
+
+func (matrix *SparseMatrix) storageFormat() matrixStorage {
+  return matrixStorage{sparse: true}
+}
+
+// Returns a reference to element (i, j). Structural zeros are inserted into
+// the matrix.
+func (matrix *SparseMatrix) ReferenceAt(i, j int) Scalar {
+  if k, ok := matrix.index(i, j); ok {
+    return matrix.Values[k]
+  }
+  s := NullScalar(matrix.elementType)
+  matrix.insert(s, i, j)
+  return s
+}
+
+func (matrix *SparseMatrix) Set(s Scalar, i, j int) {
+  if k, ok := matrix.index(i, j); ok {
+    matrix.Values[k].Copy(s)
+  } else {
+    matrix.insert(s.Clone(), i, j)
+  }
+}
+
+func (matrix *SparseMatrix) SetReference(s Scalar, i, j int) {
+  if k, ok := matrix.index(i, j); ok {
+    matrix.Values[k] = s
+  } else {
+    matrix.insert(s, i, j)
+  }
+}
+
+// Set all stored elements to zero. The sparsity structure is kept.
+func (matrix *SparseMatrix) Reset() {
+  for i := 0; i < len(matrix.Values); i++ {
+    matrix.Values[i].Reset()
+  }
+}
+
+func (matrix *SparseMatrix) ResetDerivatives() {
+  for i := 0; i < len(matrix.Values); i++ {
+    matrix.Values[i].ResetDerivatives()
+  }
+}
+
+// Set matrix to identity. Only the diagonal is stored.
+func (matrix *SparseMatrix) SetIdentity() {
+  n, m := matrix.Dims()
+  k := iMin(n, m)
+  r := NullSparseMatrix(matrix.elementType, n, m)
+  r.Index  = make([]int, k)
+  r.Values = NullVector(matrix.elementType, k)
+  for i := 0; i < n; i++ {
+    if i < k {
+      r.Index[i] = i
+      r.Values[i].SetValue(1.0)
+      r.Offset[i+1] = i+1
+    } else {
+      r.Offset[i+1] = k
+    }
+  }
+  matrix.assign(r.Values, r.Index, r.Offset)
+}
+
+/* implement ScalarContainer
+ * -------------------------------------------------------------------------- */
+
+// Apply f to all stored elements.
+func (matrix *SparseMatrix) Map(f func(Scalar) Scalar) {
+  for k := 0; k < len(matrix.Values); k++ {
+    matrix.Values[k] = f(matrix.Values[k].Clone())
+  }
+}
+
+// Reduce all stored elements.
+func (matrix *SparseMatrix) Reduce(f func(Scalar, Scalar) Scalar) Scalar {
+  if len(matrix.Values) == 0 {
+    return NullScalar(matrix.elementType)
+  }
+  r := matrix.Values[0].Clone()
+  for k := 1; k < len(matrix.Values); k++ {
+    r = f(r, matrix.Values[k])
+  }
+  return r
+}
+
+func (matrix *SparseMatrix) ElementType() ScalarType {
+  return matrix.elementType
+}
+
+func (matrix *SparseMatrix) ConvertElementType(t ScalarType) {
+  matrix.Map(func(x Scalar) Scalar {
+    return NewScalar(t, x.GetValue())
+  })
+  matrix.elementType = t
+}
+
+// Declare all stored elements as variables.
+func (matrix *SparseMatrix) Variables(order int) {
+  Variables(order, matrix.Values...)
+}
+
+func (matrix *SparseMatrix) VariablesAt(offset, order int) {
+  VariablesAt(offset, order, matrix.Values...)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (m *SparseMatrix) String() string {
+  var buffer bytes.Buffer
+
+  buffer.WriteString("[")
+  for i := 0; i < m.Rows; i++ {
+    if i != 0 {
+      buffer.WriteString(",\n ")
+    }
+    buffer.WriteString("[")
+    for j := 0; j < m.Cols; j++ {
+      if j != 0 {
+        buffer.WriteString(", ")
+      }
+      buffer.WriteString(m.At(i,j).String())
+    }
+    buffer.WriteString("]")
+  }
+  buffer.WriteString("]")
+
+  return buffer.String()
+}
+
+func (a *SparseMatrix) Table() string {
+  var buffer bytes.Buffer
+
+  n, m := a.Dims()
+
+  for i := 0; i < n; i++ {
+    if i != 0 {
+      buffer.WriteString("\n")
+    }
+    for j := 0; j < m; j++ {
+      if j != 0 {
+        buffer.WriteString(" ")
+      }
+      buffer.WriteString(a.At(i,j).String())
+    }
+  }
+
+  return buffer.String()
+}
+
+func (m *SparseMatrix) WriteMatrix(filename string) error {
+  f, err := os.Create(filename)
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  w := bufio.NewWriter(f)
+  defer w.Flush()
+
+  fmt.Fprintf(w, "%s\n", m.Table())
+
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Transpose without copying data.
+func (matrix *SparseMatrix) T() Matrix {
+  return &SparseMatrix{
+    sparseStorage: matrix.sparseStorage,
+    Rows       :  matrix.Cols,
+    Cols       :  matrix.Rows,
+    Transposed : !matrix.Transposed,
+    elementType:  matrix.elementType}
+}
+
+// Row i of the result is row p[i] of the original matrix.
+func (matrix *SparseMatrix) PermuteRows(p []int) {
+  if len(p) != matrix.Rows {
+    panic("PermuteRows(): permutation vector has invalid length!")
+  }
+  q := make([]int, len(p))
+  for i := 0; i < len(p); i++ {
+    q[p[i]] = i
+  }
+  ri, ci, v := matrix.triplets()
+  for k := 0; k < len(ri); k++ {
+    ri[k] = q[ri[k]]
+  }
+  matrix.assignTriplets(ri, ci, v)
+}
@@ -0,0 +1,282 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "sort"
+
+/* -------------------------------------------------------------------------- */
+
+// Replace the content of r by a matrix in CSR format. If r is transposed,
+// the data is converted to CSC format, so that matrices that share data
+// with r see the new elements.
+func (r *SparseMatrix) assign(values Vector, index, offset []int) {
+  if r.Transposed {
+    s := &SparseMatrix{sparseStorage: &sparseStorage{values, index, offset}, Rows: r.Rows, Cols: r.Cols}
+    ri, ci, v := s.triplets()
+    s = newSparseMatrix(r.elementType, r.Cols, r.Rows, ci, ri, v)
+    values, index, offset = s.Values, s.Index, s.Offset
+  }
+  r.Values = values
+  r.Index  = index
+  r.Offset = offset
+}
+
+// Replace the content of r by the given triplets.
+func (r *SparseMatrix) assignTriplets(ri, ci []int, values Vector) {
+  s := newSparseMatrix(r.elementType, r.Rows, r.Cols, ri, ci, values)
+  r.assign(s.Values, s.Index, s.Offset)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Merge rows of a and b and apply f to each pair of elements. Elements that
+// are stored in only one of the matrices are paired with zero. If needA
+// (needB) is true, elements that are not stored in a (b) are skipped.
+func (r *SparseMatrix) mergeM(a_, b_ Matrix, needA, needB bool, f func(c, a, b Scalar)) Matrix {
+  n,  m  := r.Dims()
+  n1, m1 := a_.Dims()
+  n2, m2 := b_.Dims()
+  if n1 != n || m1 != m || n2 != n || m2 != m {
+    panic("matrix dimensions do not match!")
+  }
+  a := asSparseMatrix(a_)
+  b := asSparseMatrix(b_)
+  z := NullScalar(r.elementType)
+  values := Vector{}
+  index  := []int{}
+  offset := make([]int, n+1)
+  for i := 0; i < n; i++ {
+    k1, k2 := a.Offset[i], b.Offset[i]
+    for k1 < a.Offset[i+1] || k2 < b.Offset[i+1] {
+      var j int
+      var x, y Scalar
+      switch {
+      case k2 == b.Offset[i+1] || (k1 < a.Offset[i+1] && a.Index[k1] < b.Index[k2]):
+        j, x, y = a.Index[k1], a.Values[k1], z; k1++
+        if needB {
+          continue
+        }
+      case k1 == a.Offset[i+1] || b.Index[k2] < a.Index[k1]:
+        j, x, y = b.Index[k2], z, b.Values[k2]; k2++
+        if needA {
+          continue
+        }
+      default:
+        j, x, y = a.Index[k1], a.Values[k1], b.Values[k2]; k1++; k2++
+      }
+      c := NullScalar(r.elementType)
+      f(c, x, y)
+      values = append(values, c)
+      index  = append(index,  j)
+    }
+    offset[i+1] = len(index)
+  }
+  r.assign(values, index, offset)
+  return r
+}
+
+// Apply f to all elements of a. If dense is true, structural zeros of a are
+// included and the result has no structural zeros.
+func (r *SparseMatrix) mapM(a_ Matrix, dense bool, f func(c, a Scalar)) Matrix {
+  n,  m  := r.Dims()
+  n1, m1 := a_.Dims()
+  if n1 != n || m1 != m {
+    panic("matrix dimensions do not match!")
+  }
+  if dense {
+    values := NilVector(n*m)
+    index  := make([]int, n*m)
+    offset := make([]int, n+1)
+    for i := 0; i < n; i++ {
+      for j := 0; j < m; j++ {
+        values[i*m+j] = NullScalar(r.elementType)
+        index [i*m+j] = j
+        f(values[i*m+j], a_.ConstAt(i, j))
+      }
+      offset[i+1] = (i+1)*m
+    }
+    r.assign(values, index, offset)
+    return r
+  }
+  a := asSparseMatrix(a_)
+  values := NilVector(len(a.Values))
+  for k := 0; k < len(a.Values); k++ {
+    values[k] = NullScalar(r.elementType)
+    f(values[k], a.Values[k])
+  }
+  r.assign(values, append([]int{}, a.Index...), append([]int{}, a.Offset...))
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Element-wise addition of two matrices. The result is stored in r.
+func (r *SparseMatrix) MaddM(a, b Matrix) Matrix {
+  return r.mergeM(a, b, false, false, func(c, a, b Scalar) { c.Add(a, b) })
+}
+
+// Add scalar b to all elements of a. The result is stored in r and has
+// no structural zeros.
+func (r *SparseMatrix) MaddS(a Matrix, b Scalar) Matrix {
+  return r.mapM(a, true, func(c, a Scalar) { c.Add(a, b) })
+}
+
+// Element-wise substraction of two matrices. The result is stored in r.
+func (r *SparseMatrix) MsubM(a, b Matrix) Matrix {
+  return r.mergeM(a, b, false, false, func(c, a, b Scalar) { c.Sub(a, b) })
+}
+
+// Substract b from all elements of a. The result is stored in r and has
+// no structural zeros.
+func (r *SparseMatrix) MsubS(a Matrix, b Scalar) Matrix {
+  return r.mapM(a, true, func(c, a Scalar) { c.Sub(a, b) })
+}
+
+// Element-wise multiplication of two matrices. The result is stored in r
+// and contains only elements that are stored in both a and b.
+func (r *SparseMatrix) MmulM(a, b Matrix) Matrix {
+  return r.mergeM(a, b, true, true, func(c, a, b Scalar) { c.Mul(a, b) })
+}
+
+// Multiply all elements of a with b. The result is stored in r.
+func (r *SparseMatrix) MmulS(a Matrix, b Scalar) Matrix {
+  return r.mapM(a, false, func(c, a Scalar) { c.Mul(a, b) })
+}
+
+// Element-wise division of two matrices. The result is stored in r and
+// contains only elements that are stored in a.
+func (r *SparseMatrix) MdivM(a, b Matrix) Matrix {
+  return r.mergeM(a, b, true, false, func(c, a, b Scalar) { c.Div(a, b) })
+}
+
+// Divide all elements of a by b. The result is stored in r.
+func (r *SparseMatrix) MdivS(a Matrix, b Scalar) Matrix {
+  return r.mapM(a, false, func(c, a Scalar) { c.Div(a, b) })
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Matrix product of a and b. The result is stored in r. Only products of
+// stored elements are computed, i.e. the cost is proportional to the
+// number of non-trivial multiplications.
+func (r *SparseMatrix) MdotM(a_, b_ Matrix) Matrix {
+  n,  m  := r.Dims()
+  n1, m1 := a_.Dims()
+  n2, m2 := b_.Dims()
+  if n1 != n || m2 != m || m1 != n2 {
+    panic("matrix dimensions do not match!")
+  }
+  a := asSparseMatrix(a_)
+  b := asSparseMatrix(b_)
+  t := NullScalar(r.elementType)
+  // accumulator for a single row
+  acc  := NullVector(r.elementType, m)
+  mark := make([]int, m)
+  cols := make([]int, 0, m)
+  for j := 0; j < m; j++ {
+    mark[j] = -1
+  }
+  values := Vector{}
+  index  := []int{}
+  offset := make([]int, n+1)
+  for i := 0; i < n; i++ {
+    cols = cols[0:0]
+    for k1 := a.Offset[i]; k1 < a.Offset[i+1]; k1++ {
+      k := a.Index[k1]
+      for k2 := b.Offset[k]; k2 < b.Offset[k+1]; k2++ {
+        j := b.Index[k2]
+        if mark[j] != i {
+          mark[j] = i
+          acc[j].Mul(a.Values[k1], b.Values[k2])
+          cols = append(cols, j)
+        } else {
+          t.Mul(a.Values[k1], b.Values[k2])
+          acc[j].Add(acc[j], t)
+        }
+      }
+    }
+    sort.Ints(cols)
+    for _, j := range cols {
+      values = append(values, acc[j].Clone())
+      index  = append(index, j)
+    }
+    offset[i+1] = len(index)
+  }
+  r.assign(values, index, offset)
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Outer product of two vectors. The result is stored in r and contains
+// only products of non-zero elements.
+func (r *SparseMatrix) Outer(a, b Vector) Matrix {
+  n, m := r.Dims()
+  if len(a) != n || len(b) != m {
+    panic("matrix/vector dimensions do not match!")
+  }
+  values := Vector{}
+  index  := []int{}
+  offset := make([]int, n+1)
+  for i := 0; i < n; i++ {
+    if !isStructuralZero(a[i]) {
+      for j := 0; j < m; j++ {
+        if !isStructuralZero(b[j]) {
+          c := NullScalar(r.elementType)
+          c.Mul(a[i], b[j])
+          values = append(values, c)
+          index  = append(index,  j)
+        }
+      }
+    }
+    offset[i+1] = len(index)
+  }
+  r.assign(values, index, offset)
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Jacobian of f at x_. The result is stored in r. The sparsity
+// pattern is detected with DetectJacobianSparsity and the Jacobian is
+// computed as in SparseJacobian, i.e. elements carry no derivatives.
+func (r *SparseMatrix) Jacobian(f func(Vector) Vector, x_ Vector) Matrix {
+  n, m := r.Dims()
+  p := DetectJacobianSparsity(f, x_)
+  if len(x_) != m || len(p) != n {
+    panic("matrix/vector dimensions do not match")
+  }
+  s := newSparseMatrixFromPattern(r.elementType, n, m, p)
+  r.assign(s.Values, s.Index, s.Offset)
+  return sparseJacobian(r, f, x_, p)
+}
+
+// Compute the Hessian of f at x_. The result is stored in r. The sparsity
+// pattern is detected with DetectHessianSparsity and the Hessian is
+// computed as in SparseHessian, i.e. elements carry no derivatives.
+func (r *SparseMatrix) Hessian(f func(Vector) Scalar, x_ Vector) Matrix {
+  n, m := r.Dims()
+  if len(x_) != n || len(x_) != m {
+    panic("matrix/vector dimensions do not match")
+  }
+  p := DetectHessianSparsity(f, x_)
+  s := newSparseMatrixFromPattern(r.elementType, n, m, p)
+  r.assign(s.Values, s.Index, s.Offset)
+  return sparseHessian(r, f, x_, p)
+}
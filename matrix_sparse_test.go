@@ -0,0 +1,211 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestSparseMatrix(t *testing.T) {
+  s := NewSparseMatrix(RealType, 3, 4,
+    []int{2, 0, 1, 0, 2, 2},
+    []int{3, 0, 2, 3, 0, 3},
+    []float64{1, 2, 3, 4, 5, 6})
+  d := NewDenseMatrix(RealType, 3, 4, []float64{
+    2, 0, 0, 4,
+    0, 0, 3, 0,
+    5, 0, 0, 7})
+
+  if s.GetNnz() != 5 {
+    t.Error("Sparse matrix GetNnz() failed!")
+  }
+  if !Mequal(s, d) {
+    t.Error("Sparse matrix At() failed!")
+  }
+  if !Mequal(s.T(), d.T()) {
+    t.Error("Sparse matrix T() failed!")
+  }
+  if !Vequal(s.Row(2), d.Row(2)) || !Vequal(s.T().Row(3), d.T().Row(3)) {
+    t.Error("Sparse matrix Row() failed!")
+  }
+  if !Vequal(s.Col(3), d.Col(3)) || !Vequal(s.T().Col(2), d.T().Col(2)) {
+    t.Error("Sparse matrix Col() failed!")
+  }
+  if !Mequal(s.Submatrix(1, 2, 1, 3), d.Submatrix(1, 2, 1, 3)) {
+    t.Error("Sparse matrix Submatrix() failed!")
+  }
+  if !Mequal(s.T().Submatrix(0, 3, 1, 2), d.T().Submatrix(0, 3, 1, 2)) {
+    t.Error("Sparse matrix Submatrix() failed!")
+  }
+  // structural zeros are inserted by ReferenceAt
+  s.ReferenceAt(1, 1).SetValue(8)
+  d.ReferenceAt(1, 1).SetValue(8)
+  if s.GetNnz() != 6 || !Mequal(s, d) {
+    t.Error("Sparse matrix ReferenceAt() failed!")
+  }
+  s.Set(NewReal(9), 1, 3)
+  d.Set(NewReal(9), 1, 3)
+  if s.GetNnz() != 7 || !Mequal(s, d) {
+    t.Error("Sparse matrix Set() failed!")
+  }
+  s.PermuteRows([]int{2, 0, 1})
+  d.PermuteRows([]int{2, 0, 1})
+  if !Mequal(s, d) {
+    t.Error("Sparse matrix PermuteRows() failed!")
+  }
+  s.Reshape(4, 3)
+  d.Reshape(4, 3)
+  if !Mequal(s, d) {
+    t.Error("Sparse matrix Reshape() failed!")
+  }
+}
+
+func TestSparseMatrixMath(t *testing.T) {
+  s1 := NewSparseMatrix(RealType, 3, 4,
+    []int{2, 0, 1, 0, 2, 2},
+    []int{3, 0, 2, 3, 0, 3},
+    []float64{1, 2, 3, 4, 5, 6})
+  d1 := NewDenseMatrix(RealType, 3, 4, []float64{
+    2, 0, 0, 4,
+    0, 0, 3, 0,
+    5, 0, 0, 7})
+  s2 := NewSparseMatrix(RealType, 3, 4, []int{0, 1, 2}, []int{0, 1, 2}, []float64{1, 2, 3})
+  d2 := NewDenseMatrix(RealType, 3, 4, []float64{
+    1, 0, 0, 0,
+    0, 2, 0, 0,
+    0, 0, 3, 0})
+
+  if r, ok := MaddM(s1, s2).(*SparseMatrix); !ok || !Mequal(r, MaddM(d1, d2)) {
+    t.Error("Sparse matrix MaddM() failed!")
+  }
+  if r := MsubM(s1, d2); !Mequal(r, MsubM(d1, d2)) {
+    t.Error("Sparse matrix MsubM() failed!")
+  }
+  if r := MmulM(s1, s2); r.(*SparseMatrix).GetNnz() != 1 || !Mequal(r, MmulM(d1, d2)) {
+    t.Error("Sparse matrix MmulM() failed!")
+  }
+  if r := MmulS(s1, NewReal(2)); !Mequal(r, MmulS(d1, NewReal(2))) {
+    t.Error("Sparse matrix MmulS() failed!")
+  }
+  if r := MaddS(s1, NewReal(2)); !Mequal(r, MaddS(d1, NewReal(2))) {
+    t.Error("Sparse matrix MaddS() failed!")
+  }
+  // matrix products
+  if r, ok := MdotM(s1, s2.T()).(*SparseMatrix); !ok || !Mequal(r, MdotM(d1, d2.T())) {
+    t.Error("Sparse matrix MdotM() failed!")
+  }
+  if r, ok := MdotM(d1.T(), s2).(*DenseMatrix); !ok || !Mequal(r, MdotM(d1.T(), d2)) {
+    t.Error("Sparse matrix MdotM() failed!")
+  }
+  v := NewVector(RealType, []float64{1, 2, 3, 4})
+  w := NewVector(RealType, []float64{1, 2, 3})
+  if !Vequal(MdotV(s1, v), MdotV(d1, v)) || !Vequal(MdotV(s1.T(), w), MdotV(d1.T(), w)) {
+    t.Error("Sparse matrix MdotV() failed!")
+  }
+  if !Vequal(VdotM(w, s1), VdotM(w, d1)) || !Vequal(VdotM(v, s1.T()), VdotM(v, d1.T())) {
+    t.Error("Sparse matrix VdotM() failed!")
+  }
+  v.Set(NewReal(0), 1)
+  r := NullSparseMatrix(RealType, 3, 4)
+  if r.Outer(w, v); r.GetNnz() != 9 || !Mequal(r, Outer(w, v)) {
+    t.Error("Sparse matrix Outer() failed!")
+  }
+}
+
+func TestSparseMatrixDerivatives(t *testing.T) {
+  s := NewSparseMatrix(RealType, 3, 4,
+    []int{2, 0, 1, 0, 2, 2},
+    []int{3, 0, 2, 3, 0, 3},
+    []float64{1, 2, 3, 4, 5, 6})
+  x := NewVector(RealType, []float64{1, 2, 3, 4})
+  x.Variables(1)
+  // y = s x, hence dy/dx = s
+  y := MdotV(s, x)
+  for i := 0; i < 3; i++ {
+    for j := 0; j < 4; j++ {
+      if y[i].GetDerivative(1, j) != s.At(i, j).GetValue() {
+        t.Error("Sparse matrix derivatives failed!")
+      }
+    }
+  }
+  // derivatives with respect to matrix elements
+  s.Variables(1)
+  z := MdotM(s, NewDenseMatrix(RealType, 4, 3, []float64{1, 0, 0, 2, 0, 0, 3, 0, 0, 4, 0, 0}))
+  if z.At(2, 0).GetDerivative(1, 3) != 1 || z.At(2, 0).GetDerivative(1, 4) != 4 {
+    t.Error("Sparse matrix derivatives failed!")
+  }
+  // sparse Jacobian
+  f := func(x Vector) Vector {
+    r := NullVector(x.ElementType(), 3)
+    r[0].Mul(x[0], x[1])
+    r[1].Exp(x[2])
+    r[2].Mul(x[0], x[0])
+    return r
+  }
+  x = NewVector(RealType, []float64{1, 2, 3})
+  j := NullSparseMatrix(RealType, 3, 3)
+  j.Jacobian(f, x)
+  if j.GetNnz() != 4 || !Mequal(j, Jacobian(f, x)) {
+    t.Error("Sparse matrix Jacobian() failed!")
+  }
+}
+
+func TestSparseMatrixSharing(t *testing.T) {
+
+  a := NewSparseMatrix(RealType, 2, 3, []int{0}, []int{0}, []float64{1})
+  b := a.T()
+  c := a.CloneShallow()
+  // insertions write through to all matrices that share data
+  b.ReferenceAt(2, 1).SetValue(7)
+  if a.At(1, 2).GetValue() != 7 || c.At(1, 2).GetValue() != 7 {
+    t.Error("Sparse matrix sharing failed!")
+  }
+  a.Set(NewReal(3), 1, 0)
+  if b.At(0, 1).GetValue() != 3 || b.(*SparseMatrix).GetNnz() != 3 {
+    t.Error("Sparse matrix sharing failed!")
+  }
+  // results of operations are written through to the transposed matrix
+  b.MmulS(b, NewReal(2))
+  if a.At(1, 2).GetValue() != 14 || a.At(0, 0).GetValue() != 2 {
+    t.Error("Sparse matrix sharing failed!")
+  }
+  b.SetIdentity()
+  if a.At(1, 2).GetValue() != 0 || a.At(1, 1).GetValue() != 1 {
+    t.Error("Sparse matrix sharing failed!")
+  }
+}
+
+func TestSparseMatrixInterface(t *testing.T) {
+
+  s := NullSparseMatrix(RealType, 2, 2)
+  d := NullDenseMatrix(RealType, 2, 2)
+  // empty sparse matrices
+  if Mnorm(s).GetValue() != 0 || !Mequal(s, d) {
+    t.Error("Sparse matrix interface failed!")
+  }
+  s.Set(NewReal(2), 0, 1)
+  d.Copy(s.T())
+  if d.At(1, 0).GetValue() != 2 || Mequal(s, d) || !Mequal(s.T(), d) {
+    t.Error("Sparse matrix interface failed!")
+  }
+  if Mnorm(s.T()).GetValue() != 4 || Mnorm(d).GetValue() != 4 {
+    t.Error("Sparse matrix interface failed!")
+  }
+}
@@ -0,0 +1,226 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* -------------------------------------------------------------------------- */
+
+// A sparsity pattern contains for each row the column indices of
+// structurally non-zero elements in ascending order.
+type SparsityPattern [][]int
+
+// Returns the union of two sparsity patterns with the same number of rows.
+func (p SparsityPattern) Union(q SparsityPattern) SparsityPattern {
+  if len(p) != len(q) {
+    panic("Union(): sparsity patterns have different numbers of rows!")
+  }
+  r := make(SparsityPattern, len(p))
+  for i := 0; i < len(p); i++ {
+    r[i] = mergeIndices(p[i], q[i])
+  }
+  return r
+}
+
+// Merge two sorted lists of indices.
+func mergeIndices(a, b []int) []int {
+  r := make([]int, 0, len(a)+len(b))
+  i, j := 0, 0
+  for i < len(a) || j < len(b) {
+    switch {
+    case j == len(b) || (i < len(a) && a[i] < b[j]):
+      r = append(r, a[i]); i++
+    case i == len(a) || b[j] < a[i]:
+      r = append(r, b[j]); j++
+    default:
+      r = append(r, a[i]); i++; j++
+    }
+  }
+  return r
+}
+
+// Assign colors to the n columns of a matrix with the given sparsity
+// pattern, such that columns that share a row have different colors
+// (greedy distance-2 coloring). Returns the colors and the number of colors.
+func colorColumns(p SparsityPattern, n int) ([]int, int) {
+  cols := make([][]int, n)
+  for i, row := range p {
+    for _, j := range row {
+      cols[j] = append(cols[j], i)
+    }
+  }
+  color     := make([]int, n)
+  forbidden := make([]int, n+1)
+  for j := 0; j < n; j++ {
+    color    [j] = -1
+    forbidden[j] = -1
+  }
+  m := 0
+  for j := 0; j < n; j++ {
+    for _, i := range cols[j] {
+      for _, k := range p[i] {
+        if c := color[k]; c >= 0 {
+          forbidden[c] = j
+        }
+      }
+    }
+    c := 0
+    for c < m && forbidden[c] == j {
+      c++
+    }
+    color[j] = c
+    if c == m {
+      m++
+    }
+  }
+  return color, m
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Detect the sparsity pattern of the Jacobian of f at x_. The function f is
+// evaluated once on SparseReal scalars, hence scalars within f should be
+// created with the element type of its argument. The pattern is only valid
+// at x_ if the structure of f depends on its argument, e.g. through branches
+// or Max, in which case patterns detected at several points must be combined
+// with Union.
+func DetectJacobianSparsity(f func(Vector) Vector, x_ Vector) SparsityPattern {
+  x := NullVector(SparseRealType, len(x_))
+  for i := 0; i < len(x); i++ {
+    x[i].SetValue(x_[i].GetValue())
+  }
+  x.VariablesAt(0, 1)
+  y := f(x)
+  p := make(SparsityPattern, len(y))
+  for i := 0; i < len(y); i++ {
+    index, _ := sparseDerivativesOf(y[i])
+    p[i] = append([]int{}, index...)
+  }
+  return p
+}
+
+// Detect the sparsity pattern of the Hessian of f at x_. The function f is
+// recorded as Graph. As for DetectJacobianSparsity, the pattern is only
+// valid at x_ if the structure of f depends on its argument.
+func DetectHessianSparsity(f func(Vector) Scalar, x_ Vector) SparsityPattern {
+  g, err := NewGraph(func(x Vector) (Scalar, error) { return f(x), nil }, x_)
+  if err != nil {
+    panic(err)
+  }
+  return g.hessianSparsity()
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Jacobian of f at x_ for a given sparsity pattern. If the
+// pattern is nil, it is detected with DetectJacobianSparsity. Columns that
+// do not share a row are compressed into a single derivative slot, so that
+// the number of derivatives propagated through f equals the number of
+// colors. The result is a sparse matrix with elements at all positions of
+// the pattern. As in Jacobian, slots are assigned in a context nested within
+// all slots carried by x_ and elements of the result carry derivatives with
+// respect to them.
+func SparseJacobian(f func(Vector) Vector, x_ Vector, p SparsityPattern) *SparseMatrix {
+  if p == nil {
+    p = DetectJacobianSparsity(f, x_)
+  }
+  r := newSparseMatrixFromPattern(x_.ElementType(), len(p), len(x_), p)
+  sparseJacobian(r, f, x_, p)
+  return r
+}
+
+func sparseJacobian(r Matrix, f func(Vector) Vector, x_ Vector, p SparsityPattern) Matrix {
+  n := len(x_)
+  color, m := colorColumns(p, n)
+  x := x_.Clone()
+  c := DiffContext{}.nestedWithin(m, x_)
+  k := c.Offset
+  for i := 0; i < n; i++ {
+    setNestedVariable(x[i], k+color[i], k+m, k, c.Order())
+  }
+  y := f(x)
+  if len(y) != len(p) {
+    panic("vector dimensions do not match")
+  }
+  for i, row := range p {
+    for _, j := range row {
+      copyNestedDerivative(r.ReferenceAt(i, j), y[i], color[j], k)
+    }
+  }
+  return r
+}
+
+// Compute the Hessian of f at x_ for a given symmetric sparsity pattern. If
+// the pattern is nil, it is detected with DetectHessianSparsity. The
+// function f is recorded as Graph and each group of columns is obtained by
+// a single Hessian-vector product. The result is a sparse matrix with
+// elements at all positions of the pattern, which carry no derivatives.
+func SparseHessian(f func(Vector) Scalar, x_ Vector, p SparsityPattern) *SparseMatrix {
+  n := len(x_)
+  g, err := NewGraph(func(x Vector) (Scalar, error) { return f(x), nil }, x_)
+  if err != nil {
+    panic(err)
+  }
+  if p == nil {
+    p = g.hessianSparsity()
+  }
+  r := newSparseMatrixFromPattern(x_.ElementType(), n, n, p)
+  sparseHessianGraph(r, g, x_, p)
+  return r
+}
+
+func sparseHessian(r Matrix, f func(Vector) Scalar, x_ Vector, p SparsityPattern) Matrix {
+  g, err := NewGraph(func(x Vector) (Scalar, error) { return f(x), nil }, x_)
+  if err != nil {
+    panic(err)
+  }
+  if p == nil {
+    p = g.hessianSparsity()
+  }
+  return sparseHessianGraph(r, g, x_, p)
+}
+
+func sparseHessianGraph(r Matrix, g *Graph, x_ Vector, p SparsityPattern) Matrix {
+  n := len(x_)
+  x := x_.Slice()
+  if len(p) != n {
+    panic("matrix/vector dimensions do not match")
+  }
+  color, m := colorColumns(p, n)
+  d  := make([]float64, n)
+  hv := make([]float64, n)
+  for c := 0; c < m; c++ {
+    for j := 0; j < n; j++ {
+      if color[j] == c {
+        d[j] = 1.0
+      } else {
+        d[j] = 0.0
+      }
+    }
+    g.Hvp(hv, x, d)
+    for i, row := range p {
+      for _, j := range row {
+        if color[j] == c {
+          r.ReferenceAt(i, j).SetValue(hv[i])
+        }
+      }
+    }
+  }
+  return r
+}
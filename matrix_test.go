@@ -24,6 +24,27 @@ import "testing"
 
 /* -------------------------------------------------------------------------- */
 
+// matrix type that wraps another matrix as it could be defined by other
+// packages, which cannot specify a storage format
+type testMatrix struct {
+  Matrix
+}
+
+func TestMatrixForeign(t *testing.T) {
+
+  a := testMatrix{NewSparseMatrix(RealType, 2, 2, []int{0, 1}, []int{1, 0}, []float64{1, 2})}
+  b := NewDenseMatrix(RealType, 2, 2, []float64{1, 2, 3, 4})
+
+  r1 := MaddM(a, b)
+  r2 := MdotM(a, b)
+  if _, ok := r1.(*DenseMatrix); !ok || !Mequal(r1, NewDenseMatrix(RealType, 2, 2, []float64{1, 3, 5, 4})) {
+    t.Error("Matrix test failed!")
+  }
+  if !Mequal(r2, NewDenseMatrix(RealType, 2, 2, []float64{3, 4, 2, 4})) {
+    t.Error("Matrix test failed!")
+  }
+}
+
 func TestMatrix(t *testing.T) {
 
   m1 := NewDenseMatrix(RealType, 2, 3, []float64{1,2,3,4,5,6})
@@ -100,6 +121,43 @@ func TestSubmatrix(t *testing.T) {
   }
 }
 
+func TestMatrixView(t *testing.T) {
+
+  a := NewDenseMatrix(RealType, 3, 4, []float64{1,2,3,4,5,6,7,8,9,10,11,12})
+  b := a.Clone()
+  v := a.SubmatrixView(1,2,1,3)
+
+  if !Mequal(v, a.Submatrix(1,2,1,3)) || !Mequal(v.T(), a.Submatrix(1,2,1,3).T()) {
+    t.Error("SubmatrixView failed!")
+  }
+  // in-place operations write through
+  v.MaddM(v, NewDenseMatrix(RealType, 2, 3, []float64{1}))
+  v.RowView(1).MmulS(v.RowView(1), NewReal(2))
+  a.ColView(0).Set(NewReal(0), 2, 0)
+  r := NewDenseMatrix(RealType, 3, 4, []float64{1,2,3,4,5,7,8,9,0,22,24,26})
+  if !Mequal(a, r) {
+    t.Error("SubmatrixView failed!")
+  }
+  // views of the transpose
+  w := a.T().(*DenseMatrix).SubmatrixView(0,3,1,2)
+  w.MsubM(w, b.T().Submatrix(0,3,1,2))
+  r = NewDenseMatrix(RealType, 3, 4, []float64{1,2,3,4,0,1,1,1,-9,12,13,14})
+  if !Mequal(a, r) {
+    t.Error("SubmatrixView failed!")
+  }
+  d := a.SubmatrixView(0,2,1,3).DiagView()
+  d.Copy(NewDenseMatrix(RealType, 3, 1, []float64{-1,-2,-3}))
+  if a.At(0,1).GetValue() != -1 || a.At(1,2).GetValue() != -2 || a.At(2,3).GetValue() != -3 {
+    t.Error("DiagView failed!")
+  }
+  // matrix product of overlapping views
+  c := NewDenseMatrix(RealType, 2, 2, []float64{1,2,3,4})
+  c.View().MdotM(c, c)
+  if !Mequal(c, NewDenseMatrix(RealType, 2, 2, []float64{7,10,15,22})) {
+    t.Error("MdotM failed!")
+  }
+}
+
 func TestMatrixTrace(t *testing.T) {
 
   m1 := NewDenseMatrix(RealType, 2, 2, []float64{1,2,3,4})
@@ -120,6 +178,18 @@ func TestMatrixDot(t *testing.T) {
   }
 }
 
+func TestMatrixDotRectangular(t *testing.T) {
+
+  m1 := NewDenseMatrix(RealType, 2, 3, []float64{1,2,3,4,5,6})
+  m2 := NewDenseMatrix(RealType, 3, 1, []float64{1,0,-1})
+  m3 := NullDenseMatrix(RealType, 2, 1)
+  m3.MdotM(m1, m2)
+
+  if m3.At(0,0).GetValue() != -2 || m3.At(1,0).GetValue() != -2 {
+    t.Error("Matrix multiplication failed!")
+  }
+}
+
 func TestMatrixMul(t *testing.T) {
 
   m1 := NewDenseMatrix(RealType, 2, 3, []float64{1,2,3,4,5,6})
@@ -211,6 +281,435 @@ func TestMatrixJacobian(t *testing.T) {
   }
 }
 
+func TestMatrixHessian(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // x1^2 x2 + sin(x1) x2^3
+    return Add(Mul(Pow(x[0], NewBareReal(2)), x[1]), Mul(Sin(x[0]), Pow(x[1], NewBareReal(3))))
+  }
+
+  v1 := NewVector(RealType, []float64{1,2})
+  m1 := Hessian(f, v1)
+  m2 := NewDenseMatrix(RealType, 2, 2, []float64{
+    4 - 8*math.Sin(1), 2 + 12*math.Cos(1),
+    2 + 12*math.Cos(1), 12*math.Sin(1)})
+
+  if Mnorm(MsubM(m1, m2)).GetValue() > 1e-8 {
+    t.Error("Hessian test failed!")
+  }
+}
+
+func TestMatrixNestedJacobian(t *testing.T) {
+
+  theta := NewReal(1.3)
+  Variables(1, theta)
+
+  // the second element does not depend on theta
+  x := NewVector(RealType, []float64{0.0, 0.5})
+  x[0].Mul(theta, theta)
+
+  f := func(x Vector) Vector {
+    // y = x0 x1 theta
+    return Vector{Mul(Mul(x[0], x[1]), theta)}
+  }
+  m := Jacobian(f, x)
+
+  // dy/dx0 = x1 theta, dy/dx1 = x0 theta = theta^3
+  if math.Abs(m.At(0, 0).GetValue() - 0.5*1.3) > 1e-12 ||
+     math.Abs(m.At(0, 1).GetValue() - math.Pow(1.3, 3)) > 1e-12 {
+    t.Error("Jacobian test failed!")
+  }
+  // derivatives with respect to theta
+  if math.Abs(m.At(0, 0).GetDerivative(1, 0) - 0.5) > 1e-12 ||
+     math.Abs(m.At(0, 1).GetDerivative(1, 0) - 3*math.Pow(1.3, 2)) > 1e-12 {
+    t.Error("Jacobian test failed!")
+  }
+}
+
+func TestMatrixNestedOptimization(t *testing.T) {
+
+  theta := NewReal(1.0)
+  Variables(1, theta)
+
+  // inner objective f(x) = (x - theta)^2 + x^2 with minimum at theta/2
+  f := func(x Vector) Vector {
+    return Vector{Add(Pow(Sub(x[0], theta), NewBareReal(2)), Pow(x[0], NewBareReal(2)))}
+  }
+  x := NewVector(RealType, []float64{0.0})
+  // f depends on theta, which the initial x does not carry
+  ctx := ContextOf(Vector{theta})
+  // unrolled gradient descent
+  for i := 0; i < 100; i++ {
+    g := ctx.Jacobian(f, x)
+    x[0] = Sub(x[0], Mul(NewReal(0.1), g.At(0, 0)))
+  }
+  if math.Abs(x[0].GetValue() - 0.5) > 1e-8 {
+    t.Error("nested optimization test failed!")
+  }
+  // gradient with respect to the hyperparameter
+  if math.Abs(x[0].GetDerivative(1, 0) - 0.5) > 1e-8 {
+    t.Error("nested optimization test failed!")
+  }
+}
+
+func TestMatrixNestedIndependent(t *testing.T) {
+
+  theta := NewReal(2.0)
+  Variables(1, theta)
+
+  f := func(x Vector) Vector {
+    return Vector{Mul(x[0], theta)}
+  }
+  // x does not depend on theta, hence the context of theta must be given
+  // explicitly
+  x := NewVector(RealType, []float64{3.0})
+  ctx := ContextOf(Vector{theta})
+
+  m := ctx.Jacobian(f, x)
+
+  if math.Abs(m.At(0, 0).GetValue() - 2.0) > 1e-12 ||
+     math.Abs(m.At(0, 0).GetDerivative(1, 0) - 1.0) > 1e-12 {
+    t.Error("nested Jacobian test failed!")
+  }
+  v := NewVector(RealType, []float64{1.0})
+
+  for _, r := range []Vector{ctx.Jvp(f, x, v), ctx.Vjp(f, x, v)} {
+    if r[0].GetN() != 1 {
+      t.Error("nested Jacobian test failed!")
+    }
+    if math.Abs(r[0].GetValue() - 2.0) > 1e-12 ||
+       math.Abs(r[0].GetDerivative(1, 0) - 1.0) > 1e-12 {
+      t.Error("nested Jacobian test failed!")
+    }
+  }
+}
+
+func TestMatrixNestedUnrelated(t *testing.T) {
+
+  // variables declared elsewhere do not cause nesting
+  z := NewVector(RealType, []float64{1.0, 2.0})
+  Variables(1, z...)
+
+  f := func(x Vector) Vector {
+    return Vector{Mul(x[0], x[1])}
+  }
+  x := NewVector(RealType, []float64{2.0, 3.0})
+  m := Jacobian(f, x)
+
+  if m.At(0, 0).GetOrder() != 0 {
+    t.Error("nested Jacobian test failed!")
+  }
+  if math.Abs(m.At(0, 0).GetValue() - 3.0) > 1e-12 ||
+     math.Abs(m.At(0, 1).GetValue() - 2.0) > 1e-12 {
+    t.Error("nested Jacobian test failed!")
+  }
+}
+
+func TestMatrixJvp(t *testing.T) {
+
+  f := func(x Vector) Vector {
+    y := NullVector(x.ElementType(), 3)
+    y[0] = Mul(Sin(x[0]), x[1])
+    y[1] = Div(Exp(x[2]), x[0])
+    y[2] = Mul(x[1], x[1])
+    return y
+  }
+  x := NewVector(RealType, []float64{0.3, 1.2, -0.5})
+  v := NewVector(RealType, []float64{1.0, -2.0, 0.5})
+  w := NewVector(RealType, []float64{0.7, 0.1, -1.0})
+
+  J := Jacobian(f, x)
+
+  if Vnorm(VsubV(Jvp(f, x, v), MdotV(J, v))).GetValue() > 1e-10 {
+    t.Error("Jvp test failed!")
+  }
+  if Vnorm(VsubV(Vjp(f, x, w), VdotM(w, J))).GetValue() > 1e-10 {
+    t.Error("Vjp test failed!")
+  }
+}
+
+func TestMatrixHvp(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // x1^2 x2 + sin(x1) x2^3 + x1 / x3
+    y := Add(Mul(Pow(x[0], NewBareReal(2)), x[1]), Mul(Sin(x[0]), Pow(x[1], NewBareReal(3))))
+    return Add(y, Div(x[0], x[2]))
+  }
+  x := NewVector(RealType, []float64{1.0, 2.0, 1.5})
+  v := NewVector(RealType, []float64{0.5, -1.0, 2.0})
+
+  if Vnorm(VsubV(Hvp(f, x, v), MdotV(Hessian(f, x), v))).GetValue() > 1e-10 {
+    t.Error("Hvp test failed!")
+  }
+}
+
+func TestMatrixHvpConstant(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // 2 x1^2 x2 computed by a Real, which is not recorded as Graph
+    r := NewReal(0.0)
+    return r.Mul(NewReal(2.0), Mul(Mul(x[0], x[0]), x[1]))
+  }
+  x := NewVector(RealType, []float64{1.5, -0.5})
+  v := NewVector(RealType, []float64{1.0,  2.0})
+
+  // H v = (4 x2 v1 + 4 x1 v2, 4 x1 v1)
+  r := Hvp(f, x, v)
+  if math.Abs(r[0].GetValue() - 10.0) > 1e-10 ||
+     math.Abs(r[1].GetValue() -  6.0) > 1e-10 {
+    t.Error("Hvp test failed!")
+  }
+}
+
+func TestMatrixHvpVariables(t *testing.T) {
+
+  f1 := func(x Vector) Scalar {
+    // x1^2 x2 + sin(x1) x2^3
+    return Add(Mul(Pow(x[0], NewBareReal(2)), x[1]), Mul(Sin(x[0]), Pow(x[1], NewBareReal(3))))
+  }
+  f2 := func(x Vector) Scalar {
+    // same function computed by a Real, which is not recorded as Graph
+    r := NewReal(0.0)
+    return r.Add(NewReal(0.0), f1(x))
+  }
+  x := NewVector(RealType, []float64{1.0, 2.0})
+  v := NewVector(RealType, []float64{0.5, -1.0})
+  // derivatives carried by x are ignored
+  Variables(1, x...)
+  f1(x)
+
+  r := MdotV(Hessian(f1, NewVector(RealType, x.Slice())), v)
+
+  for _, f := range []func(Vector) Scalar{f1, f2} {
+    if Vnorm(VsubV(Hvp(f, x, v), r)).GetValue() > 1e-10 {
+      t.Error("Hvp test failed!")
+    }
+  }
+}
+
+func TestMatrixHvpOuter(t *testing.T) {
+
+  theta := NewReal(2.0)
+  Variables(1, theta)
+
+  f := func(x Vector) Scalar {
+    return Mul(Mul(x[0], x[0]), theta)
+  }
+  x := NewVector(RealType, []float64{1.5})
+  v := NewVector(RealType, []float64{1.0})
+
+  // derivatives of H v with respect to theta are not available
+  defer func() {
+    if recover() == nil {
+      t.Error("Hvp test failed!")
+    }
+  }()
+  Hvp(f, x, v)
+}
+
+func TestMatrixNestedJvp(t *testing.T) {
+
+  theta := NewReal(1.3)
+  Variables(1, theta)
+
+  x := NewVector(RealType, []float64{0.0, 0.5})
+  x[0].Mul(theta, theta)
+  v := NewVector(RealType, []float64{1.0, 2.0})
+  w := NewVector(RealType, []float64{1.0})
+
+  f := func(x Vector) Vector {
+    // y = x0 x1 theta
+    return Vector{Mul(Mul(x[0], x[1]), theta)}
+  }
+  // J v = x1 theta + 2 x0 theta = 0.5 theta + 2 theta^3
+  r := Jvp(f, x, v)
+  if math.Abs(r[0].GetValue() - (0.5*1.3 + 2*math.Pow(1.3, 3))) > 1e-12 ||
+     math.Abs(r[0].GetDerivative(1, 0) - (0.5 + 6*math.Pow(1.3, 2))) > 1e-12 {
+    t.Error("Jvp test failed!")
+  }
+  // w^T J = (x1 theta, x0 theta)
+  s := Vjp(f, x, w)
+  if math.Abs(s[1].GetValue() - math.Pow(1.3, 3)) > 1e-12 ||
+     math.Abs(s[1].GetDerivative(1, 0) - 3*math.Pow(1.3, 2)) > 1e-12 {
+    t.Error("Vjp test failed!")
+  }
+}
+
+func TestMatrixSparseJacobian(t *testing.T) {
+
+  n := 10
+  // banded residuals y_i = x_i^2 x_{i+1} - sin(x_{i+2})
+  f := func(x Vector) Vector {
+    y := NullVector(x.ElementType(), n-2)
+    for i := 0; i < n-2; i++ {
+      y[i] = Sub(Mul(Mul(x[i], x[i]), x[i+1]), Sin(x[i+2]))
+    }
+    return y
+  }
+  x := NullVector(RealType, n)
+  for i := 0; i < n; i++ {
+    x[i].SetValue(0.1*float64(i+1))
+  }
+  p := DetectJacobianSparsity(f, x)
+  for i := 0; i < n-2; i++ {
+    if len(p[i]) != 3 || p[i][0] != i || p[i][2] != i+2 {
+      t.Error("Jacobian sparsity test failed!")
+    }
+  }
+  // three colors suffice for a band of width three
+  if _, m := colorColumns(p, n); m != 3 {
+    t.Error("Jacobian sparsity test failed!")
+  }
+  if Mnorm(MsubM(SparseJacobian(f, x, nil), Jacobian(f, x))).GetValue() > 1e-10 {
+    t.Error("Jacobian sparsity test failed!")
+  }
+  if SparseJacobian(f, x, p).GetNnz() != 3*(n-2) {
+    t.Error("Jacobian sparsity test failed!")
+  }
+}
+
+func TestMatrixSparseJacobianNested(t *testing.T) {
+
+  theta := NewReal(1.3)
+  Variables(1, theta)
+
+  x := NewVector(RealType, []float64{0.0, 0.5, 2.0})
+  x[0].Mul(theta, theta)
+
+  f := func(x Vector) Vector {
+    // y = (x0 x1 theta, sin(x2))
+    return Vector{Mul(Mul(x[0], x[1]), theta), Sin(x[2])}
+  }
+  m1 := SparseJacobian(f, x, nil)
+  m2 := Jacobian(f, x)
+
+  for i := 0; i < 2; i++ {
+    for j := 0; j < 3; j++ {
+      if math.Abs(m1.At(i, j).GetValue() - m2.At(i, j).GetValue()) > 1e-12 ||
+         math.Abs(m1.At(i, j).GetDerivative(1, 0) - m2.At(i, j).GetDerivative(1, 0)) > 1e-12 {
+        t.Error("Jacobian sparsity test failed!")
+      }
+    }
+  }
+}
+
+func TestMatrixSparsityUnion(t *testing.T) {
+
+  // the structure of f depends on its argument
+  f := func(x Vector) Vector {
+    if x[0].GetValue() > 0.0 {
+      return Vector{Mul(x[0], x[1]), x[1]}
+    }
+    return Vector{x[0], x[1]}
+  }
+  p1 := DetectJacobianSparsity(f, NewVector(RealType, []float64{-1.0, 1.0}))
+  p2 := DetectJacobianSparsity(f, NewVector(RealType, []float64{ 1.0, 1.0}))
+  p  := p1.Union(p2)
+
+  if len(p1[0]) != 1 || len(p[0]) != 2 || p[0][0] != 0 || p[0][1] != 1 || len(p[1]) != 1 {
+    t.Error("Jacobian sparsity test failed!")
+  }
+}
+
+func TestMatrixSparseHessian(t *testing.T) {
+
+  n := 10
+  // chain of interactions sum_i x_i^2 x_{i+1} + exp(x_i)
+  f := func(x Vector) Scalar {
+    y := NullScalar(x.ElementType())
+    for i := 0; i < n; i++ {
+      y = Add(y, Exp(x[i]))
+      if i < n-1 {
+        y = Add(y, Mul(Mul(x[i], x[i]), x[i+1]))
+      }
+    }
+    return y
+  }
+  x := NullVector(RealType, n)
+  for i := 0; i < n; i++ {
+    x[i].SetValue(0.1*float64(i+1))
+  }
+  p := DetectHessianSparsity(f, x)
+  for i := 0; i < n; i++ {
+    if len(p[i]) != iMin(i+2, n) - iMax(i-1, 0) {
+      t.Error("Hessian sparsity test failed!")
+    }
+  }
+  if Mnorm(MsubM(SparseHessian(f, x, nil), Hessian(f, x))).GetValue() > 1e-10 {
+    t.Error("Hessian sparsity test failed!")
+  }
+}
+
+func TestMatrixSparseHessianStructural(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    return Mul(Sin(x[0]), x[1])
+  }
+  // the second derivative of sin(x0) vanishes at x0 = 0
+  x := NewVector(RealType, []float64{0.0, 1.0})
+  p := DetectHessianSparsity(f, x)
+  if len(p) != 2 || len(p[0]) != 2 || len(p[1]) != 1 || p[1][0] != 0 {
+    t.Error("Hessian sparsity test failed!")
+  }
+}
+
+func TestMatrixDeltaMethod(t *testing.T) {
+
+  f := func(x Vector) Vector {
+    y := NullVector(x.ElementType(), 2)
+    y[0].Mul(x[0], x[1])
+    y[1].Mul(x[0], x[0])
+    y[1].Add(y[1], Mul(x[1], NewBareReal(2.0)))
+    return y
+  }
+  m := NewVector(RealType, []float64{1.0, 2.0})
+  s := NewDenseMatrix(RealType, 2, 2, []float64{0.1, 0.02, 0.02, 0.2})
+
+  // first order
+  y1 := NewVector(RealType, []float64{2.0, 5.0})
+  s1 := NewDenseMatrix(RealType, 2, 2, []float64{0.68, 0.92, 0.92, 1.36})
+  // second order, which is exact for quadratic functions
+  y2 := NewVector(RealType, []float64{2.02, 5.1})
+  s2 := NewDenseMatrix(RealType, 2, 2, []float64{0.7004, 0.924, 0.924, 1.38})
+
+  if y, r := DeltaMethod(f, m, s, 1); Vnorm(VsubV(y, y1)).GetValue() > 1e-10 || Mnorm(MsubM(r, s1)).GetValue() > 1e-10 {
+    t.Error("delta method test failed!")
+  }
+  if y, r := DeltaMethod(f, m, s, 2); Vnorm(VsubV(y, y2)).GetValue() > 1e-10 || Mnorm(MsubM(r, s2)).GetValue() > 1e-10 {
+    t.Error("delta method test failed!")
+  }
+  // f is evaluated once at order 2
+  k := 0
+  g := func(x Vector) Vector {
+    k++
+    return f(x)
+  }
+  if DeltaMethod(g, m, s, 2); k != 1 {
+    t.Error("delta method test failed!")
+  }
+}
+
+func TestMatrixTaylor(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // y = x0^2 x1 + sin(x1)
+    return Add(Mul(Mul(x[0], x[0]), x[1]), Sin(x[1]))
+  }
+  x := NewVector(RealType, []float64{1.5, 0.5})
+
+  v, g, h := TaylorMultivariate(f, x)
+
+  if math.Abs(v.GetValue() - f(x).GetValue()) > 1e-12 {
+    t.Error("Taylor test failed!")
+  }
+  if math.Abs(g[0].GetValue() - 1.5) > 1e-12 || math.Abs(g[1].GetValue() - 2.25 - math.Cos(0.5)) > 1e-12 {
+    t.Error("Taylor test failed!")
+  }
+  if Mnorm(MsubM(h, Hessian(f, x))).GetValue() > 1e-12 {
+    t.Error("Taylor test failed!")
+  }
+}
+
 func TestReadMatrix(t *testing.T) {
 
   m, err := ReadMatrix(RealType, "matrix_test.table")
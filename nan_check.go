@@ -0,0 +1,180 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import   "bytes"
+import   "fmt"
+import   "math"
+import   "runtime"
+import   "strings"
+import   "sync"
+
+/* -------------------------------------------------------------------------- */
+
+// A NaNError describes the first operation that produced a value or
+// derivative that is not finite from finite operands.
+type NaNError struct {
+  Operation  string
+  Operands   []float64
+  Value      float64
+  Derivative bool
+  Stack      string
+}
+
+func (err *NaNError) Error() string {
+  if err.Derivative {
+    return fmt.Sprintf("%s%v = %v has non-finite derivatives\n%s", err.Operation, err.Operands, err.Value, err.Stack)
+  } else {
+    return fmt.Sprintf("%s%v = %v\n%s", err.Operation, err.Operands, err.Value, err.Stack)
+  }
+}
+
+/* -------------------------------------------------------------------------- */
+
+var nanCheckEnabled bool
+
+var nanCheckState struct {
+  sync.Mutex
+  handler func(*NaNError)
+  err     *NaNError
+}
+
+// Enable checked arithmetic for Real and BareReal. Every operation
+// tests its result for NaN and Inf values and derivatives. The first
+// error is kept until retrieved with NaNCheckError. If handler is not
+// nil it is called for every offending operation. Checked mode should
+// be enabled or disabled only while no computations are running.
+func EnableNaNCheck(handler func(*NaNError)) {
+  nanCheckState.Lock()
+  defer nanCheckState.Unlock()
+  nanCheckState.handler = handler
+  nanCheckState.err     = nil
+  nanCheckEnabled       = true
+}
+
+func DisableNaNCheck() {
+  nanCheckState.Lock()
+  defer nanCheckState.Unlock()
+  nanCheckState.handler = nil
+  nanCheckEnabled       = false
+}
+
+// Return and reset the first error detected in checked mode.
+func NaNCheckError() error {
+  nanCheckState.Lock()
+  defer nanCheckState.Unlock()
+  if err := nanCheckState.err; err != nil {
+    nanCheckState.err = nil
+    return err
+  }
+  return nil
+}
+
+/* -------------------------------------------------------------------------- */
+
+func isFinite(v float64) bool {
+  return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+func derivativesFinite(a Scalar) bool {
+  if a.GetOrder() >= 1 {
+    for i := 0; i < a.GetN(); i++ {
+      if !isFinite(a.GetDerivative(1, i)) {
+        return false
+      }
+    }
+  }
+  if a.GetOrder() >= 2 {
+    for i := 0; i < a.GetN(); i++ {
+      for j := i; j < a.GetN(); j++ {
+        if !isFinite(a.GetHessian(i, j)) {
+          return false
+        }
+      }
+    }
+  }
+  return true
+}
+
+// Check the result of an operation on a and b before the value v0 is
+// assigned to c. Derivatives of c must already be set. Operations on
+// non-finite operands only propagate an earlier error and are ignored.
+func nanCheck(c Scalar, v0 float64, a, b Scalar) {
+  operands := []float64{}
+  for _, x := range []Scalar{a, b} {
+    if x == nil {
+      continue
+    }
+    if !isFinite(x.GetValue()) {
+      return
+    }
+    // derivatives of c are already overwritten
+    if x != c && !derivativesFinite(x) {
+      return
+    }
+    operands = append(operands, x.GetValue())
+  }
+  err := &NaNError{Operands: operands, Value: v0}
+  if isFinite(v0) {
+    if derivativesFinite(c) {
+      return
+    }
+    err.Derivative = true
+  }
+  err.Operation, err.Stack = nanCheckCaller()
+
+  nanCheckState.Lock()
+  if nanCheckState.err == nil {
+    nanCheckState.err = err
+  }
+  handler := nanCheckState.handler
+  nanCheckState.Unlock()
+
+  if handler != nil {
+    handler(err)
+  }
+}
+
+// Find the exported method that called nanCheck and return its name
+// together with the stack of its callers.
+func nanCheckCaller() (string, string) {
+  pc     := make([]uintptr, 64)
+  frames := runtime.CallersFrames(pc[0:runtime.Callers(3, pc)])
+  op     := ""
+  stack  := bytes.Buffer{}
+  for {
+    frame, more := frames.Next()
+    if op == "" {
+      name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+      if strings.HasPrefix(name, "autodiff.") {
+        name = strings.TrimPrefix(name, "autodiff.")
+        if m := name[strings.LastIndex(name, ".")+1:]; m != "" && m[0] >= 'A' && m[0] <= 'Z' {
+          op = name
+        }
+      }
+    }
+    if op != "" {
+      fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+    }
+    if !more {
+      break
+    }
+  }
+  return op, stack.String()
+}
@@ -0,0 +1,92 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import   "fmt"
+import   "strings"
+import   "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestNaNCheck1(t *testing.T) {
+  EnableNaNCheck(nil)
+  defer DisableNaNCheck()
+
+  x := NewReal(1.0)
+  Variables(1, x)
+
+  y := Sub(Mul(x, NewReal(2.0)), NewReal(2.0))
+  z := Log(y)
+  // propagated values must not be reported
+  Add(z, NewReal(1.0))
+
+  err, ok := NaNCheckError().(*NaNError)
+  if !ok {
+    t.Fatal("NaN check failed!")
+  }
+  if err.Operation != "(*Real).Log" || len(err.Operands) != 1 || err.Operands[0] != 0.0 || err.Derivative {
+    t.Error("NaN check failed!")
+  }
+  if !strings.Contains(err.Stack, "TestNaNCheck1") {
+    t.Error("NaN check failed!")
+  }
+  if NaNCheckError() != nil {
+    t.Error("NaN check failed!")
+  }
+}
+
+func TestNaNCheck2(t *testing.T) {
+  EnableNaNCheck(nil)
+  defer DisableNaNCheck()
+
+  x := NewReal(0.0)
+  Variables(1, x)
+  // finite value with infinite derivative
+  Sqrt(x)
+
+  err, ok := NaNCheckError().(*NaNError)
+  if !ok || !err.Derivative || err.Value != 0.0 {
+    t.Error("NaN check failed!")
+  }
+}
+
+func TestNaNCheck3(t *testing.T) {
+  n := 0
+  EnableNaNCheck(func(err *NaNError) {
+    if err.Operation != "(*BareReal).Div" || len(err.Operands) != 2 {
+      t.Error("NaN check failed!")
+    }
+    n++
+  })
+  defer DisableNaNCheck()
+
+  a := NewBareReal(0.0)
+  a.Div(a, NewBareReal(0.0))
+  a.Add(a, NewBareReal(1.0))
+
+  if n != 1 || NaNCheckError() == nil {
+    t.Error("NaN check failed!")
+  }
+  DisableNaNCheck()
+
+  a.Div(NewBareReal(0.0), NewBareReal(0.0))
+  if n != 1 || NaNCheckError() != nil {
+    t.Error("NaN check failed!")
+  }
+}
@@ -0,0 +1,271 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+
+/* -------------------------------------------------------------------------- */
+
+// A Probability stores its value on log scale, which prevents underflow
+// when multiplying many small numbers. Since intermediate results may be
+// negative (e.g. the log of a probability), the value is represented by
+// log|x| and a sign. Derivatives are stored on log scale as well, i.e. as
+// derivatives of log|x|, and converted to linear scale only when they are
+// read. If x is zero, derivatives are stored on linear scale.
+type Probability struct {
+  BasicState
+  Negative bool
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var ProbabilityType ScalarType = NewProbability(0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return NewProbability(value) }
+  RegisterScalar(ProbabilityType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a new probability constant or variable.
+func NewProbability(v float64) *Probability {
+  s := Probability{*NewBasicState(0.0), false}
+  s.SetValue(v)
+  return &s
+}
+
+func NullProbability() *Probability {
+  s := Probability{*NewBasicState(math.Inf(-1)), false}
+  return &s
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Probability) Clone() Scalar {
+  r := NullProbability()
+  r.Copy(a)
+  return r
+}
+
+func (a *Probability) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *Probability) String() string {
+  return fmt.Sprintf("%e", a.GetValue())
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Returns log|x| and the sign of the scalar a without leaving log scale
+// for probabilities.
+func logAbs(a Scalar) (float64, bool) {
+  if r, ok := a.(*Probability); ok {
+    return r.Value, r.Negative
+  }
+  v := a.GetValue()
+  return math.Log(math.Abs(v)), v < 0.0
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Returns the derivative of a in the representation of a Probability, i.e.
+// on log scale unless a is zero.
+func probabilityDerivative(a Scalar, i int) float64 {
+  if r, ok := a.(*Probability); ok {
+    return r.BasicState.GetDerivative(1, i)
+  }
+  v := a.GetValue()
+  d := a.GetDerivative(1, i)
+  if v == 0.0 || d == 0.0 {
+    return d
+  }
+  return d/v
+}
+
+func probabilityHessian(a Scalar, i, j int) float64 {
+  if r, ok := a.(*Probability); ok {
+    return r.BasicState.GetHessian(i, j)
+  }
+  v := a.GetValue()
+  h := a.GetHessian(i, j)
+  if v == 0.0 {
+    return h
+  }
+  return h/v - probabilityDerivative(a, i)*probabilityDerivative(a, j)
+}
+
+// Returns the factor x and an indicator q such that the derivatives on
+// linear scale are given by x*d and x*(h + q*d*d), where d and h are
+// the stored first and second derivatives.
+func (a *Probability) derivativeScale() (float64, float64) {
+  if math.IsInf(a.Value, -1) {
+    return 1.0, 0.0
+  }
+  return a.GetValue(), 1.0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *Probability) Copy(b Scalar) {
+  a.Value, a.Negative = logAbs(b)
+  a.Order = b.GetOrder()
+  a.Alloc(b.GetN())
+  for i := 0; i < a.N; i++ {
+    a.Derivative[i] = probabilityDerivative(b, i)
+  }
+  if a.Order >= 2 {
+    for i := 0; i < a.N; i++ {
+      for j := i; j < a.N; j++ {
+        a.BasicState.SetHessian(i, j, probabilityHessian(b, i, j))
+      }
+    }
+  }
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *Probability) GetValue() float64 {
+  if a.Negative {
+    return -math.Exp(a.Value)
+  }
+  return math.Exp(a.Value)
+}
+
+func (a *Probability) GetDerivative(i, j int) float64 {
+  if i == 2 {
+    return a.GetHessian(j, j)
+  }
+  x, _ := a.derivativeScale()
+  return x*a.BasicState.GetDerivative(i, j)
+}
+
+func (a *Probability) GetHessian(i, j int) float64 {
+  if a.Hessian == nil || i >= a.N || j >= a.N {
+    return 0.0
+  }
+  x, q := a.derivativeScale()
+  return x*(a.Hessian[i][j] + q*a.Derivative[i]*a.Derivative[j])
+}
+
+func (a *Probability) GetLogValue() float64 {
+  if a.Negative {
+    return math.NaN()
+  }
+  return a.Value
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *Probability) Reset() {
+  a.BasicState.Reset()
+  a.Value    = math.Inf(-1)
+  a.Negative = false
+}
+
+func (a *Probability) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Set only the value of the variable.
+func (a *Probability) SetValue(v float64) {
+  a.setLogAbs(logAbsValue(v))
+}
+
+// Set the value of the variable on log scale.
+func (a *Probability) SetLogValue(v float64) {
+  a.setLogAbs(v, false)
+}
+
+func (a *Probability) SetDerivative(i, j int, v float64) {
+  if i == 2 {
+    a.SetHessian(j, j, v)
+    return
+  }
+  x, _ := a.derivativeScale()
+  a.BasicState.SetDerivative(i, j, v/x)
+}
+
+// First derivatives must be set before second derivatives.
+func (a *Probability) SetHessian(i, j int, v float64) {
+  x, q := a.derivativeScale()
+  a.BasicState.SetHessian(i, j, v/x - q*a.Derivative[i]*a.Derivative[j])
+}
+
+func (a *Probability) SetVariable(i, n, order int) {
+  a.BasicState.SetVariable(i, n, order)
+  if order > 0 {
+    a.SetDerivative(1, i, 1.0)
+  }
+  if order > 1 {
+    a.SetHessian(i, i, 0.0)
+  }
+}
+
+// Changing the value also changes the scale of the stored derivatives,
+// which are therefore converted such that derivatives on linear scale
+// remain unchanged.
+func (a *Probability) setLogAbs(v float64, negative bool) {
+  negative = negative && !math.IsInf(v, -1)
+  if a.Order == 0 || a.N == 0 || (v == a.Value && negative == a.Negative) {
+    a.Value    = v
+    a.Negative = negative
+    return
+  }
+  d := make([]float64, a.N)
+  h := [][]float64(nil)
+  for i := 0; i < a.N; i++ {
+    d[i] = a.GetDerivative(1, i)
+  }
+  if a.Hessian != nil {
+    h = newHessian(a.N)
+    for i := 0; i < a.N; i++ {
+      for j := i; j < a.N; j++ {
+        h[i][j] = a.GetHessian(i, j)
+      }
+    }
+  }
+  a.Value    = v
+  a.Negative = negative
+  for i := 0; i < a.N; i++ {
+    a.SetDerivative(1, i, d[i])
+  }
+  for i := 0; i < len(h); i++ {
+    for j := i; j < a.N; j++ {
+      a.SetHessian(i, j, h[i][j])
+    }
+  }
+}
+
+// Set the value without converting derivatives, which must already be on
+// the scale of the new value.
+func (a *Probability) setLogAbsRaw(v float64, negative bool) {
+  a.Value    = v
+  a.Negative = negative && !math.IsInf(v, -1)
+}
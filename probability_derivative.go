@@ -0,0 +1,174 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+
+// Returns the scale of derivatives for a value given by log|x| and its sign,
+// see derivativeScale.
+func probabilityScale(lv float64, neg bool) (float64, bool, float64) {
+  if math.IsInf(lv, -1) {
+    return 0.0, false, 0.0
+  }
+  return lv, neg, 1.0
+}
+
+// Returns x/y for two scales given on log scale.
+func probabilityRatio(lx float64, nx bool, ly float64, ny bool) float64 {
+  if nx != ny {
+    return -math.Exp(lx - ly)
+  }
+  return math.Exp(lx - ly)
+}
+
+/* derivatives of monadic functions, the new value is given on log scale
+ * by lv0 and its sign
+ * -------------------------------------------------------------------------- */
+
+func (c *Probability) monadicLazy(a Scalar, lv0 float64, neg bool, f1, f2 func() float64) Scalar {
+  return c.monadicLogLazy(a, lv0, neg, f1, f2, nil)
+}
+
+// The optional function e returns the first and second derivatives of
+// log|f| with respect to log|a|, which are used if a and f(a) are nonzero.
+// Otherwise derivatives are computed from f1 and f2.
+func (c *Probability) monadicLogLazy(a Scalar, lv0 float64, neg bool, f1, f2 func() float64, e func() (float64, float64)) Scalar {
+  c.AllocForOne(a)
+  if c.Order >= 1 {
+    la, na := logAbs(a)
+    la, na, qa := probabilityScale(la, na)
+    lc, nc, qc := probabilityScale(lv0, neg)
+    e1, e2 := 0.0, 0.0
+    if e != nil && qa == 1.0 && qc == 1.0 {
+      e1, e2 = e()
+    } else {
+      r := probabilityRatio(la, na, lc, nc)
+      e1 = f1()*r
+      if c.Order >= 2 {
+        e2 = f2()*r*probabilityRatio(la, na, 0.0, false) + qa*e1 - qc*e1*e1
+      }
+    }
+    if c.Order >= 2 {
+      // compute second derivatives
+      for i := 0; i < c.GetN(); i++ {
+        for j := i; j < c.GetN(); j++ {
+          c.BasicState.SetHessian(i, j,
+            probabilityDerivative(a, i)*probabilityDerivative(a, j)*e2 +
+            probabilityHessian(a, i, j)*e1)
+        }
+      }
+    }
+    // compute first derivatives
+    for i := 0; i < c.GetN(); i++ {
+      c.Derivative[i] = probabilityDerivative(a, i)*e1
+    }
+  }
+  // compute new value
+  c.setLogAbsRaw(lv0, neg)
+  return c
+}
+
+/* derivatives of dyadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *Probability) dyadicLazy(a, b Scalar, lv0 float64, neg bool, f1 func() (float64, float64), f2 func() (float64, float64, float64)) Scalar {
+  return c.dyadicLogLazy(a, b, lv0, neg, f1, f2, nil)
+}
+
+// The optional function e returns the derivatives e10, e01, e11, e20, and
+// e02 of log|f| with respect to log|a| and log|b|, which are used if a, b,
+// and f(a, b) are nonzero. Otherwise derivatives are computed from f1 and
+// f2.
+func (c *Probability) dyadicLogLazy(a, b Scalar, lv0 float64, neg bool, f1 func() (float64, float64), f2 func() (float64, float64, float64), e func() (float64, float64, float64, float64, float64)) Scalar {
+  c.AllocForTwo(a, b)
+  if c.Order >= 1 {
+    la, na := logAbs(a)
+    lb, nb := logAbs(b)
+    la, na, qa := probabilityScale(la, na)
+    lb, nb, qb := probabilityScale(lb, nb)
+    lc, nc, qc := probabilityScale(lv0, neg)
+    e10, e01, e11, e20, e02 := 0.0, 0.0, 0.0, 0.0, 0.0
+    if e != nil && qa == 1.0 && qb == 1.0 && qc == 1.0 {
+      e10, e01, e11, e20, e02 = e()
+    } else {
+      ra := probabilityRatio(la, na, lc, nc)
+      rb := probabilityRatio(lb, nb, lc, nc)
+      v10, v01 := f1()
+      e10 = v10*ra
+      e01 = v01*rb
+      if c.Order >= 2 {
+        xa := probabilityRatio(la, na, 0.0, false)
+        xb := probabilityRatio(lb, nb, 0.0, false)
+        v11, v20, v02 := f2()
+        e11 = v11*ra*xb - qc*e10*e01
+        e20 = v20*ra*xa + qa*e10 - qc*e10*e10
+        e02 = v02*rb*xb + qb*e01 - qc*e01*e01
+      }
+    }
+    if c.Order >= 2 {
+      // compute second derivatives
+      for i := 0; i < c.GetN(); i++ {
+        for j := i; j < c.GetN(); j++ {
+          c.BasicState.SetHessian(i, j,
+            probabilityHessian(a, i, j)*e10 +
+            probabilityHessian(b, i, j)*e01 +
+            probabilityDerivative(a, i)*probabilityDerivative(a, j)*e20 +
+            probabilityDerivative(b, i)*probabilityDerivative(b, j)*e02 +
+           (probabilityDerivative(a, i)*probabilityDerivative(b, j) +
+            probabilityDerivative(a, j)*probabilityDerivative(b, i))*e11)
+        }
+      }
+    }
+    // compute first derivatives
+    for i := 0; i < c.GetN(); i++ {
+      c.Derivative[i] = probabilityDerivative(a, i)*e10 + probabilityDerivative(b, i)*e01
+    }
+  }
+  // compute new value
+  c.setLogAbsRaw(lv0, neg)
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *Probability) StopGradient(a Scalar) Scalar {
+  la, na := logAbs(a)
+  f1 := func() float64 { return 0.0 }
+  f2 := func() float64 { return 0.0 }
+  return c.monadicLazy(a, la, na, f1, f2)
+}
+
+/* user-defined functions
+ * -------------------------------------------------------------------------- */
+
+func (c *Probability) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  lv0, neg := logAbsValue(v0)
+  f1 := func() float64 { return v1 }
+  f2 := func() float64 { return v2 }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  lv0, neg := logAbsValue(v0)
+  f1 := func() (float64, float64) { return v10, v01 }
+  f2 := func() (float64, float64, float64) { return v11, v20, v02 }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
@@ -0,0 +1,635 @@
+/* Copyright (C) 2015 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+func logAbsValue(v float64) (float64, bool) {
+  return math.Log(math.Abs(v)), v < 0.0
+}
+
+// Add two numbers given as log|x| and sign.
+func logAddSigned(a float64, na bool, b float64, nb bool) (float64, bool) {
+  if na == nb {
+    return logAdd(a, b), na
+  }
+  if a < b {
+    a, na, b = b, nb, a
+  }
+  return logSub(a, b), na
+}
+
+// Compare a and b on log scale, returns -1, 0, or 1.
+func probabilityCompare(a, b Scalar) int {
+  la, na := logAbs(a)
+  lb, nb := logAbs(b)
+  sa := probabilitySign(la, na)
+  sb := probabilitySign(lb, nb)
+  if sa != sb {
+    return sign(float64(sa - sb))
+  }
+  if la < lb {
+    return -sa
+  }
+  if la > lb {
+    return  sa
+  }
+  return 0
+}
+
+func probabilitySign(v float64, negative bool) int {
+  if math.IsInf(v, -1) {
+    return 0
+  }
+  if negative {
+    return -1
+  }
+  return 1
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Two probabilities are equal if their values agree up to a relative
+// error of epsilon.
+func (a *Probability) Equals(b Scalar) bool {
+  epsilon := 1e-12
+  la, na := logAbs(a)
+  lb, nb := logAbs(b)
+  if math.IsInf(la, -1) && math.IsInf(lb, -1) {
+    return true
+  }
+  return na == nb && math.Abs(la - lb) < epsilon
+}
+
+func (a *Probability) Greater(b Scalar) bool {
+  return probabilityCompare(a, b) == 1
+}
+
+func (a *Probability) Smaller(b Scalar) bool {
+  return probabilityCompare(a, b) == -1
+}
+
+func (a *Probability) Min(b Scalar) Scalar {
+  switch {
+  case a.Smaller(b):
+    return a
+  case b.Smaller(a):
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+func (a *Probability) Max(b Scalar) Scalar {
+  switch {
+  case a.Greater(b):
+    return a
+  case b.Greater(a):
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+// The derivative of |a| at zero is set to zero.
+func (c *Probability) Abs(a Scalar) Scalar {
+  switch a.Sign() {
+  case -1:
+    return c.Neg(a)
+  case  1:
+    c.Copy(a)
+    return c
+  }
+  return c.StopGradient(a)
+}
+
+func (a *Probability) Sign() int {
+  return probabilitySign(a.Value, a.Negative)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Probability) Neg(a Scalar) Scalar {
+  la, na := logAbs(a)
+  f1 := func() float64 { return -1 }
+  f2 := func() float64 { return  0 }
+  return c.monadicLazy(a, la, !na, f1, f2)
+}
+
+func (c *Probability) Add(a, b Scalar) Scalar {
+  la, na := logAbs(a)
+  lb, nb := logAbs(b)
+  lv0, neg := logAddSigned(la, na, lb, nb)
+  f1 := func() (float64, float64) { return 1, 1 }
+  f2 := func() (float64, float64, float64) { return 0, 0, 0 }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Sub(a, b Scalar) Scalar {
+  la, na := logAbs(a)
+  lb, nb := logAbs(b)
+  lv0, neg := logAddSigned(la, na, lb, !nb)
+  f1 := func() (float64, float64) { return 1, -1 }
+  f2 := func() (float64, float64, float64) { return 0, 0, 0 }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Mul(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  la, na := logAbs(a)
+  lb, nb := logAbs(b)
+  f1 := func() (float64, float64) { return y, x }
+  f2 := func() (float64, float64, float64) { return 1, 0, 0 }
+  e  := func() (float64, float64, float64, float64, float64) {
+    return 1, 1, 0, 0, 0
+  }
+  return c.dyadicLogLazy(a, b, la+lb, na != nb, f1, f2, e)
+}
+
+func (c *Probability) Div(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  la, na := logAbs(a)
+  lb, nb := logAbs(b)
+  f1 := func() (float64, float64) {
+    return 1/y, -x/(y*y)
+  }
+  f2 := func() (float64, float64, float64) {
+    return -1/(y*y), 0, 2*x/(y*y*y)
+  }
+  e  := func() (float64, float64, float64, float64, float64) {
+    return 1, -1, 0, 0, 0
+  }
+  return c.dyadicLogLazy(a, b, la-lb, na != nb, f1, f2, e)
+}
+
+func (c *Probability) Pow(a, k Scalar) Scalar {
+  x := a.GetValue()
+  y := k.GetValue()
+  la, na := logAbs(a)
+  lv0, neg := 0.0, false
+  if y != 0.0 {
+    lv0 = y*la
+    if na {
+      if y == math.Floor(y) {
+        neg = math.Mod(y, 2.0) != 0.0
+      } else {
+        lv0 = math.NaN()
+      }
+    }
+  }
+  if k.GetOrder() >= 1 {
+    f1 := func() (float64, float64) {
+      f10 := math.Pow(x, y-1)*y
+      f01 := math.Pow(x, y-0)*math.Log(x)
+      return f10, f01
+    }
+    f2 := func() (float64, float64, float64) {
+      f11 := math.Pow(x, y-1)*(1 + y*math.Log(x))
+      f20 := math.Pow(x, y-2)*(y - 1)*y
+      f02 := math.Pow(x, y-0)*math.Log(x)*math.Log(x)
+      return f11, f20, f02
+    }
+    e  := func() (float64, float64, float64, float64, float64) {
+      return y, y*la, y, 0, y*la
+    }
+    return c.dyadicLogLazy(a, k, lv0, neg, f1, f2, e)
+  } else {
+    f1 := func() (float64) {
+      return math.Pow(x, y-1)*y
+    }
+    f2 := func() (float64) {
+      return math.Pow(x, y-2)*(y - 1)*y
+    }
+    e  := func() (float64, float64) {
+      return y, 0
+    }
+    return c.monadicLogLazy(a, lv0, neg, f1, f2, e)
+  }
+}
+
+func (c *Probability) Sqrt(a Scalar) Scalar {
+  return c.Pow(a, NewBareReal(1.0/2.0))
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *Probability) Sin(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Sin(x))
+  f1 := func() float64 { return  math.Cos(x) }
+  f2 := func() float64 { return -math.Sin(x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Sinh(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Sinh(x))
+  f1 := func() float64 { return  math.Cosh(x) }
+  f2 := func() float64 { return  math.Sinh(x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Cos(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Cos(x))
+  f1 := func() float64 { return -math.Sin(x) }
+  f2 := func() float64 { return -math.Cos(x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Cosh(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Cosh(x))
+  f1 := func() float64 { return  math.Sinh(x) }
+  f2 := func() float64 { return  math.Cosh(x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Tan(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Tan(x))
+  f1 := func() float64 { return  1.0+math.Pow(math.Tan(x), 2) }
+  f2 := func() float64 { return  2.0*math.Tan(x)*f1() }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Tanh(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Tanh(x))
+  f1 := func() float64 { return  1.0-math.Pow(math.Tanh(x), 2) }
+  f2 := func() float64 { return -2.0*math.Tanh(x)*f1() }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Asin(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Asin(x))
+  f1 := func() float64 { return 1.0/math.Sqrt(1.0-x*x) }
+  f2 := func() float64 { return x/math.Pow(1.0-x*x, 1.5) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Acos(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Acos(x))
+  f1 := func() float64 { return -1.0/math.Sqrt(1.0-x*x) }
+  f2 := func() float64 { return -x/math.Pow(1.0-x*x, 1.5) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Atan(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Atan(x))
+  f1 := func() float64 { return  1.0/(1.0+x*x) }
+  f2 := func() float64 { return -2.0*x/((1.0+x*x)*(1.0+x*x)) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Atan2(a, b Scalar) Scalar {
+  y := a.GetValue()
+  x := b.GetValue()
+  lv0, neg := logAbsValue(math.Atan2(y, x))
+  f1 := func() (float64, float64) {
+    r := x*x + y*y
+    return x/r, -y/r
+  }
+  f2 := func() (float64, float64, float64) {
+    r := (x*x + y*y)*(x*x + y*y)
+    return (y*y - x*x)/r, -2.0*x*y/r, 2.0*x*y/r
+  }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Asinh(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Asinh(x))
+  f1 := func() float64 { return  1.0/math.Sqrt(x*x+1.0) }
+  f2 := func() float64 { return -x/math.Pow(x*x+1.0, 1.5) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Acosh(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Acosh(x))
+  f1 := func() float64 { return  1.0/math.Sqrt(x*x-1.0) }
+  f2 := func() float64 { return -x/math.Pow(x*x-1.0, 1.5) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Atanh(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Atanh(x))
+  f1 := func() float64 { return 1.0/(1.0-x*x) }
+  f2 := func() float64 { return 2.0*x/((1.0-x*x)*(1.0-x*x)) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+// The result is stored on log scale, hence exp(x) does not underflow.
+func (c *Probability) Exp(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return math.Exp(x) }
+  f2 := func() float64 { return math.Exp(x) }
+  e  := func() (float64, float64) { return x, x }
+  return c.monadicLogLazy(a, x, false, f1, f2, e)
+}
+
+func (c *Probability) Log(a Scalar) Scalar {
+  x := a.GetValue()
+  la, na := logAbs(a)
+  if na {
+    la = math.NaN()
+  }
+  lv0, neg := logAbsValue(la)
+  f1 := func() float64 { return  1/x }
+  f2 := func() float64 { return -1/(x*x) }
+  e  := func() (float64, float64) { return 1/la, -1/(la*la) }
+  return c.monadicLogLazy(a, lv0, neg, f1, f2, e)
+}
+
+func (c *Probability) Log1p(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Log1p(x))
+  f1 := func() float64 { return  1/ (1+x) }
+  f2 := func() float64 { return -1/((1+x)*(1+x)) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Expm1(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Expm1(x))
+  f1 := func() float64 { return math.Exp(x) }
+  f2 := func() float64 { return math.Exp(x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Logistic(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := special.Logistic(x)
+  f1 := func() float64 { return v0*(1.0-v0) }
+  f2 := func() float64 { return v0*(1.0-v0)*(1.0-2.0*v0) }
+  e  := func() (float64, float64) {
+    h1 :=  special.Logistic(-x)
+    h2 := -special.Logistic( x)*h1
+    return x*h1, x*h1 + x*x*h2
+  }
+  // log(1/(1+exp(-x))) is computed directly to avoid underflow
+  return c.monadicLogLazy(a, special.LogLogistic(x), false, f1, f2, e)
+}
+
+func (c *Probability) Softplus(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(special.Softplus(x))
+  f1 := func() float64 { return special.Logistic(x) }
+  f2 := func() float64 {
+    s := special.Logistic(x)
+    return s*(1.0-s)
+  }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Erf(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(math.Erf(x))
+  f1 := func() float64 {
+    return  2.0/(math.Exp(x*x)*special.M_SQRTPI)
+  }
+  f2 := func() float64 {
+    return -4.0/(math.Exp(x*x)*special.M_SQRTPI)*x
+  }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Erfc(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 {
+    return -2.0/(math.Exp(x*x)*special.M_SQRTPI)
+  }
+  f2 := func() float64 {
+    return  4.0/(math.Exp(x*x)*special.M_SQRTPI)*x
+  }
+  e  := func() (float64, float64) {
+    h1 := -2.0/special.M_SQRTPI*math.Exp(-x*x - special.LogErfc(x))
+    h2 := -h1*(2.0*x + h1)
+    return x*h1, x*h1 + x*x*h2
+  }
+  return c.monadicLogLazy(a, special.LogErfc(x), false, f1, f2, e)
+}
+
+func (c *Probability) LogErfc(a Scalar) Scalar {
+  x := a.GetValue()
+  t := math.Erfc(x)
+  lv0, neg := logAbsValue(special.LogErfc(x))
+  f1 := func() float64 {
+    return -2.0/(math.Exp(x*x)*special.M_SQRTPI*t)
+  }
+  f2 := func() float64 {
+    return  4.0*(math.Exp(x*x)*special.M_SQRTPI*t*x - 1)/(math.Exp(2*x*x)*math.Pi*t*t)
+  }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Gamma(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Gamma(x)
+  lv0, s := math.Lgamma(x)
+  f1 := func() float64 {
+    v1 := special.Digamma(x)
+    return v0*v1
+  }
+  f2 := func() float64 {
+    v1 := special.Digamma(x)
+    v2 := special.Trigamma(x)
+    return v0*(v1*v1 + v2)
+  }
+  e  := func() (float64, float64) {
+    h1 := special.Digamma(x)
+    h2 := special.Trigamma(x)
+    return x*h1, x*h1 + x*x*h2
+  }
+  return c.monadicLogLazy(a, lv0, s == -1, f1, f2, e)
+}
+
+func (c *Probability) Lgamma(a Scalar) Scalar {
+  x := a.GetValue()
+  v0, s := math.Lgamma(x)
+  if s == -1 {
+    v0 = math.NaN()
+  }
+  lv0, neg := logAbsValue(v0)
+  f1 := func() float64 { return special.Digamma(x) }
+  f2 := func() float64 { return special.Trigamma(x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Mlgamma(a Scalar, k int) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(special.Mlgamma(x, k))
+  f1 := func() float64 {
+    s := 0.0
+    for j := 1; j <= k; j++ {
+      s += special.Digamma(x + float64(1-j)/2.0)
+    }
+    return s
+  }
+  f2 := func() float64 {
+    s := 0.0
+    for j := 1; j <= k; j++ {
+      s += special.Trigamma(x + float64(1-j)/2.0)
+    }
+    return s
+  }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) GammaP(a, b Scalar) Scalar {
+  lv0, neg := logAbsValue(special.GammaP(a.GetValue(), b.GetValue()))
+  f1 := func() (float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return v10, v01
+  }
+  f2 := func() (float64, float64, float64) {
+    _, _, v11, v20, v02 := gammaPDerivatives(a, b)
+    return v11, v20, v02
+  }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
+
+func (c *Probability) GammaQ(a, b Scalar) Scalar {
+  lv0, neg := logAbsValue(special.GammaQ(a.GetValue(), b.GetValue()))
+  f1 := func() (float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return -v10, -v01
+  }
+  f2 := func() (float64, float64, float64) {
+    _, _, v11, v20, v02 := gammaPDerivatives(a, b)
+    return -v11, -v20, -v02
+  }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Digamma(a Scalar) Scalar {
+  return c.Polygamma(0, a)
+}
+
+func (c *Probability) Trigamma(a Scalar) Scalar {
+  return c.Polygamma(1, a)
+}
+
+func (c *Probability) Polygamma(n int, a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(special.Polygamma(n, x))
+  f1 := func() float64 { return special.Polygamma(n+1, x) }
+  f2 := func() float64 { return special.Polygamma(n+2, x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Beta(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  // the log beta function is used directly to avoid underflow
+  lv0, s := special.Lbeta(x, y)
+  v0 := float64(s)*math.Exp(lv0)
+  f1 := func() (float64, float64) {
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v0*v10, v0*v01
+  }
+  f2 := func() (float64, float64, float64) {
+    v10, v01, v11, v20, v02 := lbetaDerivatives(x, y)
+    return v0*(v10*v01 + v11), v0*(v10*v10 + v20), v0*(v01*v01 + v02)
+  }
+  e  := func() (float64, float64, float64, float64, float64) {
+    v10, v01, v11, v20, v02 := lbetaDerivatives(x, y)
+    return x*v10, y*v01, x*y*v11, x*v10 + x*x*v20, y*v01 + y*y*v02
+  }
+  return c.dyadicLogLazy(a, b, lv0, s == -1, f1, f2, e)
+}
+
+func (c *Probability) Lbeta(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  v0, s := special.Lbeta(x, y)
+  if s == -1 {
+    v0 = math.NaN()
+  }
+  lv0, neg := logAbsValue(v0)
+  f1 := func() (float64, float64) {
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v10, v01
+  }
+  f2 := func() (float64, float64, float64) {
+    _, _, v11, v20, v02 := lbetaDerivatives(x, y)
+    return v11, v20, v02
+  }
+  return c.dyadicLazy(a, b, lv0, neg, f1, f2)
+}
+
+func (c *Probability) Zeta(a Scalar) Scalar {
+  x := a.GetValue()
+  lv0, neg := logAbsValue(special.Zeta(x))
+  f1 := func() float64 { return special.ZetaDerivative(1, x) }
+  f2 := func() float64 { return special.ZetaDerivative(2, x) }
+  return c.monadicLazy(a, lv0, neg, f1, f2)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (r *Probability) VdotV(a, b Vector) Scalar {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r.Reset()
+  t := NullProbability()
+  for i := 0; i < len(a); i++ {
+    t.Mul(a[i], b[i])
+    r.Add(r, t)
+  }
+  return r
+}
+
+func (r *Probability) Vnorm(a Vector) Scalar {
+  r.Reset()
+  c := NewBareReal(2.0)
+  t := NullProbability()
+  for i := 0; i < len(a); i++ {
+    t.Pow(a[i], c)
+    r.Add(r, t)
+  }
+  r.Sqrt(r)
+  return r
+}
+
+func (r *Probability) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullProbability()
+  t := NullProbability()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
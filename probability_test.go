@@ -0,0 +1,134 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestProbability1(t *testing.T) {
+
+  // product of many small probabilities does not underflow
+  a := NewProbability(1e-100)
+  r := NewProbability(1.0)
+  for i := 0; i < 10; i++ {
+    r.Mul(r, a)
+  }
+  if math.Abs(r.GetLogValue() - 10*math.Log(1e-100)) > 1e-8 {
+    t.Error("Probability test failed!")
+  }
+  // r + r = 2r
+  s := Add(r, r)
+  if math.Abs(s.GetLogValue() - r.GetLogValue() - math.Log(2.0)) > 1e-10 {
+    t.Error("Probability test failed!")
+  }
+  // r - 2r = -r
+  s.Sub(r, s)
+  if s.Sign() != -1 || math.Abs(s.(*Probability).Value - r.GetLogValue()) > 1e-10 {
+    t.Error("Probability test failed!")
+  }
+  if !s.Smaller(r) || !r.Greater(NewProbability(0.0)) {
+    t.Error("Probability test failed!")
+  }
+}
+
+func TestProbability2(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // y = log(x0 x1 + x0^2) - exp(x1)/x0
+    y := Log(Add(Mul(x[0], x[1]), Pow(x[0], NewBareReal(2))))
+    y  = Sub(y, Div(Exp(x[1]), x[0]))
+    return y
+  }
+  x1 := NewVector(RealType,        []float64{0.3, 0.2})
+  x2 := NewVector(ProbabilityType, []float64{0.3, 0.2})
+
+  Variables(2, x1...)
+  Variables(2, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-10 {
+    t.Error("Probability test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    for j := 0; j < 2; j++ {
+      if math.Abs(y1.GetHessian(i, j) - y2.GetHessian(i, j)) > 1e-8 {
+        t.Error("Probability test failed!")
+      }
+    }
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("Probability test failed!")
+    }
+  }
+}
+
+func TestProbability3(t *testing.T) {
+
+  m := NewDenseMatrix(ProbabilityType, 2, 2, []float64{0.1, 0.2, 0.3, 0.4})
+  v := NewVector(ProbabilityType, []float64{0.5, 0.5})
+
+  r := MdotV(m, v)
+
+  if math.Abs(r[0].GetValue() - 0.15) > 1e-12 ||
+     math.Abs(r[1].GetValue() - 0.35) > 1e-12 {
+    t.Error("Probability test failed!")
+  }
+}
+
+func TestProbability4(t *testing.T) {
+
+  // derivatives are kept on log scale and do not underflow
+  x := NewProbability(-1000.0)
+  x.SetVariable(0, 1, 2)
+
+  r := Log(Exp(x))
+
+  if math.Abs(r.GetDerivative(1, 0) - 1.0) > 1e-10 {
+    t.Error("Probability test failed!")
+  }
+  if math.Abs(r.GetHessian(0, 0)) > 1e-10 {
+    t.Error("Probability test failed!")
+  }
+  // d/dx_i log(x_0 x_1) = 1/x_i
+  a := NewProbability(1e-200)
+  b := NewProbability(1e-200)
+  Variables(1, a, b)
+
+  s := Log(Mul(a, b))
+
+  for i := 0; i < 2; i++ {
+    if math.Abs(s.GetDerivative(1, i)/1e200 - 1.0) > 1e-10 {
+      t.Error("Probability test failed!")
+    }
+  }
+  // log(x) is zero at x = 1
+  y := NewProbability(1.0)
+  y.SetVariable(0, 1, 2)
+
+  z := Exp(Log(y))
+
+  if math.Abs(z.GetDerivative(1, 0) - 1.0) > 1e-10 ||
+     math.Abs(z.GetHessian(0, 0)) > 1e-10 {
+    t.Error("Probability test failed!")
+  }
+}
@@ -29,9 +29,11 @@ func (c *Real) monadic(a Scalar, v0, v1, v2 float64) Scalar {
     if c.Order >= 2 {
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v2 +
-            a.GetDerivative(2, i)*v1)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v2 +
+            a.GetHessian(i, j)*v1)
+        }
       }
     }
     // compute first derivatives
@@ -39,6 +41,9 @@ func (c *Real) monadic(a Scalar, v0, v1, v2 float64) Scalar {
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v1)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, nil)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -52,9 +57,11 @@ func (c *Real) monadicLazy(a Scalar, v0 float64, f1, f2 func () float64) Scalar
       v2 := f2()
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v2 +
-            a.GetDerivative(2, i)*v1)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v2 +
+            a.GetHessian(i, j)*v1)
+        }
       }
     }
     // compute first derivatives
@@ -62,6 +69,9 @@ func (c *Real) monadicLazy(a Scalar, v0 float64, f1, f2 func () float64) Scalar
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v1)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, nil)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -73,9 +83,11 @@ func (c *Real) realMonadic(a *Real, v0, v1, v2 float64) *Real {
     if c.Order >= 2 {
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v2 +
-            a.GetDerivative(2, i)*v1)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v2 +
+            a.GetHessian(i, j)*v1)
+        }
       }
     }
     // compute first derivatives
@@ -83,6 +95,9 @@ func (c *Real) realMonadic(a *Real, v0, v1, v2 float64) *Real {
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v1)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, nil)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -96,9 +111,11 @@ func (c *Real) realMonadicLazy(a *Real, v0 float64, f1, f2 func() float64) *Real
       v2 := f2()
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v2 +
-            a.GetDerivative(2, i)*v1)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v2 +
+            a.GetHessian(i, j)*v1)
+        }
       }
     }
     // compute first derivatives
@@ -106,6 +123,9 @@ func (c *Real) realMonadicLazy(a *Real, v0 float64, f1, f2 func() float64) *Real
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v1)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, nil)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -120,12 +140,15 @@ func (c *Real) dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) *Real {
     if c.Order >= 2 {
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(2, i)*v10 +
-            b.GetDerivative(2, i)*v01 +
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v20 +
-            b.GetDerivative(1, i)*b.GetDerivative(1, i)*v02 +
-            a.GetDerivative(1, i)*b.GetDerivative(1, i)*v11*2)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetHessian(i, j)*v10 +
+            b.GetHessian(i, j)*v01 +
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v20 +
+            b.GetDerivative(1, i)*b.GetDerivative(1, j)*v02 +
+           (a.GetDerivative(1, i)*b.GetDerivative(1, j) +
+            a.GetDerivative(1, j)*b.GetDerivative(1, i))*v11)
+        }
       }
     }
     // compute first derivatives
@@ -133,6 +156,9 @@ func (c *Real) dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) *Real {
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v10 + b.GetDerivative(1, i)*v01)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, b)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -146,12 +172,15 @@ func (c *Real) dyadicLazy(a, b Scalar, v0 float64, f1 func() (float64, float64),
       v11, v20, v02 := f2()
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(2, i)*v10 +
-            b.GetDerivative(2, i)*v01 +
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v20 +
-            b.GetDerivative(1, i)*b.GetDerivative(1, i)*v02 +
-            a.GetDerivative(1, i)*b.GetDerivative(1, i)*v11*2)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetHessian(i, j)*v10 +
+            b.GetHessian(i, j)*v01 +
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v20 +
+            b.GetDerivative(1, i)*b.GetDerivative(1, j)*v02 +
+           (a.GetDerivative(1, i)*b.GetDerivative(1, j) +
+            a.GetDerivative(1, j)*b.GetDerivative(1, i))*v11)
+        }
       }
     }
     // compute first derivatives
@@ -159,6 +188,9 @@ func (c *Real) dyadicLazy(a, b Scalar, v0 float64, f1 func() (float64, float64),
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v10 + b.GetDerivative(1, i)*v01)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, b)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -170,12 +202,15 @@ func (c *Real) realDyadic(a, b *Real, v0, v10, v01, v11, v20, v02 float64) *Real
     if c.Order >= 2 {
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(2, i)*v10 +
-            b.GetDerivative(2, i)*v01 +
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v20 +
-            b.GetDerivative(1, i)*b.GetDerivative(1, i)*v02 +
-            a.GetDerivative(1, i)*b.GetDerivative(1, i)*v11*2)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetHessian(i, j)*v10 +
+            b.GetHessian(i, j)*v01 +
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v20 +
+            b.GetDerivative(1, i)*b.GetDerivative(1, j)*v02 +
+           (a.GetDerivative(1, i)*b.GetDerivative(1, j) +
+            a.GetDerivative(1, j)*b.GetDerivative(1, i))*v11)
+        }
       }
     }
     // compute first derivatives
@@ -183,6 +218,9 @@ func (c *Real) realDyadic(a, b *Real, v0, v10, v01, v11, v20, v02 float64) *Real
       c.SetDerivative(1, i, a.GetDerivative(1, i)*v10 + b.GetDerivative(1, i)*v01)
     }
   }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, b)
+  }
   // compute new value
   c.SetValue(v0)
   return c
@@ -196,20 +234,48 @@ func (c *Real) realDyadicLazy(a, b Scalar, v0 float64, f1 func() (float64, float
       v11, v20, v02 := f2()
       // compute second derivatives
       for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(2, i,
-            a.GetDerivative(2, i)*v10 +
-            b.GetDerivative(2, i)*v01 +
-            a.GetDerivative(1, i)*a.GetDerivative(1, i)*v20 +
-            b.GetDerivative(1, i)*b.GetDerivative(1, i)*v02 +
-            a.GetDerivative(1, i)*b.GetDerivative(1, i)*v11*2)
-      }
-      // compute first derivatives
-      for i := 0; i < c.GetN(); i++ {
-        c.SetDerivative(1, i, a.GetDerivative(1, i)*v10 + b.GetDerivative(1, i)*v01)
+        for j := i; j < c.GetN(); j++ {
+          c.SetHessian(i, j,
+            a.GetHessian(i, j)*v10 +
+            b.GetHessian(i, j)*v01 +
+            a.GetDerivative(1, i)*a.GetDerivative(1, j)*v20 +
+            b.GetDerivative(1, i)*b.GetDerivative(1, j)*v02 +
+           (a.GetDerivative(1, i)*b.GetDerivative(1, j) +
+            a.GetDerivative(1, j)*b.GetDerivative(1, i))*v11)
+        }
       }
     }
+    // compute first derivatives
+    for i := 0; i < c.GetN(); i++ {
+      c.SetDerivative(1, i, a.GetDerivative(1, i)*v10 + b.GetDerivative(1, i)*v01)
+    }
+  }
+  if nanCheckEnabled {
+    nanCheck(c, v0, a, b)
   }
   // compute new value
   c.SetValue(v0)
   return c
 }
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *Real) StopGradient(a Scalar) Scalar {
+  return c.monadic(a, a.GetValue(), 0.0, 0.0)
+}
+
+/* user-defined functions
+ * -------------------------------------------------------------------------- */
+
+// Evaluate a user-defined function f at a, where v0 = f(a), v1 = f'(a), and
+// v2 = f''(a).
+func (c *Real) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  return c.monadic(a, v0, v1, v2)
+}
+
+// Evaluate a user-defined function f at (a, b), where v0 is the value and
+// vij the partial derivatives of order i in a and order j in b.
+func (c *Real) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  return c.dyadic(a, b, v0, v10, v01, v11, v20, v02)
+}
@@ -58,49 +58,59 @@ func (a *Real) RealSmaller(b *Real) bool {
 /* -------------------------------------------------------------------------- */
 
 func (a *Real) Min(b Scalar) Scalar {
-  if a.GetValue() < b.GetValue() {
+  switch {
+  case a.GetValue() < b.GetValue():
     return a
+  case a.GetValue() > b.GetValue():
+    return b
   }
-  return b
+  return scalarTie(a, b)
 }
 
 func (a *Real) RealMin(b *Real) Scalar {
-  if a.GetValue() < b.GetValue() {
+  switch {
+  case a.GetValue() < b.GetValue():
     return a
+  case a.GetValue() > b.GetValue():
+    return b
   }
-  return b
+  return scalarTie(a, b)
 }
 
 /* -------------------------------------------------------------------------- */
 
 func (a *Real) Max(b Scalar) Scalar {
-  if a.GetValue() > b.GetValue() {
+  switch {
+  case a.GetValue() > b.GetValue():
     return a
+  case a.GetValue() < b.GetValue():
+    return b
   }
-  return b
+  return scalarTie(a, b)
 }
 
 func (a *Real) RealMax(b *Real) Scalar {
-  if a.GetValue() > b.GetValue() {
+  switch {
+  case a.GetValue() > b.GetValue():
     return a
+  case a.GetValue() < b.GetValue():
+    return b
   }
-  return b
+  return scalarTie(a, b)
 }
 
 /* -------------------------------------------------------------------------- */
 
+// The derivative of |a| at zero is set to zero, which is the subgradient
+// of minimum norm.
 func (c *Real) Abs(a Scalar) Scalar {
-  if c.Sign() == -1 {
-    c.Neg(a)
-  }
-  return c
+  x := a.GetValue()
+  return c.monadic(a, math.Abs(x), float64(a.Sign()), 0)
 }
 
 func (c *Real) RealAbs(a *Real) Scalar {
-  if c.Sign() == -1 {
-    c.Neg(a)
-  }
-  return c
+  x := a.GetValue()
+  return c.realMonadic(a, math.Abs(x), float64(a.Sign()), 0)
 }
 
 /* -------------------------------------------------------------------------- */
@@ -311,6 +321,69 @@ func (c *Real) Tanh(a Scalar) Scalar {
   return c.monadicLazy(a, v0, f1, f2)
 }
 
+func (c *Real) Asin(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Asin(x)
+  f1 := func() float64 { return 1.0/math.Sqrt(1.0-x*x) }
+  f2 := func() float64 { return x/math.Pow(1.0-x*x, 1.5) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Acos(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Acos(x)
+  f1 := func() float64 { return -1.0/math.Sqrt(1.0-x*x) }
+  f2 := func() float64 { return -x/math.Pow(1.0-x*x, 1.5) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Atan(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Atan(x)
+  f1 := func() float64 { return  1.0/(1.0+x*x) }
+  f2 := func() float64 { return -2.0*x/((1.0+x*x)*(1.0+x*x)) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Atan2(a, b Scalar) Scalar {
+  y := a.GetValue()
+  x := b.GetValue()
+  v0 := math.Atan2(y, x)
+  f1 := func() (float64, float64) {
+    r := x*x + y*y
+    return x/r, -y/r
+  }
+  f2 := func() (float64, float64, float64) {
+    r := (x*x + y*y)*(x*x + y*y)
+    return (y*y - x*x)/r, -2.0*x*y/r, 2.0*x*y/r
+  }
+  return c.dyadicLazy(a, b, v0, f1, f2)
+}
+
+func (c *Real) Asinh(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Asinh(x)
+  f1 := func() float64 { return  1.0/math.Sqrt(x*x+1.0) }
+  f2 := func() float64 { return -x/math.Pow(x*x+1.0, 1.5) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Acosh(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Acosh(x)
+  f1 := func() float64 { return  1.0/math.Sqrt(x*x-1.0) }
+  f2 := func() float64 { return -x/math.Pow(x*x-1.0, 1.5) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Atanh(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Atanh(x)
+  f1 := func() float64 { return 1.0/(1.0-x*x) }
+  f2 := func() float64 { return 2.0*x/((1.0-x*x)*(1.0-x*x)) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
 func (c *Real) Exp(a Scalar) Scalar {
   x := a.GetValue()
   v0 := math.Exp(x)
@@ -335,6 +408,33 @@ func (c *Real) Log1p(a Scalar) Scalar {
   return c.monadicLazy(a, v0, f1, f2)
 }
 
+func (c *Real) Expm1(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Expm1(x)
+  f1 := func() float64 { return math.Exp(x) }
+  f2 := func() float64 { return math.Exp(x) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Logistic(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := special.Logistic(x)
+  f1 := func() float64 { return v0*(1.0-v0) }
+  f2 := func() float64 { return v0*(1.0-v0)*(1.0-2.0*v0) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+func (c *Real) Softplus(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := special.Softplus(x)
+  f1 := func() float64 { return special.Logistic(x) }
+  f2 := func() float64 {
+    s := special.Logistic(x)
+    return s*(1.0-s)
+  }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
 func (c *Real) Erf(a Scalar) Scalar {
   x := a.GetValue()
   v0 :=  math.Erf(x)
@@ -418,16 +518,119 @@ func (c *Real) Mlgamma(a Scalar, k int) Scalar {
   return c.monadicLazy(a, v0, f1, f2)
 }
 
-func (c *Real) GammaP(a float64, b Scalar) Scalar {
+// Partial derivatives of the regularized lower incomplete gamma function
+// P(a,x). Derivatives with respect to the shape a are only computed if a is
+// not constant.
+func gammaPDerivatives(a, b Scalar) (float64, float64, float64, float64, float64) {
+  s := a.GetValue()
   x := b.GetValue()
-  v0 := special.GammaP(a, x)
-  f1 := func() float64 {
-    return special.GammaPfirstDerivative(a, x)
+  v10, v11, v20 := 0.0, 0.0, 0.0
+  if a.GetOrder() >= 1 {
+    v10, v20 = special.GammaPshapeDerivatives(s, x)
+    v11 = special.GammaPmixedDerivative(s, x)
   }
-  f2 := func() float64 {
-    return special.GammaPsecondDerivative(a, x)
+  return v10, special.GammaPfirstDerivative(s, x), v11, v20, special.GammaPsecondDerivative(s, x)
+}
+
+// First partial derivatives of P(a,x), see gammaPDerivatives.
+func gammaPFirstDerivatives(a, b Scalar) (float64, float64) {
+  s := a.GetValue()
+  x := b.GetValue()
+  v10 := 0.0
+  if a.GetOrder() >= 1 {
+    v10, _ = special.GammaPshapeDerivatives(s, x)
+  }
+  return v10, special.GammaPfirstDerivative(s, x)
+}
+
+func (c *Real) GammaP(a, b Scalar) Scalar {
+  v0 := special.GammaP(a.GetValue(), b.GetValue())
+  f1 := func() (float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return v10, v01
+  }
+  f2 := func() (float64, float64, float64) {
+    _, _, v11, v20, v02 := gammaPDerivatives(a, b)
+    return v11, v20, v02
+  }
+  return c.dyadicLazy(a, b, v0, f1, f2)
+}
+
+func (c *Real) GammaQ(a, b Scalar) Scalar {
+  v0 := special.GammaQ(a.GetValue(), b.GetValue())
+  f1 := func() (float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return -v10, -v01
+  }
+  f2 := func() (float64, float64, float64) {
+    _, _, v11, v20, v02 := gammaPDerivatives(a, b)
+    return -v11, -v20, -v02
   }
-  return c.monadicLazy(b, v0, f1, f2)
+  return c.dyadicLazy(a, b, v0, f1, f2)
+}
+
+func (c *Real) Digamma(a Scalar) Scalar {
+  return c.Polygamma(0, a)
+}
+
+func (c *Real) Trigamma(a Scalar) Scalar {
+  return c.Polygamma(1, a)
+}
+
+func (c *Real) Polygamma(n int, a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := special.Polygamma(n, x)
+  f1 := func() float64 { return special.Polygamma(n+1, x) }
+  f2 := func() float64 { return special.Polygamma(n+2, x) }
+  return c.monadicLazy(a, v0, f1, f2)
+}
+
+// Partial derivatives of the log beta function.
+func lbetaDerivatives(x, y float64) (float64, float64, float64, float64, float64) {
+  p := special.Digamma(x+y)
+  q := special.Trigamma(x+y)
+  return special.Digamma(x) - p, special.Digamma(y) - p, -q, special.Trigamma(x) - q, special.Trigamma(y) - q
+}
+
+func (c *Real) Beta(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  v0 := special.Beta(x, y)
+  f1 := func() (float64, float64) {
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v0*v10, v0*v01
+  }
+  f2 := func() (float64, float64, float64) {
+    v10, v01, v11, v20, v02 := lbetaDerivatives(x, y)
+    return v0*(v10*v01 + v11), v0*(v10*v10 + v20), v0*(v01*v01 + v02)
+  }
+  return c.dyadicLazy(a, b, v0, f1, f2)
+}
+
+func (c *Real) Lbeta(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  v0, s := special.Lbeta(x, y)
+  if s == -1 {
+    v0 = math.NaN()
+  }
+  f1 := func() (float64, float64) {
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v10, v01
+  }
+  f2 := func() (float64, float64, float64) {
+    _, _, v11, v20, v02 := lbetaDerivatives(x, y)
+    return v11, v20, v02
+  }
+  return c.dyadicLazy(a, b, v0, f1, f2)
+}
+
+func (c *Real) Zeta(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := special.Zeta(x)
+  f1 := func() float64 { return special.ZetaDerivative(1, x) }
+  f2 := func() float64 { return special.ZetaDerivative(2, x) }
+  return c.monadicLazy(a, v0, f1, f2)
 }
 
 /* -------------------------------------------------------------------------- */
@@ -456,3 +659,18 @@ func (r *Real) Vnorm(a Vector) Scalar {
   r.Sqrt(r)
   return r
 }
+
+func (r *Real) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullReal()
+  t := NullReal()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
@@ -22,6 +22,8 @@ package autodiff
 import "math"
 import "testing"
 
+import "github.com/pbenner/autodiff/special"
+
 /* -------------------------------------------------------------------------- */
 
 func TestReal(t *testing.T) {
@@ -121,6 +123,26 @@ func TestPow1(t *testing.T) {
   }
 }
 
+func TestPow3(t *testing.T) {
+  x := NewReal(3.4)
+  k := NewReal(4.1)
+
+  Variables(2, x, k)
+
+  r := Pow(x, k)
+
+  // mixed partial derivative
+  h := math.Pow(3.4, 3.1)*(1 + 4.1*math.Log(3.4))
+
+  if math.Abs(r.GetHessian(0, 1) - h) > 1e-8 ||
+    (math.Abs(r.GetHessian(1, 0) - h) > 1e-8) {
+    t.Error("Pow failed!")
+  }
+  if math.Abs(r.GetHessian(0, 0) - r.GetDerivative(2, 0)) > 1e-12 {
+    t.Error("Pow failed!")
+  }
+}
+
 func TestPow2(t *testing.T) {
   x := NewReal(-3.4)
   k := NewReal( 4.0)
@@ -255,7 +277,7 @@ func TestGammaP(t *testing.T) {
   x := NewReal(4.321)
   Variables(2, x)
 
-  s := GammaP(9.125, x)
+  s := GammaP(NewBareReal(9.125), x)
 
   if math.Abs(s.GetValue() - 0.029234) > 1e-6           ||
     (math.Abs(s.GetDerivative(1, 0) - 0.036763) > 1e-6) ||
@@ -263,3 +285,211 @@ func TestGammaP(t *testing.T) {
     t.Error("Incorrect derivative for Gamma()!")
   }
 }
+
+func TestInverseTrigonometric(t *testing.T) {
+
+  g := []func(Scalar) Scalar{Asin, Acos, Atan, Asinh, Atanh, Expm1, Logistic, Softplus}
+  f := []func(float64) float64{math.Asin, math.Acos, math.Atan, math.Asinh, math.Atanh, math.Expm1,
+    func(x float64) float64 { return 1.0/(1.0+math.Exp(-x)) },
+    func(x float64) float64 { return math.Log(1.0+math.Exp(x)) } }
+
+  for _, x := range []float64{-0.6, 0.3} {
+    for i := 0; i < len(g); i++ {
+      a := NewReal(x)
+      Variables(2, a)
+      s := g[i](a)
+      // finite differences
+      h  := 1e-4
+      d1 := (f[i](x+h) - f[i](x-h))/(2.0*h)
+      d2 := (f[i](x+h) - 2.0*f[i](x) + f[i](x-h))/(h*h)
+      if math.Abs(s.GetValue() - f[i](x)) > 1e-12 ||
+        (math.Abs(s.GetDerivative(1, 0) - d1) > 1e-6) ||
+        (math.Abs(s.GetDerivative(2, 0) - d2) > 1e-6) {
+        t.Errorf("Incorrect derivative for function `%d'!", i)
+      }
+    }
+  }
+  a := NewReal(2.5)
+  Variables(2, a)
+  s := Acosh(a)
+  if math.Abs(s.GetDerivative(1, 0) - 1.0/math.Sqrt(5.25)) > 1e-10 ||
+    (math.Abs(s.GetDerivative(2, 0) + 2.5/math.Pow(5.25, 1.5)) > 1e-10) {
+    t.Error("Incorrect derivative for Acosh()!")
+  }
+}
+
+func TestAtan2(t *testing.T) {
+
+  x := NewVector(RealType, []float64{-0.7, -1.2})
+  Variables(2, x...)
+
+  s := Atan2(x[0], x[1])
+  // compare with atan(y/x) + pi
+  r := Add(Atan(Div(x[0], x[1])), NewReal(-math.Pi))
+
+  if math.Abs(s.GetValue() - r.GetValue()) > 1e-12 {
+    t.Error("Atan2 test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    if math.Abs(s.GetDerivative(1, i) - r.GetDerivative(1, i)) > 1e-12 {
+      t.Error("Atan2 test failed!")
+    }
+    for j := 0; j < 2; j++ {
+      if math.Abs(s.GetHessian(i, j) - r.GetHessian(i, j)) > 1e-12 {
+        t.Error("Atan2 test failed!")
+      }
+    }
+  }
+}
+
+func TestLogSumExp(t *testing.T) {
+
+  // naive implementations overflow for these values
+  x := NewVector(RealType, []float64{1000.0, 1000.0, 999.0})
+  Variables(2, x...)
+
+  s := LogSumExp(x)
+  z := 2.0 + math.Exp(-1.0)
+
+  if math.Abs(s.GetValue() - (1000.0 + math.Log(z))) > 1e-10 {
+    t.Error("LogSumExp test failed!")
+  }
+  if math.Abs(s.GetDerivative(1, 0) - 1.0/z) > 1e-12 ||
+    (math.Abs(s.GetDerivative(1, 2) - math.Exp(-1.0)/z) > 1e-12) {
+    t.Error("LogSumExp test failed!")
+  }
+  if r := Logistic(NewReal(-800.0)); r.GetValue() != 0.0 || math.IsNaN(r.GetValue()) {
+    t.Error("Logistic test failed!")
+  }
+  if r := Softplus(NewReal(800.0)); r.GetValue() != 800.0 {
+    t.Error("Softplus test failed!")
+  }
+}
+
+func TestPolygamma(t *testing.T) {
+
+  // compare derivatives of lgamma, digamma and trigamma
+  a := NewReal(2.7)
+  Variables(2, a)
+
+  s0 := Lgamma(a)
+  s1 := Digamma(a)
+  s2 := Trigamma(a)
+  s3 := Polygamma(2, a)
+
+  if math.Abs(s0.GetDerivative(1, 0) - s1.GetValue()) > 1e-10 ||
+    (math.Abs(s0.GetDerivative(2, 0) - s1.GetDerivative(1, 0)) > 1e-10) ||
+    (math.Abs(s1.GetDerivative(2, 0) - s2.GetDerivative(1, 0)) > 1e-10) ||
+    (math.Abs(s2.GetDerivative(2, 0) - s3.GetDerivative(1, 0)) > 1e-10) {
+    t.Error("Incorrect derivative for Polygamma()!")
+  }
+}
+
+func TestBeta(t *testing.T) {
+
+  x := NewVector(RealType, []float64{1.7, 3.2})
+  Variables(2, x...)
+
+  s1 := Beta(x[0], x[1])
+  s2 := Exp(Lbeta(x[0], x[1]))
+  // compare with Gamma(a) Gamma(b) / Gamma(a+b)
+  s3 := Div(Mul(Gamma(x[0]), Gamma(x[1])), Gamma(Add(x[0], x[1])))
+
+  if math.Abs(s1.GetValue() - s3.GetValue()) > 1e-12 {
+    t.Error("Beta test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    for j := 0; j < 2; j++ {
+      if math.Abs(s1.GetHessian(i, j) - s3.GetHessian(i, j)) > 1e-10 ||
+        (math.Abs(s2.GetHessian(i, j) - s3.GetHessian(i, j)) > 1e-10) {
+        t.Error("Beta test failed!")
+      }
+    }
+  }
+}
+
+func TestGammaQ(t *testing.T) {
+
+  x := NewReal(4.321)
+  Variables(2, x)
+
+  s := Add(GammaP(NewBareReal(9.125), x), GammaQ(NewBareReal(9.125), x))
+
+  if math.Abs(s.GetValue() - 1.0) > 1e-12 ||
+    (math.Abs(s.GetDerivative(1, 0)) > 1e-12) ||
+    (math.Abs(s.GetDerivative(2, 0)) > 1e-12) {
+    t.Error("Incorrect derivative for GammaQ()!")
+  }
+}
+
+func TestGammaPshape(t *testing.T) {
+
+  // derivatives with respect to both arguments
+  f := func(a, x float64) float64 {
+    return special.GammaP(a, x)
+  }
+  x := NewVector(RealType, []float64{2.5, 1.7})
+  Variables(2, x...)
+
+  s := GammaP(x[0], x[1])
+
+  h := 1e-4
+  d := []float64{
+    (f(2.5+h, 1.7) - f(2.5-h, 1.7))/(2.0*h),
+    (f(2.5, 1.7+h) - f(2.5, 1.7-h))/(2.0*h) }
+  H := []float64{
+    (f(2.5+h, 1.7) - 2.0*f(2.5, 1.7) + f(2.5-h, 1.7))/(h*h),
+    (f(2.5+h, 1.7+h) - f(2.5+h, 1.7-h) - f(2.5-h, 1.7+h) + f(2.5-h, 1.7-h))/(4.0*h*h),
+    (f(2.5, 1.7+h) - 2.0*f(2.5, 1.7) + f(2.5, 1.7-h))/(h*h) }
+  for i := 0; i < 2; i++ {
+    if math.Abs(s.GetDerivative(1, i) - d[i]) > 1e-7 {
+      t.Error("Incorrect derivative for GammaP()!")
+    }
+  }
+  if math.Abs(s.GetDerivative(2, 0) - H[0]) > 1e-5 ||
+    (math.Abs(s.GetHessian(0, 1)  - H[1]) > 1e-5) ||
+    (math.Abs(s.GetDerivative(2, 1) - H[2]) > 1e-5) {
+    t.Error("Incorrect second derivative for GammaP()!")
+  }
+  r := Add(s, GammaQ(x[0], x[1]))
+  if math.Abs(r.GetDerivative(1, 0)) > 1e-12 ||
+    (math.Abs(r.GetHessian(0, 1)) > 1e-12) {
+    t.Error("Incorrect derivative for GammaQ()!")
+  }
+}
+
+func TestGammaPshapeBare(t *testing.T) {
+
+  // derivatives with respect to the shape at a constant x
+  f := func(a float64) float64 {
+    return special.GammaP(a, 1.7)
+  }
+  a := NewReal(2.5)
+  Variables(1, a)
+
+  s := GammaP(a, NewBareReal(1.7))
+  h := 1e-4
+  d := (f(2.5+h) - f(2.5-h))/(2.0*h)
+
+  if _, ok := s.(*Real); !ok || math.Abs(s.GetDerivative(1, 0) - d) > 1e-7 {
+    t.Error("Incorrect derivative for GammaP()!")
+  }
+  r := GammaQ(a, NewBareReal(1.7))
+  if _, ok := r.(*Real); !ok || math.Abs(r.GetDerivative(1, 0) + d) > 1e-7 {
+    t.Error("Incorrect derivative for GammaQ()!")
+  }
+}
+
+func TestZeta(t *testing.T) {
+
+  x := NewReal(2.0)
+  Variables(2, x)
+
+  s := Zeta(x)
+
+  if math.Abs(s.GetValue() - math.Pi*math.Pi/6.0) > 1e-12 ||
+    (math.Abs(s.GetDerivative(1, 0) - -0.937548254315843753) > 1e-10) ||
+    (math.Abs(s.GetDerivative(2, 0) -  1.989280234298901023) > 1e-10) {
+    t.Error("Incorrect derivative for Zeta()!")
+  }
+}
@@ -39,6 +39,7 @@ type ScalarState interface {
   GetValue        ()             float64
   GetLogValue     ()             float64
   GetDerivative   (int, int)     float64
+  GetHessian      (int, int)     float64
   GetN            ()             int
   // same as alloc
   SetN            (int)
@@ -49,6 +50,7 @@ type ScalarState interface {
   Set             (Scalar)
   SetValue        (float64)
   SetDerivative   (int, int, float64)
+  SetHessian      (int, int, float64)
   SetVariable     (int, int, int)
 }
 
@@ -61,10 +63,13 @@ type Scalar interface {
   Equals    (Scalar)          bool
   Greater   (Scalar)          bool
   Smaller   (Scalar)          bool
+  // Min and Max return one of the arguments, except at ties, where the
+  // result is a new scalar with the average of both derivatives, which is
+  // a subgradient of the minimum (maximum)
   Min       (Scalar)          Scalar
   Max       (Scalar)          Scalar
-  Abs       (Scalar)          Scalar
-  Sign      ()                int
+  Abs       (Scalar)          Scalar // zero derivatives at zero
+  Sign      ()                int    // zero at zero
   Neg       (Scalar)          Scalar
   Add       (Scalar, Scalar)  Scalar
   Sub       (Scalar, Scalar)  Scalar
@@ -78,19 +83,42 @@ type Scalar interface {
   Cosh      (Scalar)          Scalar
   Tan       (Scalar)          Scalar
   Tanh      (Scalar)          Scalar
+  Asin      (Scalar)          Scalar
+  Acos      (Scalar)          Scalar
+  Atan      (Scalar)          Scalar
+  Atan2     (Scalar, Scalar)  Scalar
+  Asinh     (Scalar)          Scalar
+  Acosh     (Scalar)          Scalar
+  Atanh     (Scalar)          Scalar
   Exp       (Scalar)          Scalar
   Log       (Scalar)          Scalar
   Log1p     (Scalar)          Scalar
+  Expm1     (Scalar)          Scalar
+  Logistic  (Scalar)          Scalar // 1/(1+exp(-x))
+  Softplus  (Scalar)          Scalar // log(1+exp(x))
   Erf       (Scalar)          Scalar
   Erfc      (Scalar)          Scalar
   LogErfc   (Scalar)          Scalar
   Gamma     (Scalar)          Scalar
   Lgamma    (Scalar)          Scalar
   Mlgamma   (Scalar, int)     Scalar // multivariate log gamma
-  GammaP    (float64, Scalar) Scalar // regularized lower incomplete gamma
+  GammaP    (Scalar, Scalar)  Scalar // regularized lower incomplete gamma
+  GammaQ    (Scalar, Scalar)  Scalar // regularized upper incomplete gamma
+  Digamma   (Scalar)          Scalar
+  Trigamma  (Scalar)          Scalar
+  Polygamma (int, Scalar)     Scalar
+  Beta      (Scalar, Scalar)  Scalar
+  Lbeta     (Scalar, Scalar)  Scalar
+  Zeta      (Scalar)          Scalar // Riemann zeta function
+  // treat argument as constant
+  StopGradient(Scalar)        Scalar
+  // user-defined functions given by value and partial derivatives
+  Monadic   (Scalar, float64, float64, float64) Scalar
+  Dyadic    (Scalar, Scalar, float64, float64, float64, float64, float64, float64) Scalar
   // vector operations
   VdotV     (a, b Vector)     Scalar
   Vnorm     (a    Vector)     Scalar
+  LogSumExp (a    Vector)     Scalar
   // nice printing
   fmt.Stringer
 }
@@ -139,8 +167,110 @@ func NullScalar(t ScalarType) Scalar {
 
 /* -------------------------------------------------------------------------- */
 
+// Declare reals as the variables at the derivative slots 0, ..., n-1.
 func Variables(order int, reals ...Scalar) {
   for i, _ := range reals {
     reals[i].SetVariable(i, len(reals), order)
   }
 }
+
+// Declare reals as variables at the derivative slots offset, offset+1, ...,
+// whereas derivatives with respect to the first offset slots, which belong
+// to enclosing contexts, are kept. Mixed second derivatives between inner
+// and outer variables are available if order is two. The offset is usually
+// given by a nested differentiation context (see DiffContext).
+func VariablesAt(offset, order int, reals ...Scalar) {
+  n := offset + len(reals)
+  for i, _ := range reals {
+    setNestedVariable(reals[i], offset+i, n, offset, order)
+  }
+}
+
+func setNestedVariable(a Scalar, i, n, offset, order int) {
+  m := iMin(a.GetN(), offset)
+  // save derivatives with respect to outer variables
+  d := make([]float64, m)
+  h := newHessian(m)
+  if a.GetOrder() >= 1 {
+    for k := 0; k < m; k++ {
+      d[k] = a.GetDerivative(1, k)
+    }
+  }
+  if a.GetOrder() >= 2 {
+    for k := 0; k < m; k++ {
+      for l := k; l < m; l++ {
+        h[k][l] = a.GetHessian(k, l)
+      }
+    }
+  }
+  a.SetVariable(i, n, order)
+  if order >= 1 {
+    for k := 0; k < m; k++ {
+      a.SetDerivative(1, k, d[k])
+    }
+  }
+  if order >= 2 {
+    for k := 0; k < m; k++ {
+      for l := k; l < m; l++ {
+        a.SetHessian(k, l, h[k][l])
+      }
+    }
+  }
+}
+
+/* differentiation contexts
+ * -------------------------------------------------------------------------- */
+
+// A DiffContext holds the derivative slots Offset, ..., Offset+N-1. Nested
+// contexts, which are used for instance by Jacobian or by optimization
+// algorithms, receive the slots following those of the enclosing context.
+// Derivatives with respect to slots of the enclosing context are propagated
+// through the nested computation. Contexts carry no global state, i.e. the
+// enclosing context is either given explicitly or it is determined from the
+// arguments of a computation (see ContextOf).
+type DiffContext struct {
+  Offset int
+  N      int
+}
+
+// Returns the outermost context that holds all derivative slots carried by
+// the elements of x.
+func ContextOf(x ...Vector) DiffContext {
+  n := 0
+  for _, x := range x {
+    for i := 0; i < len(x); i++ {
+      if x[i].GetOrder() >= 1 {
+        n = iMax(n, x[i].GetN())
+      }
+    }
+  }
+  return DiffContext{0, n}
+}
+
+// Returns a context for n variables nested within ctx.
+func (ctx DiffContext) Nested(n int) DiffContext {
+  return DiffContext{ctx.Offset+ctx.N, n}
+}
+
+// Returns a context for n variables nested within ctx and within all slots
+// carried by the elements of x.
+func (ctx DiffContext) nestedWithin(n int, x ...Vector) DiffContext {
+  return DiffContext{iMax(ctx.Offset+ctx.N, ContextOf(x...).N), n}
+}
+
+// Declare reals as variables of the context. Derivatives with respect to
+// slots of enclosing contexts are kept, whereas all other derivatives are
+// dropped.
+func (ctx DiffContext) Variables(order int, reals ...Scalar) {
+  VariablesAt(ctx.Offset, order, reals...)
+}
+
+// Returns the order of derivatives that is required to propagate first
+// derivatives with respect to the variables of enclosing contexts through
+// a computation with first derivatives in this context.
+func (ctx DiffContext) Order() int {
+  if ctx.Offset == 0 {
+    return 1
+  }
+  return 2
+}
@@ -18,6 +18,37 @@ package autodiff
 
 /* -------------------------------------------------------------------------- */
 
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
+// Returns a clone of the operand that determines the type of the result of
+// a dyadic function. This is the operand of higher order, so that constants
+// adopt the type of variables, e.g. Mul(NewReal(2), x) has the type of x.
+// If both operands have the same order or if a is a BareReal, which rejects
+// operands with derivatives, a is cloned.
+func cloneDyadic(a, b Scalar) Scalar {
+  if _, ok := a.(*BareReal); ok {
+    return a.Clone()
+  }
+  return cloneHigherOrder(a, b)
+}
+
+// Returns a clone of the operand of highest order, or a clone of a if no
+// other operand has a higher order. This is used by functions with constant
+// parameters that may nevertheless be differentiated, e.g. the shape of
+// GammaP or the threshold of Huber.
+func cloneHigherOrder(a Scalar, b ...Scalar) Scalar {
+  for _, bi := range b {
+    if bi.GetOrder() > a.GetOrder() {
+      a = bi
+    }
+  }
+  return a.Clone()
+}
+
+/* -------------------------------------------------------------------------- */
+
 func Equal(a, b Scalar) bool {
   return a.Equals(b)
 }
@@ -35,36 +66,40 @@ func Neg(a Scalar) Scalar {
   return c.Neg(a)
 }
 
+// Absolute value of a. Derivatives are zero at a = 0, which is the
+// subgradient of minimum norm.
 func Abs(a Scalar) Scalar {
   c := a.Clone()
-  if c.Sign() == -1 {
-    c.Neg(c)
-  }
-  return c
+  return c.Abs(a)
 }
 
+// The result of dyadic functions, i.e. Add, Sub, Mul, Div, Pow, Atan2, Beta,
+// Lbeta and Dyadic, has the type of the operand with derivatives of higher
+// order, or the type of the first operand if both operands have the same
+// order. A BareReal first operand always determines the type of the result,
+// hence the second operand must not carry derivatives.
 func Add(a, b Scalar) Scalar {
-  c := a.Clone()
+  c := cloneDyadic(a, b)
   return c.Add(a, b)
 }
 
 func Sub(a, b Scalar) Scalar {
-  c := a.Clone()
+  c := cloneDyadic(a, b)
   return c.Sub(a, b)
 }
 
 func Mul(a, b Scalar) Scalar {
-  c := a.Clone()
+  c := cloneDyadic(a, b)
   return c.Mul(a, b)
 }
 
 func Div(a, b Scalar) Scalar {
-  c := a.Clone()
+  c := cloneDyadic(a, b)
   return c.Div(a, b)
 }
 
 func Pow(a Scalar, k Scalar) Scalar {
-  c := a.Clone()
+  c := cloneDyadic(a, k)
   return c.Pow(a, k)
 }
 
@@ -103,6 +138,41 @@ func Tanh(a Scalar) Scalar {
   return c.Tanh(a)
 }
 
+func Asin(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Asin(a)
+}
+
+func Acos(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Acos(a)
+}
+
+func Atan(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Atan(a)
+}
+
+func Atan2(a, b Scalar) Scalar {
+  c := cloneDyadic(a, b)
+  return c.Atan2(a, b)
+}
+
+func Asinh(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Asinh(a)
+}
+
+func Acosh(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Acosh(a)
+}
+
+func Atanh(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Atanh(a)
+}
+
 func Exp(a Scalar) Scalar {
   c := a.Clone()
   return c.Exp(a)
@@ -113,6 +183,26 @@ func Log(a Scalar) Scalar {
   return c.Log(a)
 }
 
+func Log1p(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Log1p(a)
+}
+
+func Expm1(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Expm1(a)
+}
+
+func Logistic(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Logistic(a)
+}
+
+func Softplus(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Softplus(a)
+}
+
 func Erf(a Scalar) Scalar {
   c := a.Clone()
   return c.Erf(a)
@@ -143,15 +233,187 @@ func Mlgamma(a Scalar, k int) Scalar {
   return c.Mlgamma(a, k)
 }
 
-func GammaP(a float64, x Scalar) Scalar {
-  c := x.Clone()
+// Regularized lower incomplete gamma function with shape a. The result has
+// the type of the argument with derivatives of higher order, or the type of x
+// if both arguments have the same order.
+func GammaP(a, x Scalar) Scalar {
+  c := cloneHigherOrder(x, a)
   return c.GammaP(a, x)
 }
 
+// Regularized upper incomplete gamma function with shape a (see GammaP).
+func GammaQ(a, x Scalar) Scalar {
+  c := cloneHigherOrder(x, a)
+  return c.GammaQ(a, x)
+}
+
+func Digamma(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Digamma(a)
+}
+
+func Trigamma(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Trigamma(a)
+}
+
+func Polygamma(n int, x Scalar) Scalar {
+  c := x.Clone()
+  return c.Polygamma(n, x)
+}
+
+func Beta(a, b Scalar) Scalar {
+  c := cloneDyadic(a, b)
+  return c.Beta(a, b)
+}
+
+func Lbeta(a, b Scalar) Scalar {
+  c := cloneDyadic(a, b)
+  return c.Lbeta(a, b)
+}
+
+func Zeta(a Scalar) Scalar {
+  c := a.Clone()
+  return c.Zeta(a)
+}
+
+/* non-smooth functions and their smooth approximations
+ * -------------------------------------------------------------------------- */
+
+// Minimum of a and b.
 func Min(a, b Scalar) Scalar {
   return a.Min(b)
 }
 
+// Maximum of a and b.
 func Max(a, b Scalar) Scalar {
   return a.Max(b)
 }
+
+// Result of Min and Max for a = b.
+func scalarTie(a, b Scalar) Scalar {
+  c := a.Clone()
+  c.Add(a, b)
+  return c.Mul(c, NewBareReal(0.5))
+}
+
+// Smooth maximum t log(exp(a/t) + exp(b/t)) with temperature t > 0,
+// which converges to Max(a, b) as t goes to zero.
+func SoftMax(a, b, t Scalar) Scalar {
+  c := cloneHigherOrder(a, b, t)
+  c.Sub(a, b)
+  c.Div(c, t)
+  c.Softplus(c)
+  c.Mul(c, t)
+  return c.Add(c, b)
+}
+
+// Smooth minimum -t log(exp(-a/t) + exp(-b/t)) with temperature t > 0.
+func SoftMin(a, b, t Scalar) Scalar {
+  c := cloneHigherOrder(a, b, t)
+  c.Sub(a, b)
+  c.Div(c, t)
+  c.Softplus(c)
+  c.Mul(c, t)
+  c.Neg(c)
+  return c.Add(c, a)
+}
+
+// Smooth approximation sqrt(a^2 + epsilon^2) - epsilon of |a|, which is
+// zero at zero and converges to Abs(a) as epsilon goes to zero.
+func SmoothAbs(a, epsilon Scalar) Scalar {
+  c := cloneHigherOrder(a, epsilon)
+  t := c.Clone()
+  t.Mul(epsilon, epsilon)
+  c.Mul(a, a)
+  c.Add(c, t)
+  c.Sqrt(c)
+  return c.Sub(c, epsilon)
+}
+
+// Huber loss with threshold delta, i.e. a^2/2 if |a| <= delta and
+// delta (|a| - delta/2) otherwise. The loss is quadratic close to zero
+// and has continuous first derivatives.
+func Huber(a, delta Scalar) Scalar {
+  c := cloneHigherOrder(a, delta)
+  if math.Abs(a.GetValue()) <= delta.GetValue() {
+    c.Mul(a, a)
+    return c.Mul(c, NewBareReal(0.5))
+  }
+  t := c.Clone()
+  t.Mul(delta, delta)
+  t.Mul(t, NewBareReal(0.5))
+  c.Abs(a)
+  c.Mul(c, delta)
+  return c.Sub(c, t)
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+// Returns a with all derivatives set to zero, i.e. a is treated as
+// constant by subsequent operations.
+func StopGradient(a Scalar) Scalar {
+  c := a.Clone()
+  return c.StopGradient(a)
+}
+
+// Returns a function that evaluates to f(a), but whose first derivative
+// is given by df(a). Derivatives of df are not propagated. For instance,
+// a straight-through estimator for a rounding function f is obtained
+// with df(a) = 1.
+func CustomGradient(f, df func(Scalar) Scalar) func(Scalar) Scalar {
+  return func(a Scalar) Scalar {
+    x := StopGradient(a)
+    y := f(x)
+    // t = df(a) (a - a), where only the first a is differentiated
+    t := a.Clone()
+    t.Sub(a, x)
+    t.Mul(t, df(x))
+    return t.Add(t, y)
+  }
+}
+
+/* user-defined functions
+ * -------------------------------------------------------------------------- */
+
+// Evaluate a user-defined function f at a, where v0 = f(a), v1 = f'(a), and
+// v2 = f''(a).
+func Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  c := a.Clone()
+  return c.Monadic(a, v0, v1, v2)
+}
+
+// Same as Monadic, but derivatives are only evaluated if required by the
+// order of a.
+func MonadicLazy(a Scalar, v0 float64, f1, f2 func() float64) Scalar {
+  v1, v2 := 0.0, 0.0
+  if a.GetOrder() >= 1 {
+    v1 = f1()
+  }
+  if a.GetOrder() >= 2 {
+    v2 = f2()
+  }
+  return Monadic(a, v0, v1, v2)
+}
+
+// Evaluate a user-defined function f at (a, b), where v0 = f(a, b) and vij
+// is the partial derivative of order i in a and order j in b.
+func Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  c := cloneDyadic(a, b)
+  return c.Dyadic(a, b, v0, v10, v01, v11, v20, v02)
+}
+
+// Same as Dyadic, but derivatives are only evaluated if required by the
+// order of a or b. The function f1 returns (v10, v01) and f2 returns (v11,
+// v20, v02).
+func DyadicLazy(a, b Scalar, v0 float64, f1 func() (float64, float64), f2 func() (float64, float64, float64)) Scalar {
+  v10, v01, v11, v20, v02 := 0.0, 0.0, 0.0, 0.0, 0.0
+  if n := iMax(a.GetOrder(), b.GetOrder()); n >= 1 {
+    v10, v01 = f1()
+    if n >= 2 {
+      v11, v20, v02 = f2()
+    }
+  }
+  return Dyadic(a, b, v0, v10, v01, v11, v20, v02)
+}
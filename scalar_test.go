@@ -18,6 +18,7 @@ package autodiff
 
 /* -------------------------------------------------------------------------- */
 
+import "math"
 import "testing"
 
 /* -------------------------------------------------------------------------- */
@@ -30,3 +31,287 @@ func TestScalar(t *testing.T) {
     t.Error("a.GetValue() should be 1.0")
   }
 }
+
+func TestMonadic(t *testing.T) {
+
+  // sin(x) defined as a user primitive
+  f := func(x Scalar) Scalar {
+    v := x.GetValue()
+    return MonadicLazy(x, math.Sin(v),
+      func() float64 { return  math.Cos(v) },
+      func() float64 { return -math.Sin(v) })
+  }
+  for _, order := range []int{1, 2} {
+    for _, typ := range []ScalarType{RealType, TaylorRealType, ProbabilityType} {
+      a := NewScalar(typ, 0.7)
+      Variables(order, a)
+      r1 := f(a)
+      r2 := Sin(a)
+      if math.Abs(r1.GetValue() - r2.GetValue()) > 1e-12 {
+        t.Error("Monadic test failed!")
+      }
+      for k := 1; k <= order; k++ {
+        if math.Abs(r1.GetDerivative(k, 0) - r2.GetDerivative(k, 0)) > 1e-10 {
+          t.Error("Monadic test failed!")
+        }
+      }
+    }
+  }
+  for _, typ := range []ScalarType{TapeRealType, ComplexType, IntervalType} {
+    a := NewScalar(typ, 0.7)
+    Variables(1, a)
+    r := f(a)
+    if math.Abs(r.GetValue() - math.Sin(0.7)) > 1e-12 ||
+       math.Abs(r.GetDerivative(1, 0) - math.Cos(0.7)) > 1e-12 {
+      t.Error("Monadic test failed!")
+    }
+  }
+  if r := f(NewBareReal(0.7)); r.GetValue() != math.Sin(0.7) {
+    t.Error("Monadic test failed!")
+  }
+}
+
+func TestDyadic(t *testing.T) {
+
+  // x*y defined as a user primitive
+  f := func(x, y Scalar) Scalar {
+    a, b := x.GetValue(), y.GetValue()
+    return Dyadic(x, y, a*b, b, a, 1.0, 0.0, 0.0)
+  }
+  x := NewVector(RealType, []float64{1.3, -0.4})
+  Variables(2, x...)
+
+  r1 := f(x[0], x[1])
+  r2 := Mul(x[0], x[1])
+
+  if math.Abs(r1.GetValue() - r2.GetValue()) > 1e-12 {
+    t.Error("Dyadic test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    if math.Abs(r1.GetDerivative(1, i) - r2.GetDerivative(1, i)) > 1e-12 {
+      t.Error("Dyadic test failed!")
+    }
+    for j := 0; j < 2; j++ {
+      if math.Abs(r1.GetHessian(i, j) - r2.GetHessian(i, j)) > 1e-12 {
+        t.Error("Dyadic test failed!")
+      }
+    }
+  }
+}
+
+func TestDyadicType(t *testing.T) {
+
+  x := NewReal(2.0)
+  Variables(1, x)
+  y := NewTaylorReal(3.0)
+  Variables(1, y)
+
+  // constants adopt the type of variables
+  if r, ok := Mul(NewReal(2.0), x).(*Real); !ok || r.GetDerivative(1, 0) != 2.0 {
+    t.Error("Dyadic type test failed!")
+  }
+  if r, ok := Sub(NewReal(1.0), y).(*TaylorReal); !ok || r.GetDerivative(1, 0) != -1.0 {
+    t.Error("Dyadic type test failed!")
+  }
+  if _, ok := Pow(x, NewBareReal(2.0)).(*Real); !ok {
+    t.Error("Dyadic type test failed!")
+  }
+  // the first operand determines the type if both have the same order
+  if _, ok := Add(NewReal(1.0), NewBareReal(2.0)).(*Real); !ok {
+    t.Error("Dyadic type test failed!")
+  }
+  if _, ok := Add(y, x).(*TaylorReal); !ok {
+    t.Error("Dyadic type test failed!")
+  }
+  // BareReal rejects operands with derivatives
+  func() {
+    defer func() {
+      if recover() == nil {
+        t.Error("Dyadic type test failed!")
+      }
+    }()
+    Mul(NewBareReal(2.0), x)
+  }()
+}
+
+func TestStopGradient(t *testing.T) {
+
+  // f(x) = x^2 + x^2, where the second term is constant
+  f := func(x Scalar) Scalar {
+    return Add(Mul(x, x), StopGradient(Mul(x, x)))
+  }
+  for _, order := range []int{1, 2} {
+    for _, typ := range []ScalarType{RealType, TaylorRealType, ProbabilityType} {
+      a := NewScalar(typ, 0.7)
+      Variables(order, a)
+      r := f(a)
+      if math.Abs(r.GetValue() - 0.98) > 1e-12 ||
+         math.Abs(r.GetDerivative(1, 0) - 1.4) > 1e-12 {
+        t.Error("StopGradient test failed!")
+      }
+      if order == 2 && math.Abs(r.GetDerivative(2, 0) - 2.0) > 1e-12 {
+        t.Error("StopGradient test failed!")
+      }
+    }
+  }
+  for _, typ := range []ScalarType{TapeRealType, SparseRealType, ComplexType, IntervalType} {
+    a := NewScalar(typ, 0.7)
+    Variables(1, a)
+    r := f(a)
+    if math.Abs(r.GetValue() - 0.98) > 1e-12 ||
+       math.Abs(r.GetDerivative(1, 0) - 1.4) > 1e-12 {
+      t.Error("StopGradient test failed!")
+    }
+  }
+  if r := f(NewBareReal(0.7)); math.Abs(r.GetValue() - 0.98) > 1e-12 {
+    t.Error("StopGradient test failed!")
+  }
+}
+
+func TestCustomGradient(t *testing.T) {
+
+  // straight-through estimator for rounding
+  round := CustomGradient(
+    func(x Scalar) Scalar { return NewScalar(x.Type(), math.Floor(x.GetValue())) },
+    func(x Scalar) Scalar { return NewScalar(x.Type(), 1.0) })
+
+  for _, order := range []int{1, 2} {
+    for _, typ := range []ScalarType{RealType, TaylorRealType, ProbabilityType} {
+      a := NewScalar(typ, 2.7)
+      Variables(order, a)
+      r := Mul(round(a), a)
+      if math.Abs(r.GetValue() - 5.4) > 1e-12 ||
+         math.Abs(r.GetDerivative(1, 0) - 4.7) > 1e-12 {
+        t.Error("CustomGradient test failed!")
+      }
+      if order == 2 && math.Abs(r.GetDerivative(2, 0) - 2.0) > 1e-12 {
+        t.Error("CustomGradient test failed!")
+      }
+    }
+  }
+  for _, typ := range []ScalarType{TapeRealType, SparseRealType, ComplexType, IntervalType} {
+    a := NewScalar(typ, 2.7)
+    Variables(1, a)
+    r := Mul(round(a), a)
+    if math.Abs(r.GetValue() - 5.4) > 1e-12 ||
+       math.Abs(r.GetDerivative(1, 0) - 4.7) > 1e-12 {
+      t.Error("CustomGradient test failed!")
+    }
+  }
+}
+
+func TestAbs(t *testing.T) {
+
+  for _, typ := range []ScalarType{RealType, TapeRealType, TaylorRealType, SparseRealType, ProbabilityType} {
+    for _, x := range []float64{-2.0, 0.0, 2.0} {
+      a := NewScalar(typ, x)
+      Variables(1, a)
+      r := Abs(a)
+      if r.GetValue() != math.Abs(x) || r.GetDerivative(1, 0) != float64(a.Sign()) {
+        t.Errorf("Abs test failed for type %v at %v", typ, x)
+      }
+    }
+  }
+  if r := Abs(NewBareReal(-2.0)); r.GetValue() != 2.0 {
+    t.Error("Abs test failed!")
+  }
+}
+
+func TestMinMax(t *testing.T) {
+
+  x := NewVector(RealType, []float64{1.5, 1.5})
+  Variables(2, x...)
+
+  for _, r := range []Scalar{Min(x[0], x[1]), Max(x[0], x[1])} {
+    if r.GetValue() != 1.5 || r.GetDerivative(1, 0) != 0.5 || r.GetDerivative(1, 1) != 0.5 {
+      t.Error("MinMax test failed!")
+    }
+  }
+  x[1].SetValue(2.0)
+  if r := Min(x[0], x[1]); r != x[0] {
+    t.Error("MinMax test failed!")
+  }
+  if r := Max(x[0], x[1]); r != x[1] {
+    t.Error("MinMax test failed!")
+  }
+  // ties with constants of other types
+  x[1].SetValue(0.0)
+  for _, r := range []Scalar{Max(NewBareReal(0.0), x[1]), Max(x[1], NewBareReal(0.0)), Min(NewBareReal(0.0), x[1])} {
+    if r.GetValue() != 0.0 || r.GetDerivative(1, 1) != 0.5 {
+      t.Error("MinMax test failed!")
+    }
+  }
+  z := NullVector(ComplexType, 2)
+  z[0].(*Complex).SetComplexValue(1+2i)
+  z[1].(*Complex).SetComplexValue(1+2i)
+  Variables(1, z...)
+  for _, r := range []Scalar{Min(z[0], z[1]), Max(z[0], z[1])} {
+    if r.GetDerivative(1, 0) != 0.5 || r.GetDerivative(1, 1) != 0.5 {
+      t.Error("MinMax test failed!")
+    }
+  }
+  y := Vector{NewInterval(1.0, 2.0), NewInterval(1.0, 2.0)}
+  Variables(1, y...)
+  for _, r := range []Scalar{Min(y[0], y[1]), Max(y[0], y[1])} {
+    if r.(*Interval).GetDerivativeLower(0) != 0.0 || r.(*Interval).GetDerivativeUpper(0) != 1.0 {
+      t.Error("MinMax test failed!")
+    }
+  }
+}
+
+func TestSmooth(t *testing.T) {
+
+  a := NewReal(1.3)
+  b := NewReal(0.4)
+  Variables(2, a, b)
+
+  r1 := SoftMax(a, b, NewBareReal(0.5))
+  r2 := SoftMin(a, b, NewBareReal(0.5))
+  p  := 1.0/(1.0 + math.Exp(-(1.3-0.4)/0.5))
+  if math.Abs(r1.GetValue() - 0.5*math.Log(math.Exp(1.3/0.5) + math.Exp(0.4/0.5))) > 1e-12 ||
+     math.Abs(r1.GetDerivative(1, 0) - p) > 1e-12 ||
+     math.Abs(r1.GetDerivative(1, 1) - (1.0-p)) > 1e-12 {
+    t.Error("SoftMax test failed!")
+  }
+  if math.Abs(r2.GetValue() + 0.5*math.Log(math.Exp(-1.3/0.5) + math.Exp(-0.4/0.5))) > 1e-12 ||
+     math.Abs(r2.GetDerivative(1, 0) - (1.0-p)) > 1e-12 {
+    t.Error("SoftMin test failed!")
+  }
+  // SoftMax converges to Max for small temperatures
+  if r := SoftMax(a, b, NewBareReal(1e-3)); math.Abs(r.GetValue() - 1.3) > 1e-10 {
+    t.Error("SoftMax test failed!")
+  }
+  x := NewReal(0.0)
+  Variables(2, x)
+  if r := SmoothAbs(x, NewBareReal(0.1)); r.GetValue() != 0.0 || r.GetDerivative(1, 0) != 0.0 ||
+    math.Abs(r.GetHessian(0, 0) - 10.0) > 1e-12 {
+    t.Error("SmoothAbs test failed!")
+  }
+  for _, v := range []float64{-3.0, -0.5, 0.5, 3.0} {
+    x.SetValue(v)
+    r := Huber(x, NewBareReal(1.0))
+    if math.Abs(v) <= 1.0 {
+      if r.GetValue() != 0.5*v*v || r.GetDerivative(1, 0) != v {
+        t.Error("Huber test failed!")
+      }
+    } else {
+      if r.GetValue() != math.Abs(v) - 0.5 || r.GetDerivative(1, 0) != math.Copysign(1.0, v) {
+        t.Error("Huber test failed!")
+      }
+    }
+  }
+  // derivatives with respect to parameters at a constant argument
+  d := NewReal(1.0)
+  Variables(1, d)
+  if r := Huber(NewBareReal(3.0), d); r.GetValue() != 2.5 || r.GetDerivative(1, 0) != 2.0 {
+    t.Error("Huber test failed!")
+  }
+  if r := SmoothAbs(NewBareReal(0.3), d); math.Abs(r.GetDerivative(1, 0) - (1.0/math.Sqrt(1.09) - 1.0)) > 1e-12 {
+    t.Error("SmoothAbs test failed!")
+  }
+  d.SetValue(0.5)
+  if r := SoftMax(NewBareReal(1.3), NewBareReal(0.4), d); math.Abs(r.GetDerivative(1, 0) -
+    (math.Log(math.Exp(1.3/0.5) + math.Exp(0.4/0.5)) - (1.3*p + 0.4*(1.0-p))/0.5)) > 1e-12 {
+    t.Error("SoftMax test failed!")
+  }
+}
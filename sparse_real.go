@@ -0,0 +1,221 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+import "sort"
+
+/* -------------------------------------------------------------------------- */
+
+// A SparseReal implements forward-mode differentiation where only non-zero
+// first derivatives are stored. Derivatives are kept as a list of variable
+// indices in ascending order together with the partial derivatives, which
+// are merged by dyadic operations. This is useful if each scalar depends
+// only on a few out of many variables. Only first derivatives are supported.
+type SparseReal struct {
+  Value        float64
+  Order        int
+  N            int
+  Index      []int
+  Derivative []float64
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var SparseRealType ScalarType = NewSparseReal(0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return NewSparseReal(value) }
+  RegisterScalar(SparseRealType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a new real constant or variable.
+func NewSparseReal(v float64) *SparseReal {
+  return &SparseReal{Value: v}
+}
+
+func NullSparseReal() *SparseReal {
+  return &SparseReal{Value: 0.0}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *SparseReal) Clone() Scalar {
+  r := NewSparseReal(0.0)
+  r.Copy(a)
+  return r
+}
+
+func (a *SparseReal) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *SparseReal) String() string {
+  return fmt.Sprintf("%e", a.GetValue())
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Copy value and derivatives from b. Only non-zero derivatives of scalars
+// of other types are copied.
+func (a *SparseReal) Copy(b Scalar) {
+  if a == b {
+    return
+  }
+  index, derivative := sparseDerivativesOf(b)
+  a.Value      = b.GetValue()
+  a.Order      = iMin(b.GetOrder(), 1)
+  a.N          = b.GetN()
+  a.Index      = append(a.Index[0:0], index...)
+  a.Derivative = append(a.Derivative[0:0], derivative...)
+}
+
+// Set the number of variables. Derivatives of variables with an index
+// larger or equal to n are dropped.
+func (a *SparseReal) Alloc(n int) {
+  k := sort.SearchInts(a.Index, n)
+  a.Index      = a.Index     [0:k]
+  a.Derivative = a.Derivative[0:k]
+  a.N          = n
+}
+
+// Memory for derivatives is allocated when the result of an operation is
+// computed, hence only the order and number of variables are copied.
+func (c *SparseReal) AllocForOne(a Scalar) {
+  c.Order = iMin(a.GetOrder(), 1)
+  c.Alloc(a.GetN())
+}
+
+func (c *SparseReal) AllocForTwo(a, b Scalar) {
+  c.Order = iMin(iMax(a.GetOrder(), b.GetOrder()), 1)
+  c.Alloc(iMax(a.GetN(), b.GetN()))
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *SparseReal) GetOrder() int {
+  return a.Order
+}
+
+func (a *SparseReal) GetValue() float64 {
+  return a.Value
+}
+
+func (a *SparseReal) GetLogValue() float64 {
+  return math.Log(a.Value)
+}
+
+// Returns the ith derivative of the jth variable. Second derivatives are
+// not computed and always zero.
+func (a *SparseReal) GetDerivative(i, j int) float64 {
+  if i != 1 && i != 2 {
+    panic("Invalid order!")
+  }
+  if i == 2 {
+    return 0.0
+  }
+  if k := sort.SearchInts(a.Index, j); k < len(a.Index) && a.Index[k] == j {
+    return a.Derivative[k]
+  }
+  return 0.0
+}
+
+func (a *SparseReal) GetHessian(i, j int) float64 {
+  return 0.0
+}
+
+func (a *SparseReal) GetN() int {
+  return a.N
+}
+
+// Number of non-zero derivatives.
+func (a *SparseReal) GetNnz() int {
+  return len(a.Index)
+}
+
+func (a *SparseReal) SetN(n int) {
+  a.Alloc(n)
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *SparseReal) Reset() {
+  a.Value = 0.0
+  a.ResetDerivatives()
+}
+
+func (a *SparseReal) ResetDerivatives() {
+  a.Index      = a.Index     [0:0]
+  a.Derivative = a.Derivative[0:0]
+}
+
+func (a *SparseReal) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Set only the value of the variable.
+func (a *SparseReal) SetValue(v float64) {
+  a.Value = v
+}
+
+// Set the ith derivative of the jth variable to v.
+func (a *SparseReal) SetDerivative(i, j int, v float64) {
+  if i != 1 && i != 2 {
+    panic("Invalid order!")
+  }
+  if i == 2 {
+    return
+  }
+  k := sort.SearchInts(a.Index, j)
+  if k < len(a.Index) && a.Index[k] == j {
+    a.Derivative[k] = v
+    return
+  }
+  a.Index      = append(a.Index, 0)
+  a.Derivative = append(a.Derivative, 0.0)
+  copy(a.Index     [k+1:], a.Index     [k:])
+  copy(a.Derivative[k+1:], a.Derivative[k:])
+  a.Index     [k] = j
+  a.Derivative[k] = v
+}
+
+func (a *SparseReal) SetHessian(i, j int, v float64) {
+}
+
+// Declare this scalar as the ith of n variables.
+func (a *SparseReal) SetVariable(i, n, order int) {
+  a.Order = iMin(order, 1)
+  a.N     = n
+  a.ResetDerivatives()
+  if order > 0 {
+    a.Index      = append(a.Index, i)
+    a.Derivative = append(a.Derivative, 1.0)
+  }
+}
@@ -0,0 +1,142 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+/* -------------------------------------------------------------------------- */
+
+// Get the non-zero derivatives of a scalar. Derivatives of scalars of
+// other types are collected from the dense representation.
+func sparseDerivativesOf(a Scalar) ([]int, []float64) {
+  if r, ok := a.(*SparseReal); ok {
+    return r.Index, r.Derivative
+  }
+  if a.GetOrder() == 0 {
+    return nil, nil
+  }
+  index      := []int{}
+  derivative := []float64{}
+  for i := 0; i < a.GetN(); i++ {
+    if v := a.GetDerivative(1, i); v != 0.0 {
+      index      = append(index, i)
+      derivative = append(derivative, v)
+    }
+  }
+  return index, derivative
+}
+
+/* derivatives of monadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *SparseReal) monadic(a Scalar, v0, v1 float64) Scalar {
+  return c.monadicLazy(a, v0, func() float64 { return v1 })
+}
+
+func (c *SparseReal) monadicLazy(a Scalar, v0 float64, f1 func() float64) Scalar {
+  index, derivative := sparseDerivativesOf(a)
+  c.AllocForOne(a)
+  if c.Order >= 1 && len(index) > 0 {
+    v1 := f1()
+    // scaling is safe if c and a share memory
+    c.Index      = append(c.Index     [0:0], index...)
+    c.Derivative = append(c.Derivative[0:0], derivative...)
+    for i := 0; i < len(c.Derivative); i++ {
+      c.Derivative[i] *= v1
+    }
+  } else {
+    c.ResetDerivatives()
+  }
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+/* derivatives of dyadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *SparseReal) dyadic(a, b Scalar, v0, v10, v01 float64) Scalar {
+  return c.dyadicLazy(a, b, v0, func() (float64, float64) { return v10, v01 })
+}
+
+func (c *SparseReal) dyadicLazy(a, b Scalar, v0 float64, f1 func() (float64, float64)) Scalar {
+  ia, da := sparseDerivativesOf(a)
+  ib, db := sparseDerivativesOf(b)
+  order  := iMin(iMax(a.GetOrder(), b.GetOrder()), 1)
+  n      := iMax(a.GetN(), b.GetN())
+  switch {
+  case len(ib) == 0:
+    c.monadicLazy(a, v0, func() float64 { v10, _ := f1(); return v10 })
+    c.Order, c.N = order, n
+    return c
+  case len(ia) == 0:
+    c.monadicLazy(b, v0, func() float64 { _, v01 := f1(); return v01 })
+    c.Order, c.N = order, n
+    return c
+  }
+  c.AllocForTwo(a, b)
+  v10, v01 := f1()
+  // merge derivatives into new memory if c is one of the operands
+  index      := c.Index     [0:0]
+  derivative := c.Derivative[0:0]
+  if c == a || c == b {
+    index      = make([]int,     0, len(ia)+len(ib))
+    derivative = make([]float64, 0, len(ia)+len(ib))
+  }
+  i, j := 0, 0
+  for i < len(ia) || j < len(ib) {
+    switch {
+    case j == len(ib) || (i < len(ia) && ia[i] < ib[j]):
+      index      = append(index, ia[i])
+      derivative = append(derivative, da[i]*v10)
+      i++
+    case i == len(ia) || ib[j] < ia[i]:
+      index      = append(index, ib[j])
+      derivative = append(derivative, db[j]*v01)
+      j++
+    default:
+      index      = append(index, ia[i])
+      derivative = append(derivative, da[i]*v10 + db[j]*v01)
+      i++; j++
+    }
+  }
+  c.Index      = index
+  c.Derivative = derivative
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *SparseReal) StopGradient(a Scalar) Scalar {
+  return c.monadic(a, a.GetValue(), 0.0)
+}
+
+/* user-defined functions, second derivatives are not recorded
+ * -------------------------------------------------------------------------- */
+
+func (c *SparseReal) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  return c.monadic(a, v0, v1)
+}
+
+func (c *SparseReal) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  return c.dyadic(a, b, v0, v10, v01)
+}
@@ -0,0 +1,135 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestSparseReal1(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // y = x0^3 sin(x1) + exp(x0 x1) / x2
+    y := Mul(Pow(x[0], NewBareReal(3)), Sin(x[1]))
+    y  = Add(y, Div(Exp(Mul(x[0], x[1])), x[2]))
+    return y
+  }
+  x1 := NewVector(RealType,       []float64{1.2, 0.7, 2.3})
+  x2 := NewVector(SparseRealType, []float64{1.2, 0.7, 2.3})
+
+  Variables(1, x1...)
+  Variables(1, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+    t.Error("SparseReal test failed!")
+  }
+  if y2.GetN() != 3 {
+    t.Error("SparseReal test failed!")
+  }
+  for i := 0; i < 3; i++ {
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("SparseReal test failed!")
+    }
+  }
+}
+
+func TestSparseReal2(t *testing.T) {
+
+  n := 1000
+  x := NullVector(SparseRealType, n)
+  for i := 0; i < n; i++ {
+    x[i].SetValue(float64(i)/float64(n))
+  }
+  Variables(1, x...)
+
+  // residuals depend only on neighbouring variables
+  r := NullVector(SparseRealType, n-1)
+  for i := 0; i < n-1; i++ {
+    r[i] = Sub(Mul(x[i], x[i]), x[i+1])
+  }
+  for i := 0; i < n-1; i++ {
+    if r[i].(*SparseReal).GetNnz() != 2 {
+      t.Error("SparseReal test failed!")
+    }
+  }
+  s := Vnorm(r)
+  if s.(*SparseReal).GetNnz() != n {
+    t.Error("SparseReal test failed!")
+  }
+  // y = x[3]^2 - x[4]
+  if math.Abs(r[3].GetDerivative(1, 3) - 2.0*x[3].GetValue()) > 1e-12 ||
+     math.Abs(r[3].GetDerivative(1, 4) + 1.0) > 1e-12 ||
+     r[3].GetDerivative(1, 5) != 0.0 {
+    t.Error("SparseReal test failed!")
+  }
+}
+
+func TestSparseReal3(t *testing.T) {
+
+  f := func(x Vector) Vector {
+    y := NullVector(x.ElementType(), 2)
+    y[0] = Mul(x[0], Exp(x[2]))
+    y[1] = Sub(Log(x[1]), x[0])
+    return y
+  }
+  x1 := NewVector(RealType,       []float64{0.5, 1.5, 2.5})
+  x2 := NewVector(SparseRealType, []float64{0.5, 1.5, 2.5})
+
+  j1 := Jacobian(f, x1)
+  j2 := Jacobian(f, x2)
+
+  for i := 0; i < 2; i++ {
+    for j := 0; j < 3; j++ {
+      if math.Abs(j1.At(i, j).GetValue() - j2.At(i, j).GetValue()) > 1e-12 {
+        t.Error("SparseReal test failed!")
+      }
+    }
+  }
+}
+
+func TestSparseReal4(t *testing.T) {
+
+  x := NewReal(2.0)
+  y := NewSparseReal(3.0)
+
+  Variables(1, x)
+
+  // non-zero derivatives of x are copied
+  z := Mul(y, Pow(x, NewBareReal(2)))
+
+  if z.GetN() != 1 || math.Abs(z.GetDerivative(1, 0) - 12) > 1e-12 {
+    t.Error("SparseReal test failed!")
+  }
+  r := NullReal()
+  r.Copy(z)
+  if math.Abs(r.GetDerivative(1, 0) - 12) > 1e-12 {
+    t.Error("SparseReal test failed!")
+  }
+  // in-place updates must not change the operands
+  w := z.Clone()
+  w.Mul(w, w)
+  if math.Abs(z.GetDerivative(1, 0) - 12) > 1e-12 {
+    t.Error("SparseReal test failed!")
+  }
+}
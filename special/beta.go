@@ -0,0 +1,37 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
+// logarithm of the absolute value of the beta function and its sign
+func Lbeta(a, b float64) (float64, int) {
+  la, sa := math.Lgamma(a)
+  lb, sb := math.Lgamma(b)
+  lc, sc := math.Lgamma(a+b)
+  return la + lb - lc, sa*sb*sc
+}
+
+// beta function Gamma(a) Gamma(b) / Gamma(a+b)
+func Beta(a, b float64) float64 {
+  v, s := Lbeta(a, b)
+  return float64(s)*math.Exp(v)
+}
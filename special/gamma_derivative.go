@@ -0,0 +1,125 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+import "math"
+
+/* Derivatives of the regularized incomplete gamma functions with respect
+ * to the shape parameter
+ * -------------------------------------------------------------------------- */
+
+// Truncated power series with derivatives for x < a+1, where
+//
+// P(a,x) = x^a e^-x / Gamma(a+1) sum_n c_n, c_n = prod_{k=1}^n x/(a+k)
+//
+func gamma_p_shape_derivatives_series(a, x float64) (float64, float64) {
+  s0, s1, s2 := 1.0, 0.0, 0.0
+  // c_n and the derivatives of log c_n
+  c, h, q := 1.0, 0.0, 0.0
+  for n := 1; n < SeriesIterationsMax; n++ {
+    k  := a + float64(n)
+    c  *= x/k
+    h  -= 1.0/k
+    q  += 1.0/(k*k)
+    s0 += c
+    s1 += c*h
+    s2 += c*(h*h + q)
+    if c <= 2.22045e-16*s0 && c*(h*h + q) <= 2.22045e-16*s2 {
+      break
+    }
+  }
+  p := GammaP(a, x)
+  // derivatives of log P(a,x)
+  g1 := math.Log(x) - Digamma(a+1.0) + s1/s0
+  g2 := -Trigamma(a+1.0) + s2/s0 - s1*s1/(s0*s0)
+  return p*g1, p*(g2 + g1*g1)
+}
+
+// Number of terms required by the continued fraction of Q(a,x).
+func gamma_q_fraction_terms(a, x float64) int {
+  f := NewUpperIncompleteGammaFraction(a, x)
+  tiny := math.SmallestNonzeroFloat64
+  _, b := f.Eval()
+  C := b
+  D := 0.0
+  for i := 1; i < SeriesIterationsMax; i++ {
+    ai, bi := f.Eval()
+    if D = bi + ai*D; D == 0.0 {
+      D = tiny
+    }
+    if C = bi + ai/C; C == 0.0 {
+      C = tiny
+    }
+    D = 1.0/D
+    if math.Abs(C*D - 1.0) <= 2.22045e-16 {
+      return i+1
+    }
+  }
+  return SeriesIterationsMax
+}
+
+// Continued fraction with derivatives for x >= a+1, where
+//
+// Q(a,x) = x^a e^-x / Gamma(a) / (x - a + 1 + a_1/(b_1 + a_2/(b_2 + ...)))
+//
+// with a_k = k(a-k) and b_k = x - a + 1 + 2k. The fraction is evaluated
+// backwards, propagating first and second derivatives with respect to a.
+func gamma_p_shape_derivatives_fraction(a, x float64) (float64, float64) {
+  n := gamma_q_fraction_terms(a, x) + 10
+  // t = b_n
+  t0, t1, t2 := x - a + 1.0 + 2.0*float64(n), -1.0, 0.0
+  for k := n-1; k >= 0; k-- {
+    // t = b_k + a_{k+1}/t
+    j  := float64(k+1)
+    u0 := j*(a - j)
+    u1 := j
+    w0 := u0/t0
+    w1 := (u1 - w0*t1)/t0
+    w2 := (-2.0*w1*t1 - w0*t2)/t0
+    t0, t1, t2 = x - a + 1.0 + 2.0*float64(k) + w0, -1.0 + w1, w2
+  }
+  // derivatives of log Q(a,x)
+  g1 := math.Log(x) - Digamma(a) - t1/t0
+  g2 := -Trigamma(a) - t2/t0 + t1*t1/(t0*t0)
+  q  := GammaQ(a, x)
+  return -q*g1, -q*(g2 + g1*g1)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// First and second derivative of the regularized lower incomplete gamma
+// function P(a,x) with respect to the shape parameter a.
+func GammaPshapeDerivatives(a, x float64) (float64, float64) {
+  if a <= 0.0 || x < 0.0 || math.IsNaN(a) || math.IsNaN(x) {
+    return math.NaN(), math.NaN()
+  }
+  if x == 0.0 {
+    return 0.0, 0.0
+  }
+  if x < a + 1.0 {
+    return gamma_p_shape_derivatives_series(a, x)
+  } else {
+    return gamma_p_shape_derivatives_fraction(a, x)
+  }
+}
+
+// Mixed second derivative of P(a,x) with respect to a and x.
+func GammaPmixedDerivative(a, x float64) float64 {
+  return GammaPfirstDerivative(a, x)*(math.Log(x) - Digamma(a))
+}
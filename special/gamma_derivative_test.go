@@ -0,0 +1,60 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestGammaPshapeDerivatives(t *testing.T) {
+  // compare with central differences on both sides of x = a+1, where the
+  // series and the continued fraction are used
+  r := [][]float64{
+    { 0.5, 0.2 }, { 2.5, 1.0 }, { 2.5, 6.0 }, { 9.125, 4.321 }, { 30.0, 25.0 }, { 50.0, 70.0 } }
+
+  for i := 0; i < len(r); i++ {
+    a, x := r[i][0], r[i][1]
+    h := 1e-4*a
+    d1, d2 := GammaPshapeDerivatives(a, x)
+    e1 := (GammaP(a+h, x) - GammaP(a-h, x))/(2.0*h)
+    e2 := (GammaP(a+h, x) - 2.0*GammaP(a, x) + GammaP(a-h, x))/(h*h)
+    if math.Abs(d1 - e1) > 1e-7 {
+      t.Errorf("test %d failed: %v != %v", i, d1, e1)
+    }
+    if math.Abs(d2 - e2) > 1e-4 {
+      t.Errorf("test %d failed: %v != %v", i, d2, e2)
+    }
+    e3 := (GammaPfirstDerivative(a+h, x) - GammaPfirstDerivative(a-h, x))/(2.0*h)
+    if d := GammaPmixedDerivative(a, x); math.Abs(d - e3) > 1e-7 {
+      t.Errorf("test %d failed: %v != %v", i, d, e3)
+    }
+  }
+}
+
+func TestGammaPlarge(t *testing.T) {
+  // regression test for the uniform asymptotic expansion
+  if r := GammaP(30.0, 25.0); math.Abs(r - 0.1821039159774551) > 1e-12 {
+    t.Errorf("test failed: %v", r)
+  }
+  if r := GammaP(50.0, 50.0); math.Abs(r - 0.5188083154720433) > 1e-12 {
+    t.Errorf("test failed: %v", r)
+  }
+}
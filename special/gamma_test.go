@@ -2177,3 +2177,16 @@ func TestGammaP(t *testing.T) {
     }
   }
 }
+
+func TestGammaPTemme(t *testing.T) {
+  // arguments for which Temme's uniform asymptotic expansion is used
+  r := [][]float64{
+    {30, 33, 0.72269862906858876783},
+    {50, 45, 0.24680203440016584695},
+    {25, 22, 0.28828052442293256320} }
+  for i := 0; i < len(r); i++ {
+    if v := GammaP(r[i][0], r[i][1]); math.Abs(v - r[i][2]) > 1e-12 {
+      t.Errorf("GammaP() failed for (%f, %f): value=%e, target=%e", r[i][0], r[i][1], v, r[i][2])
+    }
+  }
+}
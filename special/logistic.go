@@ -0,0 +1,47 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
+// logistic function 1/(1+exp(-x))
+func Logistic(x float64) float64 {
+  if x >= 0.0 {
+    return 1.0/(1.0+math.Exp(-x))
+  } else {
+    e := math.Exp(x)
+    return e/(1.0+e)
+  }
+}
+
+// logarithm of the logistic function, i.e. -softplus(-x)
+func LogLogistic(x float64) float64 {
+  return -Softplus(-x)
+}
+
+// softplus function log(1+exp(x))
+func Softplus(x float64) float64 {
+  if x > 0.0 {
+    return x + math.Log1p(math.Exp(-x))
+  } else {
+    return math.Log1p(math.Exp(x))
+  }
+}
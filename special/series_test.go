@@ -0,0 +1,46 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+type geometricSeries struct {
+  term float64
+}
+
+func (s *geometricSeries) Eval() float64 {
+  r := s.term
+  s.term /= 2.0
+  return r
+}
+
+func TestSumSeries(t *testing.T) {
+  // 3 + (1 + 1/2 + 1/4 + ...) = 5
+  if r := SumSeries(&geometricSeries{1.0}, 3.0, 1e-16, 1000); math.Abs(r - 5.0) > 1e-14 {
+    t.Error("SumSeries failed!")
+  }
+  // the initial value is added to the lower gamma series
+  if r := lower_gamma_series(2.2, 10, 1.0) - lower_gamma_series(2.2, 10, 0.0); math.Abs(r - 1.0) > 1e-12 {
+    t.Error("lower_gamma_series failed!")
+  }
+}
@@ -0,0 +1,94 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
+// Derivative of order m of the Riemann zeta function computed with the
+// Euler-Maclaurin summation formula
+//
+// zeta(s) = sum_{n=1}^{N-1} n^-s + N^(1-s)/(s-1) + N^-s/2
+//         + sum_{k=1}^{M} B_2k/(2k)! s(s+1)...(s+2k-2) N^(-s-2k+1)
+//
+// where each term is differentiated analytically.
+func zeta_derivative_imp(m int, s float64) float64 {
+  const N = 20
+  const M = 12
+  n := float64(N)
+  l := math.Log(n)
+  // binomial coefficients
+  binom := make([]float64, m+1)
+  binom[0] = 1.0
+  for i := 1; i <= m; i++ {
+    binom[i] = binom[i-1]*float64(m-i+1)/float64(i)
+  }
+  result := 0.0
+  for j := 1; j < N; j++ {
+    result += math.Pow(-math.Log(float64(j)), float64(m))*math.Pow(float64(j), -s)
+  }
+  // N^-s/2
+  result += math.Pow(-l, float64(m))*math.Pow(n, -s)/2.0
+  // N^(1-s)/(s-1), where the ith derivative of 1/(s-1) is
+  // (-1)^i i!/(s-1)^(i+1)
+  for i, f := 0, 1.0; i <= m; i++ {
+    if i > 0 {
+      f *= -float64(i)
+    }
+    result += binom[i]*math.Pow(-l, float64(m-i))*math.Pow(n, 1.0-s)*f/math.Pow(s-1.0, float64(i+1))
+  }
+  // derivatives of the polynomial s(s+1)...(s+2k-2)
+  p := make([]float64, m+1)
+  p[0] = 1.0
+  mul := func(c float64) {
+    for i := m; i > 0; i-- {
+      p[i] = p[i]*(s+c) + float64(i)*p[i-1]
+    }
+    p[0] *= s+c
+  }
+  f := 1.0
+  for k := 1; k <= M; k++ {
+    if k == 1 {
+      mul(0.0)
+    } else {
+      mul(float64(2*k-3))
+      mul(float64(2*k-2))
+    }
+    f *= float64((2*k-1)*(2*k))
+    e := math.Pow(n, -s-float64(2*k)+1.0)
+    t := 0.0
+    for i := 0; i <= m; i++ {
+      t += binom[i]*p[m-i]*math.Pow(-l, float64(i))
+    }
+    result += BernoulliNumber(2*k)/f*t*e
+  }
+  return result
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Derivative of order m of the Riemann zeta function. For m = 0 the zeta
+// function itself is returned.
+func ZetaDerivative(m int, s float64) float64 {
+  if m == 0 {
+    return Zeta(s)
+  }
+  return zeta_derivative_imp(m, s)
+}
@@ -0,0 +1,42 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package special
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestZetaDerivative(t *testing.T) {
+  r := [][]float64{
+    // s, zeta'(s), zeta''(s)
+    {  0.0, -9.18938533204672741781e-01, -2.00635645590858485121e+00 },
+    {  2.0, -9.37548254315843753702e-01,  1.98928023429890102342e+00 },
+    { -1.0, -1.65421143700450929213e-01, -2.50204424110e-01 } }
+
+  for i := 0; i < len(r); i++ {
+    if d := ZetaDerivative(1, r[i][0]); math.Abs(d - r[i][1]) > 1e-12 {
+      t.Errorf("test %d failed: %v != %v", i, d, r[i][1])
+    }
+    if d := ZetaDerivative(2, r[i][0]); math.Abs(d - r[i][2]) > 1e-10 {
+      t.Errorf("test %d failed: %v != %v", i, d, r[i][2])
+    }
+  }
+}
@@ -0,0 +1,203 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+
+/* -------------------------------------------------------------------------- */
+
+// A TapeReal implements reverse-mode differentiation. Instead of carrying
+// a dense vector of derivatives, every operation records a node on a tape
+// that references its operands together with the local partial
+// derivatives. The full gradient is computed with a single backward sweep
+// when a derivative is first requested. Only first derivatives are
+// supported.
+type TapeReal struct {
+  Value float64
+  node  *tapeNode
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var TapeRealType ScalarType = NewTapeReal(0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return NewTapeReal(value) }
+  RegisterScalar(TapeRealType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a new real constant or variable.
+func NewTapeReal(v float64) *TapeReal {
+  return &TapeReal{Value: v}
+}
+
+func NullTapeReal() *TapeReal {
+  return &TapeReal{Value: 0.0}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *TapeReal) Clone() Scalar {
+  r := NewTapeReal(0.0)
+  r.Copy(a)
+  return r
+}
+
+func (a *TapeReal) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *TapeReal) String() string {
+  return fmt.Sprintf("%e", a.GetValue())
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Copy value and tape position from b. If b is not a TapeReal, its
+// derivatives are recorded as a new leaf on the tape.
+func (a *TapeReal) Copy(b Scalar) {
+  if r, ok := b.(*TapeReal); ok {
+    a.Value = r.Value
+    a.node  = r.node
+  } else {
+    a.Value = b.GetValue()
+    a.node  = tapeNodeOf(b)
+  }
+}
+
+// Memory for derivatives is never allocated in advance, hence the
+// following methods have no effect.
+func (a *TapeReal) Alloc(n int) {
+}
+
+func (c *TapeReal) AllocForOne(a Scalar) {
+}
+
+func (c *TapeReal) AllocForTwo(a, b Scalar) {
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *TapeReal) GetOrder() int {
+  if a.node == nil {
+    return 0
+  }
+  return 1
+}
+
+func (a *TapeReal) GetValue() float64 {
+  return a.Value
+}
+
+func (a *TapeReal) GetLogValue() float64 {
+  return math.Log(a.Value)
+}
+
+// Returns the ith derivative of the jth variable. The first call triggers
+// the backward sweep, subsequent calls use the cached gradient. Second
+// derivatives are not computed and always zero.
+func (a *TapeReal) GetDerivative(i, j int) float64 {
+  if i != 1 && i != 2 {
+    panic("Invalid order!")
+  }
+  if i == 2 || a.node == nil {
+    return 0.0
+  }
+  if g := a.node.backward(); j < len(g) {
+    return g[j]
+  }
+  return 0.0
+}
+
+func (a *TapeReal) GetHessian(i, j int) float64 {
+  return 0.0
+}
+
+func (a *TapeReal) GetN() int {
+  if a.node == nil {
+    return 0
+  }
+  return a.node.n
+}
+
+func (a *TapeReal) SetN(n int) {
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *TapeReal) Reset() {
+  a.Value = 0.0
+  a.node  = nil
+}
+
+func (a *TapeReal) ResetDerivatives() {
+  a.node = nil
+}
+
+func (a *TapeReal) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Set only the value of the variable.
+func (a *TapeReal) SetValue(v float64) {
+  a.Value = v
+}
+
+// Set the ith derivative of the jth variable to v. This replaces the
+// recorded history of the variable by a leaf that holds the gradient
+// explicitly.
+func (a *TapeReal) SetDerivative(i, j int, v float64) {
+  if i != 1 && i != 2 {
+    panic("Invalid order!")
+  }
+  if i == 2 {
+    return
+  }
+  n := iMax(a.GetN(), j+1)
+  g := make([]float64, n)
+  if a.node != nil {
+    copy(g, a.node.backward())
+  }
+  g[j] = v
+  a.node = newTapeLeaf(g)
+}
+
+func (a *TapeReal) SetHessian(i, j int, v float64) {
+}
+
+// Declare this scalar as the ith of n variables. The tape position
+// is reset to a new leaf.
+func (a *TapeReal) SetVariable(i, n, order int) {
+  if order > 0 {
+    a.node = newTapeVariable(i, n, 1.0)
+  } else {
+    a.node = nil
+  }
+}
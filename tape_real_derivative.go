@@ -0,0 +1,217 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "sort"
+import "sync/atomic"
+
+/* tape
+ * -------------------------------------------------------------------------- */
+
+// Nodes are numbered in the order of their creation, which guarantees
+// that operands always have a smaller id than the result.
+var tapeCounter uint64
+
+type tapeNode struct {
+  id       uint64
+  // number of variables
+  n        int
+  // operands and partial derivatives with respect to them
+  parents  [2]*tapeNode
+  partials [2]float64
+  // leafs either refer to a scaled variable or hold an explicit
+  // gradient
+  index    int
+  weight   float64
+  seed     []float64
+  // gradient computed by the backward sweep
+  gradient []float64
+}
+
+func newTapeNode(n int) *tapeNode {
+  return &tapeNode{id: atomic.AddUint64(&tapeCounter, 1), n: n, index: -1}
+}
+
+func newTapeVariable(i, n int, w float64) *tapeNode {
+  r := newTapeNode(n)
+  r.index  = i
+  r.weight = w
+  return r
+}
+
+func newTapeLeaf(seed []float64) *tapeNode {
+  r := newTapeNode(len(seed))
+  r.seed = seed
+  return r
+}
+
+// Get the tape position of a scalar. Derivatives of scalars of other
+// types are copied to a new leaf.
+func tapeNodeOf(a Scalar) *tapeNode {
+  if r, ok := a.(*TapeReal); ok {
+    return r.node
+  }
+  if a.GetOrder() == 0 || a.GetN() == 0 {
+    return nil
+  }
+  seed := make([]float64, a.GetN())
+  for i := 0; i < len(seed); i++ {
+    seed[i] = a.GetDerivative(1, i)
+  }
+  return newTapeLeaf(seed)
+}
+
+/* backward sweep
+ * -------------------------------------------------------------------------- */
+
+func (r *tapeNode) backward() []float64 {
+  if r.gradient != nil {
+    return r.gradient
+  }
+  // collect all nodes that contribute to r
+  nodes := []*tapeNode{}
+  index := make(map[*tapeNode]int)
+  stack := []*tapeNode{r}
+  for len(stack) > 0 {
+    node := stack[len(stack)-1]
+    stack = stack[0:len(stack)-1]
+    if _, ok := index[node]; ok {
+      continue
+    }
+    index[node] = 0
+    nodes = append(nodes, node)
+    for _, p := range node.parents {
+      if p != nil {
+        stack = append(stack, p)
+      }
+    }
+  }
+  // sort nodes in reverse order of creation
+  sort.Slice(nodes, func(i, j int) bool { return nodes[i].id > nodes[j].id })
+  for i, node := range nodes {
+    index[node] = i
+  }
+  // propagate adjoints
+  adjoint := make([]float64, len(nodes))
+  adjoint[0] = 1.0
+  g := make([]float64, r.n)
+  for i, node := range nodes {
+    if node.index >= 0 {
+      g[node.index] += adjoint[i]*node.weight
+    }
+    for j, v := range node.seed {
+      g[j] += adjoint[i]*v
+    }
+    for j, p := range node.parents {
+      if p != nil {
+        adjoint[index[p]] += adjoint[i]*node.partials[j]
+      }
+    }
+  }
+  r.gradient = g
+  return g
+}
+
+/* derivatives of monadic functions
+ * -------------------------------------------------------------------------- */
+
+// Record a new node with a single operand p. Functions of a single
+// variable are again recorded as scaled variables, which prevents the
+// tape from growing when variables are updated in place (e.g. x = x - d).
+func newTapeMonadic(p *tapeNode, v1 float64) *tapeNode {
+  if p.index >= 0 {
+    return newTapeVariable(p.index, p.n, p.weight*v1)
+  }
+  r := newTapeNode(p.n)
+  r.parents [0] = p
+  r.partials[0] = v1
+  return r
+}
+
+func (c *TapeReal) monadic(a Scalar, v0, v1 float64) Scalar {
+  if p := tapeNodeOf(a); p != nil {
+    c.node = newTapeMonadic(p, v1)
+  } else {
+    c.node = nil
+  }
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+func (c *TapeReal) monadicLazy(a Scalar, v0 float64, f1 func() float64) Scalar {
+  if p := tapeNodeOf(a); p != nil {
+    c.node = newTapeMonadic(p, f1())
+  } else {
+    c.node = nil
+  }
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+/* derivatives of dyadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *TapeReal) dyadic(a, b Scalar, v0, v10, v01 float64) Scalar {
+  return c.dyadicLazy(a, b, v0, func() (float64, float64) { return v10, v01 })
+}
+
+func (c *TapeReal) dyadicLazy(a, b Scalar, v0 float64, f1 func() (float64, float64)) Scalar {
+  p := tapeNodeOf(a)
+  q := tapeNodeOf(b)
+  switch {
+  case p == nil && q == nil:
+    c.node = nil
+  case q == nil:
+    v10, _ := f1()
+    c.node = newTapeMonadic(p, v10)
+  case p == nil:
+    _, v01 := f1()
+    c.node = newTapeMonadic(q, v01)
+  default:
+    v10, v01 := f1()
+    c.node = newTapeNode(iMax(p.n, q.n))
+    c.node.parents  = [2]*tapeNode{p, q}
+    c.node.partials = [2]float64{v10, v01}
+  }
+  // compute new value
+  c.Value = v0
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *TapeReal) StopGradient(a Scalar) Scalar {
+  c.Value = a.GetValue()
+  c.node  = nil
+  return c
+}
+
+/* user-defined functions, second derivatives are not recorded
+ * -------------------------------------------------------------------------- */
+
+func (c *TapeReal) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  return c.monadic(a, v0, v1)
+}
+
+func (c *TapeReal) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  return c.dyadic(a, b, v0, v10, v01)
+}
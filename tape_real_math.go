@@ -0,0 +1,400 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+func (a *TapeReal) Equals(b Scalar) bool {
+  epsilon := 1e-12
+  return math.Abs(a.GetValue() - b.GetValue()) < epsilon
+}
+
+func (a *TapeReal) Greater(b Scalar) bool {
+  return a.GetValue() > b.GetValue()
+}
+
+func (a *TapeReal) Smaller(b Scalar) bool {
+  return a.GetValue() < b.GetValue()
+}
+
+func (a *TapeReal) Min(b Scalar) Scalar {
+  switch {
+  case a.GetValue() < b.GetValue():
+    return a
+  case a.GetValue() > b.GetValue():
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+func (a *TapeReal) Max(b Scalar) Scalar {
+  switch {
+  case a.GetValue() > b.GetValue():
+    return a
+  case a.GetValue() < b.GetValue():
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+// The derivative of |a| at zero is set to zero.
+func (c *TapeReal) Abs(a Scalar) Scalar {
+  switch a.Sign() {
+  case -1:
+    return c.Neg(a)
+  case  1:
+    c.Copy(a)
+    return c
+  }
+  return c.StopGradient(a)
+}
+
+func (a *TapeReal) Sign() int {
+  if a.GetValue() < 0.0 {
+    return -1
+  }
+  if a.GetValue() > 0.0 {
+    return  1
+  }
+  return 0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *TapeReal) Neg(a Scalar) Scalar {
+  return c.monadic(a, -a.GetValue(), -1)
+}
+
+func (c *TapeReal) Add(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  return c.dyadic(a, b, x+y, 1, 1)
+}
+
+func (c *TapeReal) Sub(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  return c.dyadic(a, b, x-y, 1, -1)
+}
+
+func (c *TapeReal) Mul(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  return c.dyadic(a, b, x*y, y, x)
+}
+
+func (c *TapeReal) Div(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  return c.dyadic(a, b, x/y, 1/y, -x/(y*y))
+}
+
+func (c *TapeReal) Pow(a, k Scalar) Scalar {
+  x := a.GetValue()
+  y := k.GetValue()
+  v0 := math.Pow(x, y)
+  if k.GetOrder() >= 1 {
+    f1 := func() (float64, float64) {
+      f10 := math.Pow(x, y-1)*y
+      f01 := math.Pow(x, y-0)*math.Log(x)
+      return f10, f01
+    }
+    return c.dyadicLazy(a, k, v0, f1)
+  } else {
+    f1 := func() float64 {
+      return math.Pow(x, y-1)*y
+    }
+    return c.monadicLazy(a, v0, f1)
+  }
+}
+
+func (c *TapeReal) Sqrt(a Scalar) Scalar {
+  return c.Pow(a, NewBareReal(1.0/2.0))
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *TapeReal) Sin(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return  math.Cos(x) }
+  return c.monadicLazy(a, math.Sin(x), f1)
+}
+
+func (c *TapeReal) Sinh(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return  math.Cosh(x) }
+  return c.monadicLazy(a, math.Sinh(x), f1)
+}
+
+func (c *TapeReal) Cos(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return -math.Sin(x) }
+  return c.monadicLazy(a, math.Cos(x), f1)
+}
+
+func (c *TapeReal) Cosh(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return  math.Sinh(x) }
+  return c.monadicLazy(a, math.Cosh(x), f1)
+}
+
+func (c *TapeReal) Tan(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return  1.0+math.Pow(math.Tan(x), 2) }
+  return c.monadicLazy(a, math.Tan(x), f1)
+}
+
+func (c *TapeReal) Tanh(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return  1.0-math.Pow(math.Tanh(x), 2) }
+  return c.monadicLazy(a, math.Tanh(x), f1)
+}
+
+func (c *TapeReal) Asin(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Asin(x), 1.0/math.Sqrt(1.0-x*x))
+}
+
+func (c *TapeReal) Acos(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Acos(x), -1.0/math.Sqrt(1.0-x*x))
+}
+
+func (c *TapeReal) Atan(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Atan(x), 1.0/(1.0+x*x))
+}
+
+func (c *TapeReal) Atan2(a, b Scalar) Scalar {
+  y := a.GetValue()
+  x := b.GetValue()
+  f1 := func() (float64, float64) {
+    r := x*x + y*y
+    return x/r, -y/r
+  }
+  return c.dyadicLazy(a, b, math.Atan2(y, x), f1)
+}
+
+func (c *TapeReal) Asinh(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Asinh(x), 1.0/math.Sqrt(x*x+1.0))
+}
+
+func (c *TapeReal) Acosh(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Acosh(x), 1.0/math.Sqrt(x*x-1.0))
+}
+
+func (c *TapeReal) Atanh(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Atanh(x), 1.0/(1.0-x*x))
+}
+
+func (c *TapeReal) Exp(a Scalar) Scalar {
+  v0 := math.Exp(a.GetValue())
+  return c.monadic(a, v0, v0)
+}
+
+func (c *TapeReal) Log(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Log(x), 1/x)
+}
+
+func (c *TapeReal) Log1p(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Log1p(x), 1/(1+x))
+}
+
+func (c *TapeReal) Expm1(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, math.Expm1(x), math.Exp(x))
+}
+
+func (c *TapeReal) Logistic(a Scalar) Scalar {
+  v0 := special.Logistic(a.GetValue())
+  return c.monadic(a, v0, v0*(1.0-v0))
+}
+
+func (c *TapeReal) Softplus(a Scalar) Scalar {
+  x := a.GetValue()
+  return c.monadic(a, special.Softplus(x), special.Logistic(x))
+}
+
+func (c *TapeReal) Erf(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 {
+    return  2.0/(math.Exp(x*x)*special.M_SQRTPI)
+  }
+  return c.monadicLazy(a, math.Erf(x), f1)
+}
+
+func (c *TapeReal) Erfc(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 {
+    return -2.0/(math.Exp(x*x)*special.M_SQRTPI)
+  }
+  return c.monadicLazy(a, math.Erfc(x), f1)
+}
+
+func (c *TapeReal) LogErfc(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 {
+    return -2.0/(math.Exp(x*x)*special.M_SQRTPI*math.Erfc(x))
+  }
+  return c.monadicLazy(a, special.LogErfc(x), f1)
+}
+
+func (c *TapeReal) Gamma(a Scalar) Scalar {
+  x := a.GetValue()
+  v0 := math.Gamma(x)
+  f1 := func() float64 {
+    return v0*special.Digamma(x)
+  }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *TapeReal) Lgamma(a Scalar) Scalar {
+  x := a.GetValue()
+  v0, s := math.Lgamma(x)
+  if s == -1 {
+    v0 = math.NaN()
+  }
+  f1 := func() float64 { return special.Digamma(x) }
+  return c.monadicLazy(a, v0, f1)
+}
+
+func (c *TapeReal) Mlgamma(a Scalar, k int) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 {
+    s := 0.0
+    for j := 1; j <= k; j++ {
+      s += special.Digamma(x + float64(1-j)/2.0)
+    }
+    return s
+  }
+  return c.monadicLazy(a, special.Mlgamma(x, k), f1)
+}
+
+func (c *TapeReal) GammaP(a, b Scalar) Scalar {
+  f1 := func() (float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return v10, v01
+  }
+  return c.dyadicLazy(a, b, special.GammaP(a.GetValue(), b.GetValue()), f1)
+}
+
+func (c *TapeReal) GammaQ(a, b Scalar) Scalar {
+  f1 := func() (float64, float64) {
+    v10, v01 := gammaPFirstDerivatives(a, b)
+    return -v10, -v01
+  }
+  return c.dyadicLazy(a, b, special.GammaQ(a.GetValue(), b.GetValue()), f1)
+}
+
+func (c *TapeReal) Digamma(a Scalar) Scalar {
+  return c.Polygamma(0, a)
+}
+
+func (c *TapeReal) Trigamma(a Scalar) Scalar {
+  return c.Polygamma(1, a)
+}
+
+func (c *TapeReal) Polygamma(n int, a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return special.Polygamma(n+1, x) }
+  return c.monadicLazy(a, special.Polygamma(n, x), f1)
+}
+
+func (c *TapeReal) Beta(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  v0 := special.Beta(x, y)
+  f1 := func() (float64, float64) {
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v0*v10, v0*v01
+  }
+  return c.dyadicLazy(a, b, v0, f1)
+}
+
+func (c *TapeReal) Lbeta(a, b Scalar) Scalar {
+  x := a.GetValue()
+  y := b.GetValue()
+  v0, s := special.Lbeta(x, y)
+  if s == -1 {
+    v0 = math.NaN()
+  }
+  f1 := func() (float64, float64) {
+    v10, v01, _, _, _ := lbetaDerivatives(x, y)
+    return v10, v01
+  }
+  return c.dyadicLazy(a, b, v0, f1)
+}
+
+func (c *TapeReal) Zeta(a Scalar) Scalar {
+  x := a.GetValue()
+  f1 := func() float64 { return special.ZetaDerivative(1, x) }
+  return c.monadicLazy(a, special.Zeta(x), f1)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (r *TapeReal) VdotV(a, b Vector) Scalar {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r.Reset()
+  t := NullTapeReal()
+  for i := 0; i < len(a); i++ {
+    t.Mul(a[i], b[i])
+    r.Add(r, t)
+  }
+  return r
+}
+
+func (r *TapeReal) Vnorm(a Vector) Scalar {
+  r.Reset()
+  c := NewBareReal(2.0)
+  t := NullTapeReal()
+  for i := 0; i < len(a); i++ {
+    t.Pow(a[i], c)
+    r.Add(r, t)
+  }
+  r.Sqrt(r)
+  return r
+}
+
+func (r *TapeReal) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullTapeReal()
+  t := NullTapeReal()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
@@ -0,0 +1,115 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestTapeReal1(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // y = x0^3 sin(x1) + exp(x0 x1) / x2
+    y := Mul(Pow(x[0], NewBareReal(3)), Sin(x[1]))
+    y  = Add(y, Div(Exp(Mul(x[0], x[1])), x[2]))
+    return y
+  }
+  x1 := NewVector(RealType,     []float64{1.2, 0.7, 2.3})
+  x2 := NewVector(TapeRealType, []float64{1.2, 0.7, 2.3})
+
+  Variables(1, x1...)
+  Variables(1, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+    t.Error("TapeReal test failed!")
+  }
+  if y2.GetN() != 3 {
+    t.Error("TapeReal test failed!")
+  }
+  for i := 0; i < 3; i++ {
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("TapeReal test failed!")
+    }
+  }
+}
+
+func TestTapeReal2(t *testing.T) {
+
+  x := NewTapeReal(1.5)
+  Variables(1, x)
+
+  // y = x^16 computed by repeated squaring
+  y := x.Clone()
+  for i := 0; i < 4; i++ {
+    y.Mul(y, y)
+  }
+  if math.Abs(y.GetDerivative(1, 0) - 16*math.Pow(1.5, 15)) > 1e-8 {
+    t.Error("TapeReal test failed!")
+  }
+  // the history of x must not change
+  if x.GetDerivative(1, 0) != 1.0 {
+    t.Error("TapeReal test failed!")
+  }
+}
+
+func TestTapeReal3(t *testing.T) {
+
+  m1 := NewDenseMatrix(RealType,     2, 2, []float64{1,2,3,4})
+  m2 := NewDenseMatrix(TapeRealType, 2, 2, []float64{1,2,3,4})
+
+  m1.Variables(1)
+  m2.Variables(1)
+
+  s1 := Mnorm(MdotM(m1, m1))
+  s2 := Mnorm(MdotM(m2, m2))
+
+  if math.Abs(s1.GetValue() - s2.GetValue()) > 1e-10 {
+    t.Error("TapeReal test failed!")
+  }
+  for i := 0; i < 4; i++ {
+    if math.Abs(s1.GetDerivative(1, i) - s2.GetDerivative(1, i)) > 1e-8 {
+      t.Error("TapeReal test failed!")
+    }
+  }
+}
+
+func TestTapeReal4(t *testing.T) {
+
+  x := NewReal(2.0)
+  y := NewTapeReal(3.0)
+
+  Variables(1, x)
+
+  // derivatives of x are recorded as a leaf
+  z := Mul(y, Pow(x, NewBareReal(2)))
+
+  if z.GetN() != 1 || math.Abs(z.GetDerivative(1, 0) - 12) > 1e-12 {
+    t.Error("TapeReal test failed!")
+  }
+  r := NullReal()
+  r.Copy(z)
+  if math.Abs(r.GetDerivative(1, 0) - 12) > 1e-12 {
+    t.Error("TapeReal test failed!")
+  }
+}
@@ -0,0 +1,72 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+// Compute the Taylor polynomial of f at x0 up to the given order. The
+// polynomial is given in powers of x - x0, i.e. f(x) is approximated by
+// p.Eval(x - x0). Coefficients are computed with TaylorReal scalars, hence
+// f must return a TaylorReal of the given order or a constant, in which case
+// all coefficients of positive order are zero.
+func Taylor(f func(Scalar) Scalar, x0 float64, order int) special.Polynomial {
+  if order < 0 {
+    panic("Taylor(): invalid order")
+  }
+  x := NewTaylorReal(x0)
+  x.SetVariable(0, 1, order)
+  r := make([]float64, order+1)
+  y := f(x)
+  if y.GetOrder() == 0 {
+    r[0] = y.GetValue()
+    return special.NewPolynomial(r)
+  }
+  if y, ok := y.(*TaylorReal); ok && y.GetOrder() == order {
+    return special.NewPolynomial(taylorSeries(r, y, 0))
+  }
+  panic("Taylor(): f must return a TaylorReal of the given order")
+}
+
+// Compute the second-order Taylor expansion of f at x_, i.e. the value,
+// gradient, and Hessian of f at x_. The expansion is computed in a context
+// nested within all slots carried by x_, but the results carry no
+// derivatives.
+func TaylorMultivariate(f func(Vector) Scalar, x_ Vector) (Scalar, Vector, Matrix) {
+  x := x_.Clone()
+  c := DiffContext{}.nestedWithin(len(x), x_)
+  c.Variables(2, x...)
+  k := c.Offset
+  y := f(x)
+  n := len(x)
+  v := NewScalar(x.ElementType(), y.GetValue())
+  g := NullVector(x.ElementType(), n)
+  h := NullDenseMatrix(x.ElementType(), n, n)
+  // copy derivatives
+  for i := 0; i < n; i++ {
+    g[i].SetValue(y.GetDerivative(1, k+i))
+    for j := 0; j < n; j++ {
+      h.ReferenceAt(i, j).SetValue(y.GetHessian(k+i, k+j))
+    }
+  }
+  return v, g, h
+}
@@ -0,0 +1,265 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+import "reflect"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+// A TaylorReal propagates truncated Taylor expansions of arbitrary order.
+// For each variable x_j the coefficients
+//
+//   Coefficients[j][k] = 1/k! d^k f / dx_j^k,  k = 0, ..., Order
+//
+// are stored, i.e. the expansion of f along each coordinate axis. If the
+// order is at least two, the mixed partial derivatives
+//
+//   Mixed[k] = d^2 f / dx_i dx_j,  i < j
+//
+// are stored as well, which are propagated as series of order two along the
+// directions e_i + e_j. Mixed partial derivatives of higher order are not
+// available.
+type TaylorReal struct {
+  Value           float64
+  Order           int
+  N               int
+  Coefficients [][]float64
+  Mixed          []float64
+}
+
+/* register scalar type
+ * -------------------------------------------------------------------------- */
+
+var TaylorRealType ScalarType = NewTaylorReal(0.0).Type()
+
+func init() {
+  f := func(value float64) Scalar { return NewTaylorReal(value) }
+  RegisterScalar(TaylorRealType, f)
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a new real constant or variable.
+func NewTaylorReal(v float64) *TaylorReal {
+  return &TaylorReal{Value: v}
+}
+
+func NullTaylorReal() *TaylorReal {
+  return &TaylorReal{Value: 0.0}
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (a *TaylorReal) Clone() Scalar {
+  r := NewTaylorReal(0.0)
+  r.Copy(a)
+  return r
+}
+
+func (a *TaylorReal) Type() ScalarType {
+  return reflect.TypeOf(a)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (a *TaylorReal) String() string {
+  return fmt.Sprintf("%e", a.GetValue())
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Copy value and Taylor coefficients from b. Scalars of other types
+// provide coefficients up to order two.
+func (a *TaylorReal) Copy(b Scalar) {
+  if r, ok := b.(*TaylorReal); ok {
+    a.Order = r.Order
+    a.Alloc(r.N)
+    for j := 0; j < a.N; j++ {
+      copy(a.Coefficients[j], r.Coefficients[j])
+    }
+    copy(a.Mixed, r.Mixed)
+    a.Value = r.Value
+  } else {
+    a.Order = b.GetOrder()
+    a.Alloc(b.GetN())
+    for j := 0; j < a.N; j++ {
+      taylorSeries(a.Coefficients[j], b, j)
+    }
+    for i := 0; i < a.N && a.Order >= 2; i++ {
+      for j := i+1; j < a.N; j++ {
+        a.Mixed[a.mixedIndex(i, j)] = b.GetHessian(i, j)
+      }
+    }
+    a.Value = b.GetValue()
+  }
+}
+
+// Allocate memory for the coefficients of n variables.
+func (a *TaylorReal) Alloc(n int) {
+  if a.N != n || (n > 0 && len(a.Coefficients[0]) != a.Order+1) {
+    a.Coefficients = make([][]float64, n)
+    v := make([]float64, n*(a.Order+1))
+    for j := 0; j < n; j++ {
+      a.Coefficients[j] = v[j*(a.Order+1):(j+1)*(a.Order+1)]
+    }
+    a.N = n
+  }
+  if a.Order < 2 {
+    a.Mixed = nil
+  } else if len(a.Mixed) != n*(n-1)/2 {
+    a.Mixed = make([]float64, n*(n-1)/2)
+  }
+}
+
+// Index of the mixed partial derivative with respect to x_i and x_j, i != j.
+func (a *TaylorReal) mixedIndex(i, j int) int {
+  if i > j {
+    i, j = j, i
+  }
+  return i*(2*a.N-i-1)/2 + j-i-1
+}
+
+func (c *TaylorReal) AllocForOne(a Scalar) {
+  c.Order = a.GetOrder()
+  c.Alloc(a.GetN())
+}
+
+func (c *TaylorReal) AllocForTwo(a, b Scalar) {
+  c.Order = iMax(a.GetOrder(), b.GetOrder())
+  c.Alloc(iMax(a.GetN(), b.GetN()))
+}
+
+/* read access
+ * -------------------------------------------------------------------------- */
+
+func (a *TaylorReal) GetOrder() int {
+  return a.Order
+}
+
+func (a *TaylorReal) GetValue() float64 {
+  return a.Value
+}
+
+func (a *TaylorReal) GetLogValue() float64 {
+  return math.Log(a.Value)
+}
+
+// Returns the ith derivative of the jth variable.
+func (a *TaylorReal) GetDerivative(i, j int) float64 {
+  if i < 1 {
+    panic("Invalid order!")
+  }
+  if i > a.Order || j >= a.N {
+    return 0.0
+  }
+  return special.Factorial(i)*a.Coefficients[j][i]
+}
+
+func (a *TaylorReal) GetHessian(i, j int) float64 {
+  if i == j {
+    return a.GetDerivative(2, i)
+  }
+  if a.Order < 2 || i >= a.N || j >= a.N {
+    return 0.0
+  }
+  return a.Mixed[a.mixedIndex(i, j)]
+}
+
+func (a *TaylorReal) GetN() int {
+  return a.N
+}
+
+func (a *TaylorReal) SetN(n int) {
+  a.Alloc(n)
+}
+
+/* write access
+ * -------------------------------------------------------------------------- */
+
+func (a *TaylorReal) Reset() {
+  a.Value = 0.0
+  for j := 0; j < a.N; j++ {
+    for k := 0; k <= a.Order; k++ {
+      a.Coefficients[j][k] = 0.0
+    }
+  }
+  for k := 0; k < len(a.Mixed); k++ {
+    a.Mixed[k] = 0.0
+  }
+}
+
+func (a *TaylorReal) ResetDerivatives() {
+  for j := 0; j < a.N; j++ {
+    for k := 1; k <= a.Order; k++ {
+      a.Coefficients[j][k] = 0.0
+    }
+  }
+  for k := 0; k < len(a.Mixed); k++ {
+    a.Mixed[k] = 0.0
+  }
+}
+
+func (a *TaylorReal) Set(b Scalar) {
+  a.Copy(b)
+}
+
+// Set only the value of the variable.
+func (a *TaylorReal) SetValue(v float64) {
+  a.Value = v
+  for j := 0; j < a.N; j++ {
+    a.Coefficients[j][0] = v
+  }
+}
+
+// Set the ith derivative of the jth variable to v. Derivatives of higher
+// order than a are ignored.
+func (a *TaylorReal) SetDerivative(i, j int, v float64) {
+  if i < 1 {
+    panic("Invalid order!")
+  }
+  if i <= a.Order {
+    a.Coefficients[j][i] = v/special.Factorial(i)
+  }
+}
+
+func (a *TaylorReal) SetHessian(i, j int, v float64) {
+  if i == j {
+    a.SetDerivative(2, i, v)
+  } else if a.Order >= 2 {
+    a.Mixed[a.mixedIndex(i, j)] = v
+  }
+}
+
+// Allocate memory for n variables and set the derivative
+// of the ith variable to 1 (initial value).
+func (a *TaylorReal) SetVariable(i, n, order int) {
+  a.Order = order
+  a.Alloc(n)
+  a.ResetDerivatives()
+  a.SetValue(a.Value)
+  if order > 0 {
+    a.Coefficients[i][1] = 1.0
+  }
+}
@@ -0,0 +1,160 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+
+/* mixed partial derivatives
+ * -------------------------------------------------------------------------- */
+
+// Compute the coefficients of order two along the directions e_i + e_j,
+// i < j, where g(r, i, j) computes the series r of length three.
+func (c *TaylorReal) mixedSeries(g func(r []float64, i, j int)) []float64 {
+  m := make([]float64, len(c.Mixed))
+  r := make([]float64, 3)
+  for i := 0; i < c.N && c.Order >= 2; i++ {
+    for j := i+1; j < c.N; j++ {
+      g(r, i, j)
+      m[c.mixedIndex(i, j)] = r[2]
+    }
+  }
+  return m
+}
+
+// Obtain mixed partial derivatives from the coefficients m along e_i + e_j,
+// which are given by 1/2 (f_ii + 2 f_ij + f_jj). Coefficients along the
+// coordinate axes must be computed first.
+func (c *TaylorReal) setMixed(m []float64) {
+  for i := 0; i < c.N && c.Order >= 2; i++ {
+    for j := i+1; j < c.N; j++ {
+      k := c.mixedIndex(i, j)
+      c.Mixed[k] = m[k] - c.Coefficients[i][2] - c.Coefficients[j][2]
+    }
+  }
+}
+
+/* Taylor coefficients of monadic functions. The function f computes the
+ * series r = f(a) for each variable. If there are no variables, f is
+ * called once with series of length one.
+ * -------------------------------------------------------------------------- */
+
+func (c *TaylorReal) monadic(a Scalar, f func(r, a []float64)) Scalar {
+  c.AllocForOne(a)
+  // operands are copied, so that c may refer to a
+  x := make([]float64, c.Order+1)
+  r := make([]float64, c.Order+1)
+  m := c.mixedSeries(func(r []float64, i, j int) {
+    f(r, taylorMixedSeries(x[0:3], a, i, j))
+  })
+  if c.N == 0 {
+    f(r[0:1], taylorSeries(x[0:1], a, 0))
+    c.Value = r[0]
+  }
+  for j := 0; j < c.N; j++ {
+    f(r, taylorSeries(x, a, j))
+    copy(c.Coefficients[j], r)
+    c.Value = r[0]
+  }
+  c.setMixed(m)
+  return c
+}
+
+/* Taylor coefficients of dyadic functions
+ * -------------------------------------------------------------------------- */
+
+func (c *TaylorReal) dyadic(a, b Scalar, f func(r, a, b []float64)) Scalar {
+  c.AllocForTwo(a, b)
+  // operands are copied, so that c may refer to a or b
+  x := make([]float64, c.Order+1)
+  y := make([]float64, c.Order+1)
+  r := make([]float64, c.Order+1)
+  m := c.mixedSeries(func(r []float64, i, j int) {
+    f(r, taylorMixedSeries(x[0:3], a, i, j), taylorMixedSeries(y[0:3], b, i, j))
+  })
+  if c.N == 0 {
+    f(r[0:1], taylorSeries(x[0:1], a, 0), taylorSeries(y[0:1], b, 0))
+    c.Value = r[0]
+  }
+  for j := 0; j < c.N; j++ {
+    f(r, taylorSeries(x, a, j), taylorSeries(y, b, j))
+    copy(c.Coefficients[j], r)
+    c.Value = r[0]
+  }
+  c.setMixed(m)
+  return c
+}
+
+/* stop gradient
+ * -------------------------------------------------------------------------- */
+
+func (c *TaylorReal) StopGradient(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    for k := 1; k < len(r); k++ {
+      r[k] = 0.0
+    }
+    r[0] = a[0]
+  })
+}
+
+/* user-defined functions, Taylor coefficients of order three or higher are
+ * not available
+ * -------------------------------------------------------------------------- */
+
+// Panic if coefficients of order three or higher are required.
+func checkSecondOrder(name string, a ...Scalar) {
+  order, n := 0, 0
+  for _, a := range a {
+    order = iMax(order, a.GetOrder())
+    n     = iMax(n, a.GetN())
+  }
+  if order >= 3 && n > 0 {
+    panic(fmt.Sprintf("%s: derivatives of order three or higher are not available!", name))
+  }
+}
+
+func (c *TaylorReal) Monadic(a Scalar, v0, v1, v2 float64) Scalar {
+  checkSecondOrder("Monadic()", a)
+  return c.monadic(a, func(r, a []float64) {
+    d := make([]float64, len(r))
+    d[0] = v0
+    if len(d) > 1 {
+      d[1] = v1
+    }
+    if len(d) > 2 {
+      d[2] = v2
+    }
+    taylorCompose(r, a, d)
+  })
+}
+
+func (c *TaylorReal) Dyadic(a, b Scalar, v0, v10, v01, v11, v20, v02 float64) Scalar {
+  checkSecondOrder("Dyadic()", a, b)
+  return c.dyadic(a, b, func(r, a, b []float64) {
+    for k := 0; k < len(r); k++ {
+      r[k] = 0.0
+    }
+    r[0] = v0
+    if len(r) > 1 {
+      r[1] = v10*a[1] + v01*b[1]
+    }
+    if len(r) > 2 {
+      r[2] = v10*a[2] + v01*b[2] + v20/2.0*a[1]*a[1] + v11*a[1]*b[1] + v02/2.0*b[1]*b[1]
+    }
+  })
+}
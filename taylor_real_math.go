@@ -0,0 +1,503 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+func (a *TaylorReal) Equals(b Scalar) bool {
+  epsilon := 1e-12
+  return math.Abs(a.GetValue() - b.GetValue()) < epsilon
+}
+
+func (a *TaylorReal) Greater(b Scalar) bool {
+  return a.GetValue() > b.GetValue()
+}
+
+func (a *TaylorReal) Smaller(b Scalar) bool {
+  return a.GetValue() < b.GetValue()
+}
+
+func (a *TaylorReal) Min(b Scalar) Scalar {
+  switch {
+  case a.GetValue() < b.GetValue():
+    return a
+  case a.GetValue() > b.GetValue():
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+func (a *TaylorReal) Max(b Scalar) Scalar {
+  switch {
+  case a.GetValue() > b.GetValue():
+    return a
+  case a.GetValue() < b.GetValue():
+    return b
+  }
+  return scalarTie(a, b)
+}
+
+// The derivative of |a| at zero is set to zero.
+func (c *TaylorReal) Abs(a Scalar) Scalar {
+  switch a.Sign() {
+  case -1:
+    return c.Neg(a)
+  case  1:
+    c.Copy(a)
+    return c
+  }
+  return c.StopGradient(a)
+}
+
+func (a *TaylorReal) Sign() int {
+  if a.GetValue() < 0.0 {
+    return -1
+  }
+  if a.GetValue() > 0.0 {
+    return  1
+  }
+  return 0
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *TaylorReal) Neg(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    taylorScale(r, a, -1.0)
+  })
+}
+
+func (c *TaylorReal) Add(a, b Scalar) Scalar {
+  return c.dyadic(a, b, taylorAdd)
+}
+
+func (c *TaylorReal) Sub(a, b Scalar) Scalar {
+  return c.dyadic(a, b, taylorSub)
+}
+
+func (c *TaylorReal) Mul(a, b Scalar) Scalar {
+  return c.dyadic(a, b, taylorMul)
+}
+
+func (c *TaylorReal) Div(a, b Scalar) Scalar {
+  return c.dyadic(a, b, taylorDiv)
+}
+
+func (c *TaylorReal) Pow(a, k Scalar) Scalar {
+  if k.GetOrder() >= 1 {
+    // a^k = exp(k log a)
+    return c.dyadic(a, k, func(r, a, k []float64) {
+      t := make([]float64, len(r))
+      taylorLog(r, a)
+      taylorMul(t, k, r)
+      taylorExp(r, t)
+    })
+  } else {
+    p := k.GetValue()
+    return c.monadic(a, func(r, a []float64) {
+      taylorPow(r, a, p)
+    })
+  }
+}
+
+func (c *TaylorReal) Sqrt(a Scalar) Scalar {
+  return c.Pow(a, NewBareReal(1.0/2.0))
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (c *TaylorReal) Sin(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    taylorSinCos(r, make([]float64, len(r)), a)
+  })
+}
+
+func (c *TaylorReal) Sinh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    taylorSinhCosh(r, make([]float64, len(r)), a)
+  })
+}
+
+func (c *TaylorReal) Cos(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    taylorSinCos(make([]float64, len(r)), r, a)
+  })
+}
+
+func (c *TaylorReal) Cosh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    taylorSinhCosh(make([]float64, len(r)), r, a)
+  })
+}
+
+func (c *TaylorReal) Tan(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    s := make([]float64, len(r))
+    t := make([]float64, len(r))
+    taylorSinCos(s, t, a)
+    taylorDiv(r, s, t)
+  })
+}
+
+func (c *TaylorReal) Tanh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    s := make([]float64, len(r))
+    t := make([]float64, len(r))
+    taylorSinhCosh(s, t, a)
+    taylorDiv(r, s, t)
+  })
+}
+
+// Series of (c + s a^2)^p, which is the derivative of the inverse
+// trigonometric and hyperbolic functions.
+func taylorInverseDerivative(r, a []float64, c, s, p float64) {
+  t := make([]float64, len(r))
+  taylorMul(t, a, a)
+  taylorScale(t, t, s)
+  t[0] += c
+  taylorPow(r, t, p)
+}
+
+func (c *TaylorReal) Asin(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorInverseDerivative(g, a, 1.0, -1.0, -0.5)
+    taylorIntegrate(r, a, g, math.Asin(a[0]))
+  })
+}
+
+func (c *TaylorReal) Acos(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorInverseDerivative(g, a, 1.0, -1.0, -0.5)
+    taylorScale(g, g, -1.0)
+    taylorIntegrate(r, a, g, math.Acos(a[0]))
+  })
+}
+
+func (c *TaylorReal) Atan(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorInverseDerivative(g, a, 1.0, 1.0, -1.0)
+    taylorIntegrate(r, a, g, math.Atan(a[0]))
+  })
+}
+
+func (c *TaylorReal) Atan2(a, b Scalar) Scalar {
+  return c.dyadic(a, b, taylorAtan2)
+}
+
+func (c *TaylorReal) Asinh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorInverseDerivative(g, a, 1.0, 1.0, -0.5)
+    taylorIntegrate(r, a, g, math.Asinh(a[0]))
+  })
+}
+
+func (c *TaylorReal) Acosh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorInverseDerivative(g, a, -1.0, 1.0, -0.5)
+    taylorIntegrate(r, a, g, math.Acosh(a[0]))
+  })
+}
+
+func (c *TaylorReal) Atanh(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorInverseDerivative(g, a, 1.0, -1.0, -1.0)
+    taylorIntegrate(r, a, g, math.Atanh(a[0]))
+  })
+}
+
+func (c *TaylorReal) Exp(a Scalar) Scalar {
+  return c.monadic(a, taylorExp)
+}
+
+func (c *TaylorReal) Log(a Scalar) Scalar {
+  return c.monadic(a, taylorLog)
+}
+
+func (c *TaylorReal) Log1p(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    t := make([]float64, len(r))
+    copy(t, a)
+    t[0] += 1.0
+    taylorLog(r, t)
+    r[0] = math.Log1p(a[0])
+  })
+}
+
+func (c *TaylorReal) Expm1(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    taylorExp(r, a)
+    r[0] = math.Expm1(a[0])
+  })
+}
+
+func (c *TaylorReal) Logistic(a Scalar) Scalar {
+  return c.monadic(a, taylorLogistic)
+}
+
+func (c *TaylorReal) Softplus(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorLogistic(g, a)
+    taylorIntegrate(r, a, g, special.Softplus(a[0]))
+  })
+}
+
+// Series of 2/sqrt(pi) exp(-a^2), i.e. the derivative of erf(a).
+func taylorErfDerivative(r, a []float64) {
+  t := make([]float64, len(r))
+  taylorMul(t, a, a)
+  taylorScale(t, t, -1.0)
+  taylorExp(r, t)
+  taylorScale(r, r, 2.0/special.M_SQRTPI)
+}
+
+func (c *TaylorReal) Erf(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorErfDerivative(g, a)
+    taylorIntegrate(r, a, g, math.Erf(a[0]))
+  })
+}
+
+func (c *TaylorReal) Erfc(a Scalar) Scalar {
+  return c.monadic(a, func(r, a []float64) {
+    g := make([]float64, len(r))
+    taylorErfDerivative(g, a)
+    taylorScale(g, g, -1.0)
+    taylorIntegrate(r, a, g, math.Erfc(a[0]))
+  })
+}
+
+func (c *TaylorReal) LogErfc(a Scalar) Scalar {
+  return c.monadic(a, taylorLogErfc)
+}
+
+// Derivatives of the log gamma function at x up to order n.
+func lgammaDerivatives(x float64, n int) []float64 {
+  d := make([]float64, n+1)
+  d[0], _ = math.Lgamma(x)
+  for k := 1; k <= n; k++ {
+    d[k] = special.Polygamma(k-1, x)
+  }
+  return d
+}
+
+func (c *TaylorReal) Gamma(a Scalar) Scalar {
+  x := a.GetValue()
+  d := lgammaDerivatives(x, iMax(c.Order, a.GetOrder()))
+  _, s := math.Lgamma(x)
+  return c.monadic(a, func(r, a []float64) {
+    t := make([]float64, len(r))
+    taylorCompose(t, a, d)
+    taylorExp(r, t)
+    taylorScale(r, r, float64(s))
+    r[0] = math.Gamma(x)
+  })
+}
+
+func (c *TaylorReal) Lgamma(a Scalar) Scalar {
+  x := a.GetValue()
+  d := lgammaDerivatives(x, a.GetOrder())
+  if _, s := math.Lgamma(x); s == -1 {
+    d[0] = math.NaN()
+  }
+  return c.monadic(a, func(r, a []float64) {
+    taylorCompose(r, a, d)
+  })
+}
+
+func (c *TaylorReal) Mlgamma(a Scalar, k int) Scalar {
+  x := a.GetValue()
+  d := make([]float64, a.GetOrder()+1)
+  for j := 1; j <= k; j++ {
+    dj := lgammaDerivatives(x + float64(1-j)/2.0, a.GetOrder())
+    for i := 1; i < len(d); i++ {
+      d[i] += dj[i]
+    }
+  }
+  d[0] = special.Mlgamma(x, k)
+  return c.monadic(a, func(r, a []float64) {
+    taylorCompose(r, a, d)
+  })
+}
+
+func (c *TaylorReal) GammaP(a, b Scalar) Scalar {
+  if a.GetOrder() >= 1 {
+    // derivatives with respect to the shape are only available up to
+    // second order
+    checkSecondOrder("GammaP()", a, b)
+    v10, v01, v11, v20, v02 := gammaPDerivatives(a, b)
+    return c.Dyadic(a, b, special.GammaP(a.GetValue(), b.GetValue()), v10, v01, v11, v20, v02)
+  }
+  s := a.GetValue()
+  return c.monadic(b, func(r, x []float64) {
+    // derivative: x^(a-1) exp(-x) / Gamma(a)
+    g := make([]float64, len(r))
+    t := make([]float64, len(r))
+    lg, _ := math.Lgamma(s)
+    taylorLog(t, x)
+    taylorScale(t, t, s-1.0)
+    taylorSub(t, t, x)
+    t[0] -= lg
+    taylorExp(g, t)
+    taylorIntegrate(r, x, g, special.GammaP(s, x[0]))
+  })
+}
+
+func (c *TaylorReal) GammaQ(a, b Scalar) Scalar {
+  if a.GetOrder() >= 1 {
+    // derivatives with respect to the shape are only available up to
+    // second order
+    checkSecondOrder("GammaQ()", a, b)
+    v10, v01, v11, v20, v02 := gammaPDerivatives(a, b)
+    return c.Dyadic(a, b, special.GammaQ(a.GetValue(), b.GetValue()), -v10, -v01, -v11, -v20, -v02)
+  }
+  s := a.GetValue()
+  return c.monadic(b, func(r, x []float64) {
+    // derivative: -x^(a-1) exp(-x) / Gamma(a)
+    g := make([]float64, len(r))
+    t := make([]float64, len(r))
+    lg, _ := math.Lgamma(s)
+    taylorLog(t, x)
+    taylorScale(t, t, s-1.0)
+    taylorSub(t, t, x)
+    t[0] -= lg
+    taylorExp(g, t)
+    taylorScale(g, g, -1.0)
+    taylorIntegrate(r, x, g, special.GammaQ(s, x[0]))
+  })
+}
+
+func (c *TaylorReal) Digamma(a Scalar) Scalar {
+  return c.Polygamma(0, a)
+}
+
+func (c *TaylorReal) Trigamma(a Scalar) Scalar {
+  return c.Polygamma(1, a)
+}
+
+func (c *TaylorReal) Polygamma(n int, a Scalar) Scalar {
+  x := a.GetValue()
+  d := make([]float64, a.GetOrder()+1)
+  for k := 0; k < len(d); k++ {
+    d[k] = special.Polygamma(n+k, x)
+  }
+  return c.monadic(a, func(r, a []float64) {
+    taylorCompose(r, a, d)
+  })
+}
+
+// Series of log(|Gamma(a)|) + log(|Gamma(b)|) - log(|Gamma(a+b)|).
+func taylorLbeta(r, a, b []float64) {
+  n := len(r)-1
+  s := make([]float64, len(r))
+  t := make([]float64, len(r))
+  taylorCompose(r, a, lgammaDerivatives(a[0], n))
+  taylorCompose(t, b, lgammaDerivatives(b[0], n))
+  taylorAdd(r, r, t)
+  taylorAdd(s, a, b)
+  taylorCompose(t, s, lgammaDerivatives(s[0], n))
+  taylorSub(r, r, t)
+}
+
+func (c *TaylorReal) Beta(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b []float64) {
+    t := make([]float64, len(r))
+    taylorLbeta(t, a, b)
+    taylorExp(r, t)
+    r[0] = special.Beta(a[0], b[0])
+    _, s := special.Lbeta(a[0], b[0])
+    for k := 1; k < len(r); k++ {
+      r[k] *= float64(s)
+    }
+  })
+}
+
+func (c *TaylorReal) Lbeta(a, b Scalar) Scalar {
+  return c.dyadic(a, b, func(r, a, b []float64) {
+    taylorLbeta(r, a, b)
+    if _, s := special.Lbeta(a[0], b[0]); s == -1 {
+      r[0] = math.NaN()
+    }
+  })
+}
+
+func (c *TaylorReal) Zeta(a Scalar) Scalar {
+  x := a.GetValue()
+  d := make([]float64, a.GetOrder()+1)
+  for k := 0; k < len(d); k++ {
+    d[k] = special.ZetaDerivative(k, x)
+  }
+  return c.monadic(a, func(r, a []float64) {
+    taylorCompose(r, a, d)
+  })
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (r *TaylorReal) VdotV(a, b Vector) Scalar {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r.Reset()
+  t := NullTaylorReal()
+  for i := 0; i < len(a); i++ {
+    t.Mul(a[i], b[i])
+    r.Add(r, t)
+  }
+  return r
+}
+
+func (r *TaylorReal) Vnorm(a Vector) Scalar {
+  r.Reset()
+  c := NewBareReal(2.0)
+  t := NullTaylorReal()
+  for i := 0; i < len(a); i++ {
+    t.Pow(a[i], c)
+    r.Add(r, t)
+  }
+  r.Sqrt(r)
+  return r
+}
+
+func (r *TaylorReal) LogSumExp(a Vector) Scalar {
+  // subtract the maximum to avoid overflow
+  m := NewBareReal(logSumExpMax(a))
+  s := NullTaylorReal()
+  t := NullTaylorReal()
+  for i := 0; i < len(a); i++ {
+    t.Sub(a[i], m)
+    t.Exp(t)
+    s.Add(s, t)
+  }
+  r.Log(s)
+  r.Add(r, m)
+  return r
+}
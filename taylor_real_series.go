@@ -0,0 +1,294 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "math"
+
+import "github.com/pbenner/autodiff/special"
+
+/* Arithmetic on truncated Taylor series. All series have the same length
+ * K+1, where the kth element is the kth Taylor coefficient. The result r
+ * must not share memory with any of the arguments.
+ * -------------------------------------------------------------------------- */
+
+// Get the Taylor series of a along the jth variable. Scalars other than
+// TaylorReal provide coefficients up to order two.
+func taylorSeries(r []float64, a Scalar, j int) []float64 {
+  for k := 0; k < len(r); k++ {
+    r[k] = 0.0
+  }
+  r[0] = a.GetValue()
+  if b, ok := a.(*TaylorReal); ok {
+    if j < b.N {
+      copy(r, b.Coefficients[j])
+    }
+  } else {
+    for k := 1; k < len(r) && k <= iMin(a.GetOrder(), 2); k++ {
+      r[k] = a.GetDerivative(k, j)/special.Factorial(k)
+    }
+  }
+  return r
+}
+
+// Get the Taylor series of order two of a along the direction e_i + e_j,
+// i != j, which is given by f_i + f_j and 1/2 (f_ii + 2 f_ij + f_jj).
+func taylorMixedSeries(r []float64, a Scalar, i, j int) []float64 {
+  x := taylorSeries(make([]float64, 3), a, i)
+  y := taylorSeries(make([]float64, 3), a, j)
+  r[0] = x[0]
+  r[1] = x[1] + y[1]
+  r[2] = x[2] + y[2]
+  if a.GetOrder() >= 2 && i < a.GetN() && j < a.GetN() {
+    r[2] += a.GetHessian(i, j)
+  }
+  return r
+}
+
+func taylorAdd(r, a, b []float64) {
+  for k := 0; k < len(r); k++ {
+    r[k] = a[k] + b[k]
+  }
+}
+
+func taylorSub(r, a, b []float64) {
+  for k := 0; k < len(r); k++ {
+    r[k] = a[k] - b[k]
+  }
+}
+
+func taylorScale(r, a []float64, c float64) {
+  for k := 0; k < len(r); k++ {
+    r[k] = c*a[k]
+  }
+}
+
+func taylorMul(r, a, b []float64) {
+  for k := 0; k < len(r); k++ {
+    r[k] = 0.0
+    for i := 0; i <= k; i++ {
+      r[k] += a[i]*b[k-i]
+    }
+  }
+}
+
+func taylorDiv(r, a, b []float64) {
+  for k := 0; k < len(r); k++ {
+    r[k] = a[k]
+    for i := 1; i <= k; i++ {
+      r[k] -= b[i]*r[k-i]
+    }
+    r[k] /= b[0]
+  }
+}
+
+func taylorExp(r, a []float64) {
+  r[0] = math.Exp(a[0])
+  for k := 1; k < len(r); k++ {
+    r[k] = 0.0
+    for i := 1; i <= k; i++ {
+      r[k] += float64(i)*a[i]*r[k-i]
+    }
+    r[k] /= float64(k)
+  }
+}
+
+func taylorLog(r, a []float64) {
+  r[0] = math.Log(a[0])
+  for k := 1; k < len(r); k++ {
+    r[k] = a[k]
+    for i := 1; i < k; i++ {
+      r[k] -= float64(i)*r[i]*a[k-i]/float64(k)
+    }
+    r[k] /= a[0]
+  }
+}
+
+// Power with constant exponent p.
+func taylorPow(r, a []float64, p float64) {
+  if a[0] == 0.0 && p >= 0.0 && p == math.Floor(p) {
+    // the recursion below is not defined at zero, but the result
+    // is a polynomial if p is an integer, where all coefficients
+    // vanish if p exceeds the order
+    n := len(r)
+    if p < float64(n) {
+      n = int(p)
+    }
+    t := make([]float64, len(r))
+    for k := 0; k < len(r); k++ {
+      r[k] = 0.0
+    }
+    r[0] = 1.0
+    for i := 0; i < n; i++ {
+      copy(t, r)
+      taylorMul(r, t, a)
+    }
+    return
+  }
+  r[0] = math.Pow(a[0], p)
+  for k := 1; k < len(r); k++ {
+    r[k] = 0.0
+    for i := 1; i <= k; i++ {
+      r[k] += (p*float64(i) - float64(k-i))*a[i]*r[k-i]
+    }
+    r[k] /= float64(k)*a[0]
+  }
+}
+
+func taylorSinCos(s, c, a []float64) {
+  s[0] = math.Sin(a[0])
+  c[0] = math.Cos(a[0])
+  for k := 1; k < len(s); k++ {
+    s[k] = 0.0
+    c[k] = 0.0
+    for i := 1; i <= k; i++ {
+      s[k] += float64(i)*a[i]*c[k-i]
+      c[k] -= float64(i)*a[i]*s[k-i]
+    }
+    s[k] /= float64(k)
+    c[k] /= float64(k)
+  }
+}
+
+func taylorSinhCosh(s, c, a []float64) {
+  s[0] = math.Sinh(a[0])
+  c[0] = math.Cosh(a[0])
+  for k := 1; k < len(s); k++ {
+    s[k] = 0.0
+    c[k] = 0.0
+    for i := 1; i <= k; i++ {
+      s[k] += float64(i)*a[i]*c[k-i]
+      c[k] += float64(i)*a[i]*s[k-i]
+    }
+    s[k] /= float64(k)
+    c[k] /= float64(k)
+  }
+}
+
+// Compute the series of f(a) given the series g of f'(a) and the value
+// v0 = f(a[0]).
+func taylorIntegrate(r, a, g []float64, v0 float64) {
+  r[0] = v0
+  for k := 1; k < len(r); k++ {
+    r[k] = 0.0
+    for i := 1; i <= k; i++ {
+      r[k] += float64(i)*a[i]*g[k-i]
+    }
+    r[k] /= float64(k)
+  }
+}
+
+// Series of atan2(a, b), which has derivative (b a' - a b')/(a^2 + b^2).
+func taylorAtan2(r, a, b []float64) {
+  n  := len(r)
+  da := make([]float64, n)
+  db := make([]float64, n)
+  for k := 0; k+1 < n; k++ {
+    da[k] = float64(k+1)*a[k+1]
+    db[k] = float64(k+1)*b[k+1]
+  }
+  s := make([]float64, n)
+  t := make([]float64, n)
+  taylorMul(s, b, da)
+  taylorMul(t, a, db)
+  taylorSub(s, s, t)
+  taylorMul(t, a, a)
+  taylorMul(da, b, b)
+  taylorAdd(t, t, da)
+  taylorDiv(db, s, t)
+  r[0] = math.Atan2(a[0], b[0])
+  for k := 1; k < n; k++ {
+    r[k] = db[k-1]/float64(k)
+  }
+}
+
+// Series of 1/(1+exp(-a)), where the exponential is evaluated only for
+// non-positive arguments to avoid overflow.
+func taylorLogistic(r, a []float64) {
+  t := make([]float64, len(r))
+  u := make([]float64, len(r))
+  if a[0] >= 0.0 {
+    // 1/(1+exp(-a))
+    taylorScale(t, a, -1.0)
+    taylorExp(u, t)
+    u[0] += 1.0
+    for k := range t {
+      t[k] = 0.0
+    }
+    t[0] = 1.0
+    taylorDiv(r, t, u)
+  } else {
+    // exp(a)/(1+exp(a))
+    taylorExp(t, a)
+    copy(u, t)
+    u[0] += 1.0
+    taylorDiv(r, t, u)
+  }
+}
+
+// Compute the series of f(a) given all derivatives d[k] = f^(k)(a[0]).
+func taylorCompose(r, a, d []float64) {
+  p := make([]float64, len(r))
+  t := make([]float64, len(r))
+  b := make([]float64, len(r))
+  copy(b, a)
+  b[0] = 0.0
+  p[0] = 1.0
+  for k := 0; k < len(r); k++ {
+    r[k] = 0.0
+  }
+  r[0] = d[0]
+  for k := 1; k < len(r); k++ {
+    copy(t, p)
+    taylorMul(p, t, b)
+    for i := k; i < len(r); i++ {
+      r[i] += d[k]/special.Factorial(k)*p[i]
+    }
+  }
+}
+
+// Series of log(erfc(a)). The derivative -2/sqrt(pi) exp(-a^2 - log
+// erfc(a)) depends on the result itself, hence both series are computed
+// jointly, which avoids underflow of erfc(a) for large a.
+func taylorLogErfc(r, a []float64) {
+  n := len(r)
+  s := make([]float64, n)
+  h := make([]float64, n)
+  g := make([]float64, n)
+  taylorMul(s, a, a)
+  r[0] = special.LogErfc(a[0])
+  for k := 1; k < n; k++ {
+    // update series of the derivative up to order k-1
+    h[k-1] = -s[k-1] - r[k-1]
+    if k == 1 {
+      g[0] = -2.0/special.M_SQRTPI*math.Exp(h[0])
+    } else {
+      m := k-1
+      g[m] = 0.0
+      for i := 1; i <= m; i++ {
+        g[m] += float64(i)*h[i]*g[m-i]
+      }
+      g[m] /= float64(m)
+    }
+    r[k] = 0.0
+    for i := 1; i <= k; i++ {
+      r[k] += float64(i)*a[i]*g[k-i]
+    }
+    r[k] /= float64(k)
+  }
+}
@@ -0,0 +1,347 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+import "github.com/pbenner/autodiff/special"
+
+/* -------------------------------------------------------------------------- */
+
+func TestTaylorReal1(t *testing.T) {
+
+  x := NewTaylorReal(0.7)
+  x.SetVariable(0, 1, 5)
+
+  // y = exp(sin(x)), compare with y' = cos(x) y
+  y := NullTaylorReal()
+  y.Exp(y.Sin(x))
+
+  s, c := math.Sin(0.7), math.Cos(0.7)
+  e := math.Exp(s)
+  r := []float64{
+    e,
+    c*e,
+    (c*c - s)*e,
+    (c*c*c - 3*s*c - c)*e }
+  for i := 1; i < len(r); i++ {
+    if math.Abs(y.GetDerivative(i, 0) - r[i]) > 1e-10 {
+      t.Errorf("TaylorReal test failed for order `%d'", i)
+    }
+  }
+}
+
+func TestTaylorReal2(t *testing.T) {
+
+  x := NewTaylorReal(2.5)
+  x.SetVariable(0, 1, 4)
+
+  y := Lgamma(x)
+
+  for i := 1; i <= 4; i++ {
+    if math.Abs(y.GetDerivative(i, 0) - special.Polygamma(i-1, 2.5)) > 1e-8 {
+      t.Errorf("TaylorReal test failed for order `%d'", i)
+    }
+  }
+}
+
+func TestTaylorReal3(t *testing.T) {
+
+  // x^n has nth derivative n! and vanishing higher derivatives
+  x := NewTaylorReal(0.0)
+  x.SetVariable(0, 1, 6)
+
+  y := Pow(x, NewBareReal(4))
+
+  for i := 1; i <= 6; i++ {
+    r := 0.0
+    if i == 4 {
+      r = 24.0
+    }
+    if math.Abs(y.GetDerivative(i, 0) - r) > 1e-12 {
+      t.Errorf("TaylorReal test failed for order `%d'", i)
+    }
+  }
+}
+
+func TestTaylorReal4(t *testing.T) {
+
+  // compare first and second derivatives with Real
+  f := func(x Vector) Scalar {
+    y := Mul(Erf(x[0]), Log(x[1]))
+    y  = Add(y, Div(Tanh(x[1]), Sqrt(x[0])))
+    y  = Add(y, LogErfc(Mul(x[0], x[1])))
+    return y
+  }
+  x1 := NewVector(RealType,       []float64{0.8, 1.3})
+  x2 := NewVector(TaylorRealType, []float64{0.8, 1.3})
+
+  Variables(2, x1...)
+  Variables(3, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+    t.Error("TaylorReal test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("TaylorReal test failed!")
+    }
+    if math.Abs(y1.GetDerivative(2, i) - y2.GetDerivative(2, i)) > 1e-10 {
+      t.Error("TaylorReal test failed!")
+    }
+  }
+}
+
+func TestTaylorReal5(t *testing.T) {
+
+  // compare first and second derivatives of inverse trigonometric and
+  // log-space functions with Real
+  f := func(x Vector) Scalar {
+    y := Mul(Asin(x[0]), Acosh(x[1]))
+    y  = Add(y, Atan2(Atanh(x[0]), Expm1(x[1])))
+    y  = Add(y, Mul(Logistic(x[0]), Softplus(Neg(x[1]))))
+    y  = Add(y, Div(Asinh(x[1]), Add(Acos(x[0]), Atan(x[1]))))
+    return y
+  }
+  x1 := NewVector(RealType,       []float64{0.4, 1.7})
+  x2 := NewVector(TaylorRealType, []float64{0.4, 1.7})
+
+  Variables(2, x1...)
+  Variables(3, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+    t.Error("TaylorReal test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("TaylorReal test failed!")
+    }
+    if math.Abs(y1.GetDerivative(2, i) - y2.GetDerivative(2, i)) > 1e-10 {
+      t.Error("TaylorReal test failed!")
+    }
+  }
+}
+
+func TestTaylorReal6(t *testing.T) {
+
+  // compare first and second derivatives of special functions with Real
+  f := func(x Vector) Scalar {
+    y := Mul(Digamma(x[0]), Lbeta(x[0], x[1]))
+    y  = Add(y, Div(Beta(x[1], x[0]), Trigamma(x[1])))
+    y  = Add(y, Mul(Zeta(x[1]), GammaQ(NewBareReal(1.5), x[0])))
+    y  = Add(y, Polygamma(3, Add(x[0], x[1])))
+    return y
+  }
+  x1 := NewVector(RealType,       []float64{1.4, 2.3})
+  x2 := NewVector(TaylorRealType, []float64{1.4, 2.3})
+
+  Variables(2, x1...)
+  Variables(3, x2...)
+
+  y1 := f(x1)
+  y2 := f(x2)
+
+  if math.Abs(y1.GetValue() - y2.GetValue()) > 1e-12 {
+    t.Error("TaylorReal test failed!")
+  }
+  for i := 0; i < 2; i++ {
+    if math.Abs(y1.GetDerivative(1, i) - y2.GetDerivative(1, i)) > 1e-10 {
+      t.Error("TaylorReal test failed!")
+    }
+    if math.Abs(y1.GetDerivative(2, i) - y2.GetDerivative(2, i)) > 1e-8 {
+      t.Error("TaylorReal test failed!")
+    }
+  }
+}
+
+func TestTaylorReal7(t *testing.T) {
+
+  x := NewTaylorReal(0.3)
+  x.SetVariable(0, 1, 7)
+
+  y1 := Mul(x, Exp(x))
+  y2 := Div(NewTaylorReal(1.0), Sub(NewTaylorReal(1.0), x))
+  y3 := Log1p(x)
+  y4 := Sqrt(x)
+
+  for n := 1; n <= 7; n++ {
+    k  := float64(n)
+    // (x e^x)^(n) = (x + n) e^x
+    r1 := (0.3 + k)*math.Exp(0.3)
+    // (1/(1-x))^(n) = n!/(1-x)^(n+1)
+    r2 := special.Factorial(n)/math.Pow(0.7, k+1)
+    // log(1+x)^(n) = (-1)^(n-1) (n-1)!/(1+x)^n
+    r3 := math.Pow(-1, k-1)*special.Factorial(n-1)/math.Pow(1.3, k)
+    // sqrt(x)^(n) = prod_{i<n} (1/2 - i) x^(1/2-n)
+    r4 := math.Pow(0.3, 0.5-k)
+    for i := 0; i < n; i++ {
+      r4 *= 0.5 - float64(i)
+    }
+    if math.Abs(y1.GetDerivative(n, 0) - r1) > 1e-10*math.Abs(r1) {
+      t.Errorf("TaylorReal test failed for order `%d'", n)
+    }
+    if math.Abs(y2.GetDerivative(n, 0) - r2) > 1e-10*math.Abs(r2) {
+      t.Errorf("TaylorReal test failed for order `%d'", n)
+    }
+    if math.Abs(y3.GetDerivative(n, 0) - r3) > 1e-10*math.Abs(r3) {
+      t.Errorf("TaylorReal test failed for order `%d'", n)
+    }
+    if math.Abs(y4.GetDerivative(n, 0) - r4) > 1e-10*math.Abs(r4) {
+      t.Errorf("TaylorReal test failed for order `%d'", n)
+    }
+  }
+}
+
+func TestTaylorReal8(t *testing.T) {
+
+  // higher derivatives along each variable of f(x0, x1) = x0^3 sin(x1)
+  x := NewVector(TaylorRealType, []float64{1.5, 0.4})
+  Variables(4, x...)
+
+  y := Mul(Pow(x[0], NewBareReal(3)), Sin(x[1]))
+
+  s, c := math.Sin(0.4), math.Cos(0.4)
+  r0 := []float64{3*1.5*1.5*s, 6*1.5*s, 6*s, 0}
+  r1 := []float64{1.5*1.5*1.5*c, -1.5*1.5*1.5*s, -1.5*1.5*1.5*c, 1.5*1.5*1.5*s}
+  for i := 1; i <= 4; i++ {
+    if math.Abs(y.GetDerivative(i, 0) - r0[i-1]) > 1e-10 {
+      t.Errorf("TaylorReal test failed for order `%d'", i)
+    }
+    if math.Abs(y.GetDerivative(i, 1) - r1[i-1]) > 1e-10 {
+      t.Errorf("TaylorReal test failed for order `%d'", i)
+    }
+  }
+  if y.GetHessian(1, 1) != y.GetDerivative(2, 1) {
+    t.Error("TaylorReal test failed!")
+  }
+  // mixed partial derivatives
+  if math.Abs(y.GetHessian(0, 1) - 3*1.5*1.5*c) > 1e-10 || y.GetHessian(1, 0) != y.GetHessian(0, 1) {
+    t.Error("TaylorReal test failed!")
+  }
+}
+
+func TestTaylorReal9(t *testing.T) {
+
+  f := func(x Vector) Scalar {
+    // x0^2 exp(x1 x2) / x2 + gamma(x0)
+    y := Div(Mul(Mul(x[0], x[0]), Exp(Mul(x[1], x[2]))), x[2])
+    return Add(y, Gamma(x[0]))
+  }
+  x1 := NewVector(RealType,       []float64{1.5, 0.4, 2.0})
+  x2 := NewVector(TaylorRealType, []float64{1.5, 0.4, 2.0})
+
+  if Mnorm(MsubM(Hessian(f, x1), Hessian(f, x2))).GetValue() > 1e-10 {
+    t.Error("TaylorReal test failed!")
+  }
+  // copy mixed partial derivatives to other scalar types
+  x2.Variables(2)
+  r := NullReal()
+  r.Copy(f(x2))
+  if math.Abs(r.GetHessian(1, 2) - Hessian(f, x1).At(1, 2).GetValue()) > 1e-10 {
+    t.Error("TaylorReal test failed!")
+  }
+}
+
+func TestTaylorReal10(t *testing.T) {
+
+  x := NewTaylorReal(0.5)
+  x.SetVariable(0, 1, 2)
+  // derivatives beyond the order are ignored
+  x.SetDerivative(3, 0, 1.0)
+  if x.GetDerivative(3, 0) != 0.0 {
+    t.Error("TaylorReal test failed!")
+  }
+  // integer powers at zero
+  z := NewVector(TaylorRealType, []float64{0.0, 1.0})
+  Variables(4, z...)
+  y := Mul(Pow(z[0], NewBareReal(3)), z[1])
+  if y.GetDerivative(3, 0) != 6.0 || y.GetHessian(0, 1) != 0.0 {
+    t.Error("TaylorReal test failed!")
+  }
+  // user-defined functions provide derivatives up to order two
+  x.SetVariable(0, 1, 3)
+  defer func() {
+    if recover() == nil {
+      t.Error("TaylorReal test failed!")
+    }
+  }()
+  NullTaylorReal().Monadic(x, 1.0, 2.0, 3.0)
+}
+
+func TestTaylor(t *testing.T) {
+
+  // exp(x) = exp(x0) sum_k (x - x0)^k / k!
+  p := Taylor(func(x Scalar) Scalar { return Exp(x) }, 0.5, 12)
+
+  for _, x := range []float64{0.3, 0.5, 0.9} {
+    if math.Abs(p.Eval(x - 0.5) - math.Exp(x)) > 1e-12 {
+      t.Error("Taylor test failed!")
+    }
+  }
+  // polynomials are reproduced exactly
+  q := Taylor(func(x Scalar) Scalar {
+    return Sub(Mul(x, Mul(x, x)), Mul(x, NewBareReal(2.0)))
+  }, 1.0, 5)
+  if q.Eval(2.0) != 3.0*3.0*3.0 - 2.0*3.0 {
+    t.Error("Taylor test failed!")
+  }
+  // constants on the left adopt the type of x
+  r := Taylor(func(x Scalar) Scalar { return Mul(NewReal(2.0), Exp(x)) }, 0.0, 5)
+  if math.Abs(r.Eval(0.1) - 2.0*(1.0 + 0.1 + 0.01/2 + 0.001/6 + 0.0001/24 + 0.00001/120)) > 1e-14 {
+    t.Error("Taylor test failed!")
+  }
+}
+
+func TestTaylorInvalid(t *testing.T) {
+
+  defer func() {
+    if recover() == nil {
+      t.Error("Taylor test failed!")
+    }
+  }()
+  // the result is not a TaylorReal
+  Taylor(func(x Scalar) Scalar {
+    r := NewReal(x.GetValue())
+    r.SetVariable(0, 1, 1)
+    return r
+  }, 0.0, 5)
+}
+
+func TestTaylorConstant(t *testing.T) {
+
+  for _, f := range []func(Scalar) Scalar{
+    func(x Scalar) Scalar { return NewBareReal(2.0) },
+    func(x Scalar) Scalar { return NewReal(2.0) },
+    func(x Scalar) Scalar { return NewTaylorReal(2.0) } } {
+    p := Taylor(f, 1.0, 3)
+    for _, z := range []float64{-1.0, 0.0, 0.5} {
+      if p.Eval(z) != 2.0 {
+        t.Error("Taylor test failed!")
+      }
+    }
+  }
+}
@@ -0,0 +1,475 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "bytes"
+import "errors"
+import "fmt"
+import "reflect"
+
+/* tensor type declaration
+ * -------------------------------------------------------------------------- */
+
+// N-dimensional array of scalars. The element with index (i_1, ..., i_n) is
+// stored at Values[Offset + i_1*Strides[0] + ... + i_n*Strides[n-1]].
+// Transposed tensors, slices and broadcasted tensors are views that share
+// storage with their parent. A tensor of rank zero holds a single scalar.
+type Tensor struct {
+  Values  Vector
+  Shape   []int
+  Strides []int
+  Offset  int
+}
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Create a tensor with given shape, where values are given in row-major
+// order. If values is nil, all elements are initialized to zero.
+func NewTensor(t ScalarType, shape []int, values []float64) *Tensor {
+  n := tensorSize(shape)
+  if values == nil {
+    values = make([]float64, n)
+  }
+  if len(values) != n {
+    panic("NewTensor(): shape does not match number of values!")
+  }
+  return newTensor(NewVector(t, values), shape)
+}
+
+func NullTensor(t ScalarType, shape ...int) *Tensor {
+  return newTensor(NullVector(t, tensorSize(shape)), shape)
+}
+
+// Create a tensor with given shape that shares all elements with v. If no
+// shape is given, a tensor of rank one is returned.
+func NewTensorFromVector(v Vector, shape ...int) *Tensor {
+  if len(shape) == 0 {
+    shape = []int{len(v)}
+  }
+  if tensorSize(shape) != len(v) {
+    panic("NewTensorFromVector(): shape does not match vector length!")
+  }
+  return newTensor(v, shape)
+}
+
+// Create a tensor of rank two that shares all elements with m. Dense
+// matrices and matrix views share storage, i.e. also SetReference on the
+// matrix is visible in the tensor. Structural zeros of sparse and band
+// matrices are replaced by fresh scalars.
+func NewTensorFromMatrix(m Matrix) *Tensor {
+  switch a := m.(type) {
+  case *DenseMatrix:
+    return tensorFromView(a.View())
+  case *DenseMatrixView:
+    return tensorFromView(a)
+  }
+  n, k := m.Dims()
+  v := NilVector(n*k)
+  for i := 0; i < n; i++ {
+    for j := 0; j < k; j++ {
+      v[i*k+j] = m.ConstAt(i, j)
+    }
+  }
+  return newTensor(v, []int{n, k})
+}
+
+func newTensor(v Vector, shape []int) *Tensor {
+  shape = append([]int{}, shape...)
+  return &Tensor{v, shape, tensorStrides(shape), 0}
+}
+
+func tensorFromView(m *DenseMatrixView) *Tensor {
+  return &Tensor{
+    Values : m.Values,
+    Shape  : []int{m.Rows, m.Cols},
+    Strides: []int{m.RowStride, m.ColStride},
+    Offset : m.Offset}
+}
+
+// Number of elements of a tensor with given shape.
+func tensorSize(shape []int) int {
+  n := 1
+  for _, k := range shape {
+    if k < 0 {
+      panic("invalid tensor shape!")
+    }
+    n *= k
+  }
+  return n
+}
+
+// Strides of a contiguous tensor in row-major order.
+func tensorStrides(shape []int) []int {
+  s := make([]int, len(shape))
+  k := 1
+  for i := len(shape)-1; i >= 0; i-- {
+    s[i] = k
+    k   *= shape[i]
+  }
+  return s
+}
+
+/* copy and cloning
+ * -------------------------------------------------------------------------- */
+
+// Returns a contiguous tensor with copies of all elements.
+func (t *Tensor) Clone() *Tensor {
+  v := NilVector(t.Size())
+  t.mapIndex(func(k int, idx []int) {
+    v[k] = t.Values[t.index(idx)].Clone()
+  })
+  return newTensor(v, t.Shape)
+}
+
+// Returns a copy of the tensor that shares storage with t.
+func (t *Tensor) CloneShallow() *Tensor {
+  return &Tensor{t.Values, append([]int{}, t.Shape...), append([]int{}, t.Strides...), t.Offset}
+}
+
+// Copy elements of b, which is broadcasted to the shape of t.
+func (t *Tensor) Copy(b *Tensor) {
+  b = b.BroadcastTo(t.Shape...)
+  t.mapIndex(func(k int, idx []int) {
+    t.Values[t.index(idx)].Copy(b.Values[b.index(idx)])
+  })
+}
+
+/* field access
+ * -------------------------------------------------------------------------- */
+
+func (t *Tensor) index(idx []int) int {
+  k := t.Offset
+  for i, j := range idx {
+    k += j*t.Strides[i]
+  }
+  return k
+}
+
+func (t *Tensor) checkedIndex(idx []int) int {
+  if len(idx) != len(t.Shape) {
+    panic(fmt.Sprintf("tensor of rank %d indexed with %d indices!", len(t.Shape), len(idx)))
+  }
+  for i, j := range idx {
+    if j < 0 || j >= t.Shape[i] {
+      panic("tensor index out of range!")
+    }
+  }
+  return t.index(idx)
+}
+
+// Call f for all elements in row-major order, where k is the position of
+// the element and idx its index. The index slice is reused between calls.
+func (t *Tensor) mapIndex(f func(k int, idx []int)) {
+  n := t.Size()
+  if n == 0 {
+    return
+  }
+  idx := make([]int, len(t.Shape))
+  for k := 0; k < n; k++ {
+    f(k, idx)
+    // increment index
+    for i := len(idx)-1; i >= 0; i-- {
+      if idx[i]++; idx[i] < t.Shape[i] {
+        break
+      }
+      idx[i] = 0
+    }
+  }
+}
+
+func (t *Tensor) Rank() int {
+  return len(t.Shape)
+}
+
+// Number of elements.
+func (t *Tensor) Size() int {
+  return tensorSize(t.Shape)
+}
+
+func (t *Tensor) Dims() []int {
+  return append([]int{}, t.Shape...)
+}
+
+// True if elements are stored contiguously in row-major order.
+func (t *Tensor) IsContiguous() bool {
+  s := tensorStrides(t.Shape)
+  for i := 0; i < len(s); i++ {
+    if t.Shape[i] > 1 && s[i] != t.Strides[i] {
+      return false
+    }
+  }
+  return true
+}
+
+func (t *Tensor) At(idx ...int) Scalar {
+  return t.Values[t.checkedIndex(idx)].Clone()
+}
+
+func (t *Tensor) ReferenceAt(idx ...int) Scalar {
+  return t.Values[t.checkedIndex(idx)]
+}
+
+func (t *Tensor) Set(s Scalar, idx ...int) {
+  t.Values[t.checkedIndex(idx)].Copy(s)
+}
+
+func (t *Tensor) SetReference(s Scalar, idx ...int) {
+  t.Values[t.checkedIndex(idx)] = s
+}
+
+// Returns references to all elements in row-major order.
+func (t *Tensor) GetValues() Vector {
+  v := NilVector(t.Size())
+  t.mapIndex(func(k int, idx []int) {
+    v[k] = t.Values[t.index(idx)]
+  })
+  return v
+}
+
+// Set references to all elements in row-major order.
+func (t *Tensor) SetValues(v Vector) {
+  if len(v) != t.Size() {
+    panic("SetValues(): vector dimension does not match!")
+  }
+  t.mapIndex(func(k int, idx []int) {
+    t.Values[t.index(idx)] = v[k]
+  })
+}
+
+func (t *Tensor) Reset() {
+  t.mapIndex(func(k int, idx []int) {
+    t.Values[t.index(idx)].Reset()
+  })
+}
+
+func (t *Tensor) ResetDerivatives() {
+  t.mapIndex(func(k int, idx []int) {
+    t.Values[t.index(idx)].ResetDerivatives()
+  })
+}
+
+/* views
+ * -------------------------------------------------------------------------- */
+
+// Returns a tensor with the same elements and a new shape. One dimension
+// may be given as -1, in which case it is inferred from the number of
+// elements. The result shares storage with t if t is contiguous, otherwise
+// it holds references to the elements of t.
+func (t *Tensor) Reshape(shape ...int) (*Tensor, error) {
+  shape = append([]int{}, shape...)
+  n, k := 1, -1
+  for i, j := range shape {
+    switch {
+    case j == -1 && k == -1:
+      k = i
+    case j < 0:
+      return nil, errors.New("Reshape(): invalid shape")
+    default:
+      n *= j
+    }
+  }
+  if k != -1 {
+    if n == 0 {
+      return nil, fmt.Errorf("Reshape(): cannot infer dimension of shape %v", shape)
+    }
+    shape[k] = t.Size()/n
+    n *= shape[k]
+  }
+  if n != t.Size() {
+    return nil, fmt.Errorf("Reshape(): cannot reshape tensor of shape %v into shape %v", t.Shape, shape)
+  }
+  if t.IsContiguous() {
+    return &Tensor{t.Values, shape, tensorStrides(shape), t.Offset}, nil
+  }
+  return newTensor(t.GetValues(), shape), nil
+}
+
+// Returns a view with permuted axes, i.e. axis i of the result is axis
+// axes[i] of t. If no axes are given, the order of axes is reversed.
+func (t *Tensor) Transpose(axes ...int) *Tensor {
+  n := t.Rank()
+  if len(axes) == 0 {
+    axes = make([]int, n)
+    for i := 0; i < n; i++ {
+      axes[i] = n-i-1
+    }
+  }
+  if len(axes) != n {
+    panic("Transpose(): invalid permutation!")
+  }
+  r := &Tensor{t.Values, make([]int, n), make([]int, n), t.Offset}
+  seen := make([]bool, n)
+  for i, j := range axes {
+    if j < 0 || j >= n || seen[j] {
+      panic("Transpose(): invalid permutation!")
+    }
+    seen[j] = true
+    r.Shape  [i] = t.Shape  [j]
+    r.Strides[i] = t.Strides[j]
+  }
+  return r
+}
+
+// Returns a view of the elements from to to-1 along the given axis.
+func (t *Tensor) Slice(axis, from, to int) *Tensor {
+  axis = t.axis(axis)
+  if from < 0 || to > t.Shape[axis] || from > to {
+    panic("Slice(): index out of range!")
+  }
+  r := t.CloneShallow()
+  r.Shape[axis] = to-from
+  if to > from {
+    r.Offset += from*t.Strides[axis]
+  }
+  return r
+}
+
+// Returns a view of the ith element along the given axis, which has rank
+// one less than t.
+func (t *Tensor) Index(axis, i int) *Tensor {
+  axis = t.axis(axis)
+  if i < 0 || i >= t.Shape[axis] {
+    panic("Index(): index out of range!")
+  }
+  r := &Tensor{t.Values, nil, nil, t.Offset + i*t.Strides[axis]}
+  r.Shape   = append(append([]int{}, t.Shape  [:axis]...), t.Shape  [axis+1:]...)
+  r.Strides = append(append([]int{}, t.Strides[:axis]...), t.Strides[axis+1:]...)
+  return r
+}
+
+// Returns a view of t with given shape, where dimensions of size one are
+// repeated as required by the broadcasting rules (see TaddT).
+func (t *Tensor) BroadcastTo(shape ...int) *Tensor {
+  n := len(shape)
+  m := t.Rank()
+  if m > n {
+    panic(fmt.Sprintf("cannot broadcast tensor of shape %v to shape %v!", t.Shape, shape))
+  }
+  r := &Tensor{t.Values, append([]int{}, shape...), make([]int, n), t.Offset}
+  for i := 0; i < m; i++ {
+    switch {
+    case t.Shape[m-i-1] == shape[n-i-1]:
+      r.Strides[n-i-1] = t.Strides[m-i-1]
+    case t.Shape[m-i-1] == 1:
+      r.Strides[n-i-1] = 0
+    default:
+      panic(fmt.Sprintf("cannot broadcast tensor of shape %v to shape %v!", t.Shape, shape))
+    }
+  }
+  return r
+}
+
+// Convert negative axes, which count from the last axis.
+func (t *Tensor) axis(axis int) int {
+  if axis < 0 {
+    axis += t.Rank()
+  }
+  if axis < 0 || axis >= t.Rank() {
+    panic(fmt.Sprintf("invalid axis for tensor of rank %d!", t.Rank()))
+  }
+  return axis
+}
+
+/* conversion to vectors and matrices
+ * -------------------------------------------------------------------------- */
+
+// Returns references to all elements in row-major order as vector.
+func (t *Tensor) Vector() Vector {
+  return t.GetValues()
+}
+
+// Returns a matrix view of a tensor of rank two, which shares storage with
+// t.
+func (t *Tensor) Matrix() *DenseMatrixView {
+  if t.Rank() != 2 {
+    panic("Matrix(): tensor is not of rank two!")
+  }
+  return &DenseMatrixView{
+    Values   : t.Values,
+    Offset   : t.Offset,
+    Rows     : t.Shape[0],
+    Cols     : t.Shape[1],
+    RowStride: t.Strides[0],
+    ColStride: t.Strides[1]}
+}
+
+/* implement ScalarContainer
+ * -------------------------------------------------------------------------- */
+
+func (t *Tensor) Map(f func(Scalar) Scalar) {
+  t.mapIndex(func(k int, idx []int) {
+    i := t.index(idx)
+    t.Values[i] = f(t.Values[i])
+  })
+}
+
+func (t *Tensor) Reduce(f func(Scalar, Scalar) Scalar) Scalar {
+  v := t.GetValues()
+  r := v[0].Clone()
+  for i := 1; i < len(v); i++ {
+    r = f(r, v[i])
+  }
+  return r
+}
+
+func (t *Tensor) ElementType() ScalarType {
+  if t.Size() > 0 {
+    return reflect.TypeOf(t.Values[t.Offset])
+  }
+  return nil
+}
+
+func (t *Tensor) ConvertElementType(s ScalarType) {
+  t.Map(func(x Scalar) Scalar {
+    return NewScalar(s, x.GetValue())
+  })
+}
+
+func (t *Tensor) Variables(order int) {
+  Variables(order, t.GetValues()...)
+}
+
+func (t *Tensor) VariablesAt(offset, order int) {
+  VariablesAt(offset, order, t.GetValues()...)
+}
+
+/* type conversion
+ * -------------------------------------------------------------------------- */
+
+func (t *Tensor) String() string {
+  var buffer bytes.Buffer
+  t.writeString(&buffer, 0, t.Offset)
+  return buffer.String()
+}
+
+func (t *Tensor) writeString(buffer *bytes.Buffer, axis, k int) {
+  if axis == t.Rank() {
+    buffer.WriteString(t.Values[k].String())
+    return
+  }
+  buffer.WriteString("[")
+  for i := 0; i < t.Shape[axis]; i++ {
+    if i != 0 {
+      buffer.WriteString(", ")
+    }
+    t.writeString(buffer, axis+1, k + i*t.Strides[axis])
+  }
+  buffer.WriteString("]")
+}
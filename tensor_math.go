@@ -0,0 +1,298 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "fmt"
+import "math"
+
+/* broadcasting
+ * -------------------------------------------------------------------------- */
+
+// Shape of the result of an element-wise operation on tensors of shape a
+// and b. Shapes are aligned at the last axis, and dimensions must either
+// match or one of them must be one.
+func broadcastShape(a, b []int) []int {
+  n := iMax(len(a), len(b))
+  r := make([]int, n)
+  for i := 0; i < n; i++ {
+    da, db := 1, 1
+    if i < len(a) {
+      da = a[len(a)-i-1]
+    }
+    if i < len(b) {
+      db = b[len(b)-i-1]
+    }
+    switch {
+    case da == db || db == 1:
+      r[n-i-1] = da
+    case da == 1:
+      r[n-i-1] = db
+    default:
+      panic(fmt.Sprintf("tensor shapes %v and %v cannot be broadcast!", a, b))
+    }
+  }
+  return r
+}
+
+func equalShape(a, b []int) bool {
+  if len(a) != len(b) {
+    return false
+  }
+  for i := 0; i < len(a); i++ {
+    if a[i] != b[i] {
+      return false
+    }
+  }
+  return true
+}
+
+// Call f for all elements of r and the corresponding elements of a and b,
+// which are broadcasted to the shape of r.
+func (r *Tensor) tensorDyadic(a, b *Tensor, f func(c, a, b Scalar)) *Tensor {
+  if s := broadcastShape(a.Shape, b.Shape); !equalShape(r.Shape, s) {
+    panic(fmt.Sprintf("tensor shape %v does not match broadcast shape %v!", r.Shape, s))
+  }
+  a = a.BroadcastTo(r.Shape...)
+  b = b.BroadcastTo(r.Shape...)
+  r.mapIndex(func(k int, idx []int) {
+    f(r.Values[r.index(idx)], a.Values[a.index(idx)], b.Values[b.index(idx)])
+  })
+  return r
+}
+
+// Call f for all elements of r and the corresponding elements of a.
+func (r *Tensor) tensorMonadic(a *Tensor, f func(c, a Scalar)) *Tensor {
+  if !equalShape(r.Shape, a.Shape) {
+    panic(fmt.Sprintf("tensor shapes %v and %v do not match!", r.Shape, a.Shape))
+  }
+  r.mapIndex(func(k int, idx []int) {
+    f(r.Values[r.index(idx)], a.Values[a.index(idx)])
+  })
+  return r
+}
+
+func nullTensorFor(a, b *Tensor) *Tensor {
+  return NullTensor(a.ElementType(), broadcastShape(a.Shape, b.Shape)...)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Test if elements in a equal elements in b.
+func Tequal(a, b *Tensor) bool {
+  if !equalShape(a.Shape, b.Shape) {
+    panic("Tequal(): tensor dimensions do not match!")
+  }
+  r := true
+  a.mapIndex(func(k int, idx []int) {
+    if !Equal(a.Values[a.index(idx)], b.Values[b.index(idx)]) {
+      r = false
+    }
+  })
+  return r
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Element-wise addition of two tensors with broadcasting. The result is
+// stored in r, which must have the broadcast shape of a and b.
+func (r *Tensor) TaddT(a, b *Tensor) *Tensor {
+  return r.tensorDyadic(a, b, func(c, a, b Scalar) { c.Add(a, b) })
+}
+
+// Element-wise addition of two tensors with broadcasting, i.e. shapes are
+// aligned at the last axis and dimensions of size one (or missing leading
+// dimensions) are repeated to match the other operand. For instance, a
+// tensor of shape [3, 1, 5] and a tensor of shape [4, 5] give a result of
+// shape [3, 4, 5].
+func TaddT(a, b *Tensor) *Tensor {
+  return nullTensorFor(a, b).TaddT(a, b)
+}
+
+// Element-wise addition of a tensor and a scalar. The result is stored in
+// r.
+func (r *Tensor) TaddS(a *Tensor, b Scalar) *Tensor {
+  return r.tensorMonadic(a, func(c, a Scalar) { c.Add(a, b) })
+}
+
+func TaddS(a *Tensor, b Scalar) *Tensor {
+  return NullTensor(a.ElementType(), a.Shape...).TaddS(a, b)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Element-wise substraction of two tensors with broadcasting. The result is
+// stored in r.
+func (r *Tensor) TsubT(a, b *Tensor) *Tensor {
+  return r.tensorDyadic(a, b, func(c, a, b Scalar) { c.Sub(a, b) })
+}
+
+// Element-wise substraction of two tensors with broadcasting.
+func TsubT(a, b *Tensor) *Tensor {
+  return nullTensorFor(a, b).TsubT(a, b)
+}
+
+func (r *Tensor) TsubS(a *Tensor, b Scalar) *Tensor {
+  return r.tensorMonadic(a, func(c, a Scalar) { c.Sub(a, b) })
+}
+
+func TsubS(a *Tensor, b Scalar) *Tensor {
+  return NullTensor(a.ElementType(), a.Shape...).TsubS(a, b)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Element-wise multiplication of two tensors with broadcasting. The result
+// is stored in r.
+func (r *Tensor) TmulT(a, b *Tensor) *Tensor {
+  return r.tensorDyadic(a, b, func(c, a, b Scalar) { c.Mul(a, b) })
+}
+
+// Element-wise multiplication of two tensors with broadcasting.
+func TmulT(a, b *Tensor) *Tensor {
+  return nullTensorFor(a, b).TmulT(a, b)
+}
+
+func (r *Tensor) TmulS(a *Tensor, b Scalar) *Tensor {
+  return r.tensorMonadic(a, func(c, a Scalar) { c.Mul(a, b) })
+}
+
+func TmulS(a *Tensor, b Scalar) *Tensor {
+  return NullTensor(a.ElementType(), a.Shape...).TmulS(a, b)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Element-wise division of two tensors with broadcasting. The result is
+// stored in r.
+func (r *Tensor) TdivT(a, b *Tensor) *Tensor {
+  return r.tensorDyadic(a, b, func(c, a, b Scalar) { c.Div(a, b) })
+}
+
+// Element-wise division of two tensors with broadcasting.
+func TdivT(a, b *Tensor) *Tensor {
+  return nullTensorFor(a, b).TdivT(a, b)
+}
+
+func (r *Tensor) TdivS(a *Tensor, b Scalar) *Tensor {
+  return r.tensorMonadic(a, func(c, a Scalar) { c.Div(a, b) })
+}
+
+func TdivS(a *Tensor, b Scalar) *Tensor {
+  return NullTensor(a.ElementType(), a.Shape...).TdivS(a, b)
+}
+
+/* reductions along an axis
+ * -------------------------------------------------------------------------- */
+
+// Call f for all elements of r with references to the elements of a along
+// the given axis. The shape of r must be the shape of a without the axis.
+func (r *Tensor) reduceAxis(a *Tensor, axis int, f func(c Scalar, v Vector)) *Tensor {
+  axis = a.axis(axis)
+  if a.Shape[axis] == 0 {
+    panic("cannot reduce tensor along axis of length zero!")
+  }
+  s := a.Index(axis, 0)
+  if !equalShape(r.Shape, s.Shape) {
+    panic(fmt.Sprintf("tensor shape %v does not match reduced shape %v!", r.Shape, s.Shape))
+  }
+  v := NilVector(a.Shape[axis])
+  r.mapIndex(func(k int, idx []int) {
+    i := s.index(idx)
+    for j := 0; j < len(v); j++ {
+      v[j] = a.Values[i + j*a.Strides[axis]]
+    }
+    f(r.Values[r.index(idx)], v)
+  })
+  return r
+}
+
+func nullTensorReduced(a *Tensor, axis int) *Tensor {
+  axis = a.axis(axis)
+  s := append(append([]int{}, a.Shape[:axis]...), a.Shape[axis+1:]...)
+  return NullTensor(a.ElementType(), s...)
+}
+
+/* -------------------------------------------------------------------------- */
+
+// Sum of elements along the given axis. The result is stored in r.
+// Negative axes count from the last axis.
+func (r *Tensor) Tsum(a *Tensor, axis int) *Tensor {
+  return r.reduceAxis(a, axis, func(c Scalar, v Vector) {
+    c.Reset()
+    for j := 0; j < len(v); j++ {
+      c.Add(c, v[j])
+    }
+  })
+}
+
+// Sum of elements along the given axis. The result has rank one less than
+// a.
+func Tsum(a *Tensor, axis int) *Tensor {
+  return nullTensorReduced(a, axis).Tsum(a, axis)
+}
+
+// Mean of elements along the given axis. The result is stored in r.
+func (r *Tensor) Tmean(a *Tensor, axis int) *Tensor {
+  return r.reduceAxis(a, axis, func(c Scalar, v Vector) {
+    c.Reset()
+    for j := 0; j < len(v); j++ {
+      c.Add(c, v[j])
+    }
+    c.Div(c, NewBareReal(float64(len(v))))
+  })
+}
+
+func Tmean(a *Tensor, axis int) *Tensor {
+  return nullTensorReduced(a, axis).Tmean(a, axis)
+}
+
+// Maximum of elements along the given axis. At ties derivatives are
+// averaged over all maximal elements. The result is stored in r.
+func (r *Tensor) Tmax(a *Tensor, axis int) *Tensor {
+  return r.reduceAxis(a, axis, func(c Scalar, v Vector) {
+    m := v[0].GetValue()
+    for j := 1; j < len(v); j++ {
+      m = math.Max(m, v[j].GetValue())
+    }
+    n := 0
+    c.Reset()
+    for j := 0; j < len(v); j++ {
+      if v[j].GetValue() == m {
+        c.Add(c, v[j]); n++
+      }
+    }
+    c.Div(c, NewBareReal(float64(n)))
+  })
+}
+
+func Tmax(a *Tensor, axis int) *Tensor {
+  return nullTensorReduced(a, axis).Tmax(a, axis)
+}
+
+// log(sum_i exp(a_i)) along the given axis computed without overflow. The
+// result is stored in r.
+func (r *Tensor) TlogSumExp(a *Tensor, axis int) *Tensor {
+  return r.reduceAxis(a, axis, func(c Scalar, v Vector) {
+    c.LogSumExp(v)
+  })
+}
+
+func TlogSumExp(a *Tensor, axis int) *Tensor {
+  return nullTensorReduced(a, axis).TlogSumExp(a, axis)
+}
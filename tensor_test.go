@@ -0,0 +1,150 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+//import "fmt"
+import "math"
+import "testing"
+
+/* -------------------------------------------------------------------------- */
+
+func TestTensor(t *testing.T) {
+
+  a := NewTensor(RealType, []int{2, 3, 4}, []float64{
+     1, 2, 3, 4,  5, 6, 7, 8,  9,10,11,12,
+    13,14,15,16, 17,18,19,20, 21,22,23,24})
+
+  if a.At(1, 2, 3).GetValue() != 24 || a.At(0, 1, 2).GetValue() != 7 {
+    t.Error("At failed!")
+  }
+  // transpose is a view
+  b := a.Transpose(2, 0, 1)
+  if d := b.Dims(); d[0] != 4 || d[1] != 2 || d[2] != 3 || b.At(3, 1, 2).GetValue() != 24 {
+    t.Error("Transpose failed!")
+  }
+  b.Set(NewReal(-1), 0, 1, 0)
+  if a.At(1, 0, 0).GetValue() != -1 {
+    t.Error("Transpose failed!")
+  }
+  a.Set(NewReal(13), 1, 0, 0)
+  // reshape of a non-contiguous tensor shares elements
+  if c, err := b.Reshape(2, -1); err != nil || c.Dims()[1] != 12 || c.At(1, 11).GetValue() != 24 {
+    t.Error("Reshape failed!")
+  }
+  if _, err := a.Reshape(5, -1); err == nil {
+    t.Error("Reshape failed!")
+  }
+  if _, err := NullTensor(RealType, 0).Reshape(-1, 0); err == nil {
+    t.Error("Reshape failed!")
+  }
+  // slices
+  s := a.Slice(2, 1, 3).Index(0, 1)
+  if !Tequal(s, NewTensor(RealType, []int{3, 2}, []float64{14,15,18,19,22,23})) {
+    t.Error("Slice failed!")
+  }
+  // matrix views share storage
+  m := s.Matrix()
+  m.MmulS(m, NewReal(2))
+  if a.At(1, 2, 2).GetValue() != 46 || a.At(0, 2, 2).GetValue() != 11 {
+    t.Error("Matrix failed!")
+  }
+  x := NewDenseMatrix(RealType, 2, 3, []float64{1,2,3,4,5,6})
+  y := NewTensorFromMatrix(x.T())
+  y.Set(NewReal(0), 2, 1)
+  if x.At(1, 2).GetValue() != 0 || !Vequal(y.Vector(), NewVector(RealType, []float64{1,4,2,5,3,0})) {
+    t.Error("NewTensorFromMatrix failed!")
+  }
+}
+
+func TestTensorBroadcast(t *testing.T) {
+
+  a := NewTensor(RealType, []int{2, 1, 3}, []float64{1,2,3,4,5,6})
+  b := NewTensor(RealType, []int{2, 1},    []float64{10,20})
+
+  r1 := TaddT(a, b)
+  r2 := NewTensor(RealType, []int{2, 2, 3}, []float64{
+    11,12,13, 21,22,23,
+    14,15,16, 24,25,26})
+  if !Tequal(r1, r2) {
+    t.Error("TaddT failed!")
+  }
+  if !Tequal(TsubT(b, a), TmulS(TsubT(a, b), NewReal(-1))) {
+    t.Error("TsubT failed!")
+  }
+  if r := TmulT(a, NewTensor(RealType, []int{3}, []float64{1,0,-1})); !Tequal(r, NewTensor(RealType, []int{2, 1, 3}, []float64{1,0,-3,4,0,-6})) {
+    t.Error("TmulT failed!")
+  }
+  func() {
+    defer func() {
+      if recover() == nil {
+        t.Error("TaddT did not panic!")
+      }
+    }()
+    TaddT(a, NewTensor(RealType, []int{2}, nil))
+  }()
+}
+
+func TestTensorReduce(t *testing.T) {
+
+  a := NewTensor(RealType, []int{2, 3}, []float64{1,5,3,4,2,6})
+
+  if !Tequal(Tsum(a, 0), NewTensor(RealType, []int{3}, []float64{5,7,9})) {
+    t.Error("Tsum failed!")
+  }
+  if !Tequal(Tmean(a, -1), NewTensor(RealType, []int{2}, []float64{3,4})) {
+    t.Error("Tmean failed!")
+  }
+  if !Tequal(Tmax(a, 1), NewTensor(RealType, []int{2}, []float64{5,6})) {
+    t.Error("Tmax failed!")
+  }
+  r := TlogSumExp(a, 0)
+  if math.Abs(r.At(1).GetValue() - math.Log(math.Exp(5)+math.Exp(2))) > 1e-10 {
+    t.Error("TlogSumExp failed!")
+  }
+  if s := Tsum(Tsum(a, 0), 0); s.Rank() != 0 || s.At().GetValue() != 21 {
+    t.Error("Tsum failed!")
+  }
+}
+
+func TestTensorDerivatives(t *testing.T) {
+
+  a := NewTensor(RealType, []int{2, 2}, []float64{1,2,3,4})
+  a.Variables(1)
+  // f(a) = sum_j max_i a_ij^2 + log sum_j exp(a_0j)
+  b := TmulT(a, a)
+  c := Tsum(Tmax(b, 0), 0).At()
+  d := TlogSumExp(a.Index(0, 0), 0).At()
+  f := Add(c, d)
+
+  z := math.Exp(1) + math.Exp(2)
+  g := []float64{math.Exp(1)/z, math.Exp(2)/z, 6, 8}
+  for i := 0; i < 4; i++ {
+    if math.Abs(f.GetDerivative(1, i) - g[i]) > 1e-10 {
+      t.Errorf("derivative %d failed!", i)
+    }
+  }  // derivatives are split evenly among all maximal elements
+  x := NewTensor(RealType, []int{3}, []float64{2,2,2})
+  x.Variables(1)
+  y := Tmax(x, 0).At()
+  for i := 0; i < 3; i++ {
+    if math.Abs(y.GetDerivative(1, i) - 1.0/3.0) > 1e-10 {
+      t.Error("Tmax failed!")
+    }
+  }
+}
@@ -158,6 +158,22 @@ func (v Vector) Variables(order int) {
   Variables(order, v...)
 }
 
+// Declare v as variables of a nested differentiation context (see
+// VariablesAt).
+func (v Vector) VariablesAt(offset, order int) {
+  VariablesAt(offset, order, v...)
+}
+
+// Maximal number of variables for which derivatives are stored in the
+// elements of v.
+func (v Vector) GetN() int {
+  n := 0
+  for i, _ := range v {
+    n = iMax(n, v[i].GetN())
+  }
+  return n
+}
+
 /* sorting
  * -------------------------------------------------------------------------- */
 
@@ -0,0 +1,133 @@
+/* Copyright (C) 2017 Philipp Benner
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package autodiff
+
+/* -------------------------------------------------------------------------- */
+
+import "bytes"
+
+/* vector type declaration
+ * -------------------------------------------------------------------------- */
+
+// Vector of float64 values stored in contiguous memory. Elements are
+// accessed as BareReal scalars without derivatives.
+type DenseFloat64Vector []float64
+
+/* constructors
+ * -------------------------------------------------------------------------- */
+
+// Allocate a vector and copy values.
+func NewDenseFloat64Vector(values []float64) DenseFloat64Vector {
+  v := make(DenseFloat64Vector, len(values))
+  copy(v, values)
+  return v
+}
+
+// Allocate a vector of zeros.
+func NullDenseFloat64Vector(length int) DenseFloat64Vector {
+  return make(DenseFloat64Vector, length)
+}
+
+/* -------------------------------------------------------------------------- */
+
+func (v DenseFloat64Vector) Clone() DenseFloat64Vector {
+  return NewDenseFloat64Vector(v)
+}
+
+// Copy values from w. Derivatives are dropped.
+func (v DenseFloat64Vector) Copy(w Vector) {
+  if len(v) != len(w) {
+    panic("CopyFrom(): Vector dimensions do not match!")
+  }
+  for i := 0; i < len(w); i++ {
+    v[i] = w[i].GetValue()
+  }
+}
+
+func (v DenseFloat64Vector) At(i int) Scalar {
+  return NewBareReal(v[i])
+}
+
+// Returns a BareReal that refers to the ith element.
+func (v DenseFloat64Vector) ReferenceAt(i int) Scalar {
+  return (*BareReal)(&v[i])
+}
+
+func (v DenseFloat64Vector) Set(s Scalar, i int) {
+  v[i] = s.GetValue()
+}
+
+func (v DenseFloat64Vector) Reset() {
+  for i := 0; i < len(v); i++ {
+    v[i] = 0.0
+  }
+}
+
+// Returns a vector of BareReal scalars that refer to the elements of v.
+func (v DenseFloat64Vector) Vector() Vector {
+  r := NilVector(len(v))
+  for i := 0; i < len(v); i++ {
+    r[i] = (*BareReal)(&v[i])
+  }
+  return r
+}
+
+func (v DenseFloat64Vector) String() string {
+  var buffer bytes.Buffer
+
+  buffer.WriteString("[")
+  for i, _ := range v {
+    if i != 0 {
+      buffer.WriteString(", ")
+    }
+    buffer.WriteString(v.ReferenceAt(i).String())
+  }
+  buffer.WriteString("]")
+
+  return buffer.String()
+}
+
+/* math
+ * -------------------------------------------------------------------------- */
+
+func (a DenseFloat64Vector) VdotV(b DenseFloat64Vector) float64 {
+  if len(a) != len(b) {
+    panic("vector dimensions do not match")
+  }
+  r := 0.0
+  for i := 0; i < len(a); i++ {
+    r += a[i]*b[i]
+  }
+  return r
+}
+
+// Matrix vector product of a and b. The result is stored in r.
+func (r DenseFloat64Vector) MdotV(a *DenseFloat64Matrix, b DenseFloat64Vector) DenseFloat64Vector {
+  n, m := a.Dims()
+  if len(r) != n || len(b) != m {
+    panic("matrix/vector dimensions do not match!")
+  }
+  s1, s2 := a.strides()
+  for i := 0; i < n; i++ {
+    t := 0.0
+    for j := 0; j < m; j++ {
+      t += a.Values[i*s1+j*s2]*b[j]
+    }
+    r[i] = t
+  }
+  return r
+}
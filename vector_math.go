@@ -18,6 +18,10 @@ package autodiff
 
 /* -------------------------------------------------------------------------- */
 
+import "math"
+
+/* -------------------------------------------------------------------------- */
+
 // Test if elements in a equal elements in b.
 func Vequal(a, b Vector) bool {
   if len(a) != len(b) {
@@ -214,3 +218,23 @@ func Vnorm(a Vector) Scalar {
   r.Vnorm(a)
   return r
 }
+
+// log(sum_i exp(a_i)) computed without overflow
+func LogSumExp(a Vector) Scalar {
+  r := NullScalar(a.ElementType())
+  r.LogSumExp(a)
+  return r
+}
+
+// Maximum value of a, which is subtracted before exponentiating. Infinite
+// values are replaced by zero.
+func logSumExpMax(a Vector) float64 {
+  m := math.Inf(-1)
+  for i := 0; i < len(a); i++ {
+    m = math.Max(m, a[i].GetValue())
+  }
+  if math.IsInf(m, 0) {
+    return 0.0
+  }
+  return m
+}